@@ -9,7 +9,11 @@ import (
 	"github.com/iurikman/cashFlowManager/internal/broker"
 	"github.com/iurikman/cashFlowManager/internal/config"
 	"github.com/iurikman/cashFlowManager/internal/converter"
+	"github.com/iurikman/cashFlowManager/internal/jwks"
 	"github.com/iurikman/cashFlowManager/internal/jwtgenerator"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/password"
+	"github.com/iurikman/cashFlowManager/internal/ratelimit"
 	"github.com/iurikman/cashFlowManager/internal/rest"
 	"github.com/iurikman/cashFlowManager/internal/service"
 	"github.com/iurikman/cashFlowManager/internal/store"
@@ -43,18 +47,107 @@ func main() {
 
 	log.Info("successful migration")
 
-	xrConverter := converter.NewConverter(cfg.XRConverterHost)
+	rateProviders, err := converter.NewProviders(cfg.XRProviders, converter.ProvidersConfig{
+		CBRHost:         cfg.XRConverterHost,
+		ECBURL:          cfg.XRECBURL,
+		ECBHistoryURL:   cfg.XRECBHistoryURL,
+		StaticRatesFile: cfg.XRStaticRatesFile,
+		RequestTimeout:  cfg.XRRequestTimeout,
+	})
+	if err != nil {
+		log.Panicf("converter.NewProviders(cfg) err: %v", err)
+	}
+
+	xrConverter := converter.NewConverter(converter.Config{
+		RateTTL:         cfg.XRRateTTL,
+		RefreshInterval: cfg.XRRefreshInterval,
+	}, rateProviders, db)
+
+	jwtGenerator, err := jwtgenerator.NewJWTGenerator(jwtgenerator.Config{
+		KeyID:          cfg.JWTKeyID,
+		Issuer:         cfg.JWTIssuer,
+		Audience:       cfg.JWTAudience,
+		PrivateKeyFile: cfg.JWTPrivateKeyFile,
+		TTL:            cfg.AccessTokenTTL,
+	})
+	if err != nil {
+		log.Panicf("jwtgenerator.NewJWTGenerator(cfg) err: %v", err)
+	}
+
+	if cfg.JWTPrivateKeyFile == "" {
+		log.Warn("no JWT private key configured, issued tokens are signed with a key generated on start")
+	}
+
+	passwordHasher, err := password.NewHasher(password.Config{
+		Algorithm:     cfg.PasswordHashAlgorithm,
+		Argon2Time:    cfg.PasswordArgon2Time,
+		Argon2Memory:  cfg.PasswordArgon2Memory,
+		Argon2Threads: cfg.PasswordArgon2Threads,
+		BcryptCost:    cfg.PasswordBcryptCost,
+	})
+	if err != nil {
+		log.Panicf("password.NewHasher(cfg) err: %v", err)
+	}
 
-	transactionsProducer := broker.NewTransactionsProducer()
+	// The currencies are registered before the fee schedule, which is validated against them.
+	currencies, err := db.GetCurrencies(ctx)
+	if err != nil {
+		log.Panicf("db.GetCurrencies() err: %v", err)
+	}
 
-	svc := service.NewService(db, xrConverter, transactionsProducer)
+	models.SetCurrencies(currencies)
 
-	jwtGenerator := jwtgenerator.NewJWTGenerator()
+	fees, err := service.LoadFeeSchedule(cfg.FeesFile)
+	if err != nil {
+		log.Panicf("service.LoadFeeSchedule(cfg) err: %v", err)
+	}
+
+	svc := service.NewService(db, xrConverter, jwtGenerator, passwordHasher, service.Config{
+		IdempotencyKeyTTL:         cfg.IdempotencyKeyTTL,
+		LedgerCheckInterval:       cfg.LedgerCheckInterval,
+		HoldTTL:                   cfg.HoldTTL,
+		HoldsExpiryInterval:       cfg.HoldsExpiryInterval,
+		SchedulerInterval:         cfg.SchedulerInterval,
+		SchedulerBatchSize:        cfg.SchedulerBatchSize,
+		RefreshTokenTTL:           cfg.RefreshTokenTTL,
+		QuoteTTL:                  cfg.QuoteTTL,
+		Fees:                      fees,
+		FeeSettlementInterval:     cfg.FeeSettlementInterval,
+		CurrenciesRefreshInterval: cfg.CurrenciesRefreshInterval,
+		WalletRestorePeriod:       cfg.WalletRestorePeriod,
+		CleanerInterval:           cfg.CleanerInterval,
+		OutboxRetention:           cfg.OutboxRetention,
+		DormancyPeriod:            cfg.DormancyPeriod,
+		DormancyWarningPeriod:     cfg.DormancyWarningPeriod,
+		DormancyBatchSize:         cfg.DormancyBatchSize,
+	})
+
+	keySet, err := jwks.New(ctx, jwks.Config{
+		PublicKeyFiles:  cfg.JWTPublicKeyFiles,
+		JWKSURL:         cfg.JWKSURL,
+		JWKSFile:        cfg.JWKSFile,
+		RefreshInterval: cfg.JWKSRefreshInterval,
+	})
+	if err != nil {
+		log.Panicf("jwks.New(cfg) err: %v", err)
+	}
+
+	// Tokens issued by the service itself are verified along with those of external issuers.
+	keySet.Add(jwtGenerator.GetKeyID(), jwtGenerator.GetPublicKey())
 
 	srv, err := rest.NewServer(
-		rest.ServerConfig{BindAddress: cfg.BindAddress},
+		rest.ServerConfig{
+			BindAddress: cfg.BindAddress,
+			Issuer:      cfg.JWTIssuer,
+			Audience:    cfg.JWTAudience,
+			RateLimits: rest.RateLimits{
+				Default:    ratelimit.Limit{Rate: cfg.RateLimitRPS, Burst: cfg.RateLimitBurst},
+				Operations: ratelimit.Limit{Rate: cfg.RateLimitOperationsRPS, Burst: cfg.RateLimitOperationsBurst},
+				Auth:       ratelimit.Limit{Rate: cfg.RateLimitAuthRPS, Burst: cfg.RateLimitAuthBurst},
+			},
+		},
 		svc,
-		jwtGenerator.GetPublicKey(),
+		keySet,
 	)
 	if err != nil {
 		log.Panicf("rest.NewServer(cfg) err: %v", err)
@@ -64,9 +157,11 @@ func main() {
 
 	consumer := broker.NewConsumer(
 		db,
+		passwordHasher,
 		broker.ConsumerConfig{
-			KafkaBrokers: cfg.KafkaBrokers,
-			KafkaGroupID: cfg.KafkaGroupID,
+			KafkaBrokers:           cfg.KafkaBrokers,
+			KafkaGroupID:           cfg.KafkaGroupID,
+			RequireHashedPasswords: cfg.RequireHashedPasswords,
 		})
 
 	eg.Go(func() error {
@@ -78,6 +173,98 @@ func main() {
 	})
 	log.Info("consumer started")
 
+	transactionsProducer, err := broker.NewTransactionsProducer(cfg.KafkaAddress)
+	if err != nil {
+		log.Panicf("broker.NewTransactionsProducer(cfg) err: %v", err)
+	}
+
+	defer func() {
+		if err := transactionsProducer.Stop(); err != nil {
+			log.Warnf("transactionsProducer.Stop() err: %v", err)
+		}
+	}()
+
+	outboxRelay := broker.NewOutboxRelay(db, transactionsProducer, broker.OutboxRelayConfig{
+		PollInterval: cfg.OutboxPollInterval,
+		BatchSize:    cfg.OutboxBatchSize,
+		MaxAttempts:  cfg.OutboxMaxAttempts,
+		RetryBackoff: cfg.OutboxRetryBackoff,
+		ClaimTimeout: cfg.OutboxClaimTimeout,
+	})
+
+	eg.Go(func() error {
+		if err := outboxRelay.Start(ctx); err != nil {
+			return fmt.Errorf("outbox relay stopped: %w", err)
+		}
+
+		return nil
+	})
+	log.Info("outbox relay started")
+
+	eg.Go(func() error {
+		// Plaintext passwords left behind are still upgraded on login, so a failure doesn't stop the service.
+		if err := svc.MigratePlaintextPasswords(ctx); err != nil {
+			log.Errorf("password migration failed: %v", err)
+		}
+
+		return nil
+	})
+	log.Info("password migration started")
+
+	eg.Go(func() error {
+		if err := keySet.Start(ctx); err != nil {
+			return fmt.Errorf("jwks refresher stopped: %w", err)
+		}
+
+		return nil
+	})
+	log.Info("jwks refresher started")
+
+	eg.Go(func() error {
+		if err := xrConverter.StartRefresher(ctx); err != nil {
+			return fmt.Errorf("exchange rates refresher stopped: %w", err)
+		}
+
+		return nil
+	})
+	log.Info("exchange rates refresher started")
+
+	eg.Go(func() error {
+		if err := svc.StartLedgerAuditor(ctx); err != nil {
+			return fmt.Errorf("ledger auditor stopped: %w", err)
+		}
+
+		return nil
+	})
+	log.Info("ledger auditor started")
+
+	eg.Go(func() error {
+		if err := svc.StartFeeSettler(ctx); err != nil {
+			return fmt.Errorf("fee settler stopped: %w", err)
+		}
+
+		return nil
+	})
+	log.Info("fee settler started")
+
+	eg.Go(func() error {
+		if err := svc.StartHoldsExpirer(ctx); err != nil {
+			return fmt.Errorf("holds expirer stopped: %w", err)
+		}
+
+		return nil
+	})
+	log.Info("holds expirer started")
+
+	eg.Go(func() error {
+		if err := svc.StartScheduler(ctx); err != nil {
+			return fmt.Errorf("scheduler stopped: %w", err)
+		}
+
+		return nil
+	})
+	log.Info("scheduler started")
+
 	eg.Go(func() error {
 		if err := svc.StartCleaner(ctx); err != nil {
 			return fmt.Errorf("cleaner stopped: %w", err)
@@ -87,6 +274,15 @@ func main() {
 	})
 	log.Info("cleaner started")
 
+	eg.Go(func() error {
+		if err := svc.StartCurrenciesRefresher(ctx); err != nil {
+			return fmt.Errorf("currencies refresher stopped: %w", err)
+		}
+
+		return nil
+	})
+	log.Info("currencies refresher started")
+
 	eg.Go(func() error {
 		if err := srv.Start(ctx); err != nil {
 			return fmt.Errorf("server stopped: %w", err)
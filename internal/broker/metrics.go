@@ -0,0 +1,55 @@
+package broker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type metrics struct {
+	outboxPublished     *prometheus.CounterVec
+	outboxFailures      *prometheus.CounterVec
+	outboxDeadLetters   *prometheus.CounterVec
+	outboxDeliveryDelay *prometheus.HistogramVec
+}
+
+func newMetrics() *metrics {
+	const (
+		namespace = "outbox"
+		subsystem = "wallet_service"
+	)
+
+	return &metrics{
+		outboxPublished: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "published_total",
+			Help:      "outbox messages published to kafka",
+		},
+			[]string{"topic"},
+		),
+		outboxFailures: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "publish_failures_total",
+			Help:      "failed attempts to publish outbox messages",
+		},
+			[]string{"topic"},
+		),
+		outboxDeadLetters: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "dead_letters_total",
+			Help:      "outbox messages given up on after the maximum number of attempts",
+		},
+			[]string{"topic"},
+		),
+		outboxDeliveryDelay: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "delivery_delay_seconds",
+			Help:      "time between saving an outbox message and publishing it",
+		},
+			[]string{"topic"},
+		),
+	}
+}
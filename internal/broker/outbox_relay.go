@@ -0,0 +1,116 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+type OutboxRelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	RetryBackoff time.Duration
+	// ClaimTimeout is how long a claimed message is left to its relay before another one retries it.
+	ClaimTimeout time.Duration
+}
+
+type outboxStore interface {
+	ClaimOutboxMessages(ctx context.Context, limit, maxAttempts int, claimedUntil time.Time) ([]*models.OutboxMessage, error)
+	MarkOutboxMessagePublished(ctx context.Context, id int64) error
+	MarkOutboxMessageFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error
+	DeadLetterOutboxMessages(ctx context.Context, maxAttempts int) ([]*models.OutboxMessage, error)
+}
+
+//go:generate mockery --name publisher --exported
+type publisher interface {
+	Publish(ctx context.Context, message models.OutboxMessage) error
+}
+
+// OutboxRelay publishes messages saved to the outbox table. A message is marked as published
+// only after the broker acknowledged it, so delivery is at least once.
+type OutboxRelay struct {
+	db        outboxStore
+	publisher publisher
+	cfg       OutboxRelayConfig
+	metrics   *metrics
+}
+
+func NewOutboxRelay(db outboxStore, publisher publisher, cfg OutboxRelayConfig) *OutboxRelay {
+	return &OutboxRelay{
+		db:        db,
+		publisher: publisher,
+		cfg:       cfg,
+		metrics:   newMetrics(),
+	}
+}
+
+func (r *OutboxRelay) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.PublishPending(ctx); err != nil {
+			log.Errorf("outbox relay failed: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// PublishPending claims one batch of due messages and publishes them. No DB transaction or row
+// lock is held while the broker is written to. Failed messages are rescheduled with exponential
+// backoff until MaxAttempts is reached, then they are dead-lettered.
+func (r *OutboxRelay) PublishPending(ctx context.Context) error {
+	deadLetters, err := r.db.DeadLetterOutboxMessages(ctx, r.cfg.MaxAttempts)
+	if err != nil {
+		return fmt.Errorf("r.db.DeadLetterOutboxMessages() err: %w", err)
+	}
+
+	for _, message := range deadLetters {
+		r.metrics.outboxDeadLetters.WithLabelValues(message.Topic).Inc()
+		log.Errorf(
+			"outbox message %d with key %s to %s dead-lettered after %d attempts: %s",
+			message.ID, message.Key, message.Topic, message.Attempts, message.LastError,
+		)
+	}
+
+	messages, err := r.db.ClaimOutboxMessages(ctx, r.cfg.BatchSize, r.cfg.MaxAttempts, time.Now().Add(r.cfg.ClaimTimeout))
+	if err != nil {
+		return fmt.Errorf("r.db.ClaimOutboxMessages() err: %w", err)
+	}
+
+	for _, message := range messages {
+		if err := r.publisher.Publish(ctx, *message); err != nil {
+			r.metrics.outboxFailures.WithLabelValues(message.Topic).Inc()
+			log.Warnf("outbox message %d publishing err: %v", message.ID, err)
+
+			if err := r.db.MarkOutboxMessageFailed(ctx, message.ID, err.Error(), r.nextAttemptAt(message.Attempts)); err != nil {
+				return fmt.Errorf("r.db.MarkOutboxMessageFailed() err: %w", err)
+			}
+
+			continue
+		}
+
+		if err := r.db.MarkOutboxMessagePublished(ctx, message.ID); err != nil {
+			return fmt.Errorf("r.db.MarkOutboxMessagePublished() err: %w", err)
+		}
+
+		r.metrics.outboxPublished.WithLabelValues(message.Topic).Inc()
+		r.metrics.outboxDeliveryDelay.WithLabelValues(message.Topic).Observe(time.Since(message.CreatedAt).Seconds())
+	}
+
+	return nil
+}
+
+func (r *OutboxRelay) nextAttemptAt(attempts int) time.Time {
+	const maxBackoffShift = 10
+
+	return time.Now().Add(r.cfg.RetryBackoff << min(attempts, maxBackoffShift))
+}
@@ -2,62 +2,53 @@ package broker
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net"
+	"time"
 
-	"github.com/iurikman/cashFlowManager/internal/config"
 	"github.com/iurikman/cashFlowManager/internal/models"
 	"github.com/segmentio/kafka-go"
 	log "github.com/sirupsen/logrus"
 )
 
-const transactionsTopic = "transactions"
-
 type TransactionsProducer struct {
 	kafkaWriter *kafka.Writer
 }
 
-func NewTransactionsProducer() *TransactionsProducer {
-	cfg := config.NewConfig()
-
-	address, err := net.ResolveTCPAddr("tcp", cfg.KafkaAddress)
+func NewTransactionsProducer(kafkaAddress string) (*TransactionsProducer, error) {
+	address, err := net.ResolveTCPAddr("tcp", kafkaAddress)
 	if err != nil {
-		log.Warnf("Could not resolve Kafka address: %s", err)
-
-		return nil
+		return nil, fmt.Errorf("could not resolve Kafka address: %w", err)
 	}
 
 	return &TransactionsProducer{kafkaWriter: &kafka.Writer{
 		Addr:         address,
-		Topic:        transactionsTopic,
 		Balancer:     &kafka.LeastBytes{},
-		RequiredAcks: 1,
-		Async:        true,
-	}}
+		RequiredAcks: kafka.RequireAll,
+		// Publish writes one message at a time and waits for it, so there is nothing to batch:
+		// the default batching would hold every message for up to a second.
+		BatchSize:    1,
+		BatchTimeout: time.Millisecond,
+	}}, nil
 }
 
-func (p *TransactionsProducer) ProduceTransaction(ctx context.Context, transaction models.Transaction) error {
-	key, err := json.Marshal(transaction.TransactionID)
-	if err != nil {
-		return fmt.Errorf("could not marshal transaction id: %w", err)
-	}
-
-	payload, err := json.Marshal(transaction)
-	if err != nil {
-		return fmt.Errorf("could not marshal transaction: %w", err)
-	}
-
-	if err = p.kafkaWriter.WriteMessages(ctx, kafka.Message{
-		Key:   key,
-		Value: payload,
+// Publish synchronously writes the outbox message to its topic and returns once the broker acknowledged it.
+func (p *TransactionsProducer) Publish(ctx context.Context, message models.OutboxMessage) error {
+	if err := p.kafkaWriter.WriteMessages(ctx, kafka.Message{
+		Topic: message.Topic,
+		Key:   []byte(message.Key),
+		Value: message.Payload,
 	}); err != nil {
 		return fmt.Errorf("could not write messages: %w", err)
 	}
 
-	log.Infof("transaction # %s produced", transaction.TransactionID)
+	log.Infof("message %s produced to %s", message.Key, message.Topic)
+
+	return nil
+}
 
-	if err = p.kafkaWriter.Close(); err != nil {
+func (p *TransactionsProducer) Stop() error {
+	if err := p.kafkaWriter.Close(); err != nil {
 		return fmt.Errorf("could not close kafkaWriter: %w", err)
 	}
 
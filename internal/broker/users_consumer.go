@@ -7,6 +7,7 @@ import (
 	"io"
 
 	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/password"
 	"github.com/iurikman/cashFlowManager/internal/store"
 	"github.com/segmentio/kafka-go"
 	log "github.com/sirupsen/logrus"
@@ -19,21 +20,27 @@ const (
 type ConsumerConfig struct {
 	KafkaBrokers []string
 	KafkaGroupID string
+	// RequireHashedPasswords makes the consumer skip users with plaintext passwords instead of hashing them.
+	RequireHashedPasswords bool
 }
 
 type Consumer struct {
 	reader *kafka.Reader
 	db     *store.Postgres
+	hasher *password.Hasher
+	cfg    ConsumerConfig
 }
 
-func NewConsumer(db *store.Postgres, consumerConfig ConsumerConfig) *Consumer {
+func NewConsumer(db *store.Postgres, hasher *password.Hasher, consumerConfig ConsumerConfig) *Consumer {
 	return &Consumer{
 		reader: kafka.NewReader(kafka.ReaderConfig{
 			Brokers: consumerConfig.KafkaBrokers,
 			Topic:   userUpdatesTopic,
 			GroupID: consumerConfig.KafkaGroupID,
 		}),
-		db: db,
+		db:     db,
+		hasher: hasher,
+		cfg:    consumerConfig,
 	}
 }
 
@@ -79,6 +86,20 @@ func (c *Consumer) Start(ctx context.Context) error {
 				user.Deleted,
 			)
 
+			if !password.IsHash(user.Password) {
+				if c.cfg.RequireHashedPasswords {
+					log.Warnf("skipping user %v: password is not hashed", user.ID)
+
+					continue
+				}
+
+				if user.Password, err = c.hasher.Hash(user.Password); err != nil {
+					log.Warnf("c.hasher.Hash(...) err: %s", err)
+
+					continue
+				}
+			}
+
 			if err = c.db.UpsertUser(ctx, user); err != nil {
 				log.Warnf("c.db.UpsertUser(...) err: %s", err)
 			}
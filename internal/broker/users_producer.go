@@ -11,12 +11,14 @@ import (
 	"github.com/google/uuid"
 	"github.com/iurikman/cashFlowManager/internal/config"
 	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/password"
 	"github.com/segmentio/kafka-go"
 	log "github.com/sirupsen/logrus"
 )
 
 type Producer struct {
 	kafkaWriter *kafka.Writer
+	hasher      *password.Hasher
 }
 
 func NewProducer() *Producer {
@@ -29,13 +31,29 @@ func NewProducer() *Producer {
 		return nil
 	}
 
-	return &Producer{kafkaWriter: &kafka.Writer{
-		Addr:         address,
-		Topic:        userUpdatesTopic,
-		Balancer:     &kafka.LeastBytes{},
-		RequiredAcks: 1,
-		Async:        true,
-	}}
+	hasher, err := password.NewHasher(password.Config{
+		Algorithm:     cfg.PasswordHashAlgorithm,
+		Argon2Time:    cfg.PasswordArgon2Time,
+		Argon2Memory:  cfg.PasswordArgon2Memory,
+		Argon2Threads: cfg.PasswordArgon2Threads,
+		BcryptCost:    cfg.PasswordBcryptCost,
+	})
+	if err != nil {
+		log.Warnf("Failed to create password hasher: %s", err)
+
+		return nil
+	}
+
+	return &Producer{
+		kafkaWriter: &kafka.Writer{
+			Addr:         address,
+			Topic:        userUpdatesTopic,
+			Balancer:     &kafka.LeastBytes{},
+			RequiredAcks: 1,
+			Async:        true,
+		},
+		hasher: hasher,
+	}
 }
 
 func (p *Producer) Start(ctx context.Context) error {
@@ -46,7 +64,14 @@ func (p *Producer) Start(ctx context.Context) error {
 		user.Username = "User " + strconv.Itoa(i)
 		user.Email = "email" + strconv.Itoa(i)
 		user.Phone = "phone" + strconv.Itoa(i)
-		user.Password = "userPassword" + strconv.Itoa(i)
+
+		// Passwords never leave the producer in plaintext.
+		hash, err := p.hasher.Hash("userPassword" + strconv.Itoa(i))
+		if err != nil {
+			return fmt.Errorf("failed to hash password: %w", err)
+		}
+
+		user.Password = hash
 		user.Wallets = nil
 		user.CreatedAt = time.Now()
 		user.Deleted = false
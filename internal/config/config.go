@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	log "github.com/sirupsen/logrus"
@@ -22,7 +23,68 @@ type Config struct {
 	KafkaBalancer string   `env:"KAFKA_BALANCER" env-default:"least_bytes"`
 	KafkaAddress  string   `env:"KAFKA_ADDRESS" env-default:"127.0.0.1:9092"`
 
-	XRConverterHost string `env:"XR_CONVERTER_HOST" env-default:"http://www.cbr.ru/"`
+	JWTPublicKeyFiles   []string      `env:"JWT_PUBLIC_KEY_FILES"`
+	JWKSURL             string        `env:"JWKS_URL"`
+	JWKSFile            string        `env:"JWKS_FILE"`
+	JWKSRefreshInterval time.Duration `env:"JWKS_REFRESH_INTERVAL" env-default:"1h"`
+	JWTIssuer           string        `env:"JWT_ISSUER"`
+	JWTAudience         string        `env:"JWT_AUDIENCE"`
+	JWTKeyID            string        `env:"JWT_KEY_ID" env-default:"local"`
+	JWTPrivateKeyFile   string        `env:"JWT_PRIVATE_KEY_FILE"`
+	AccessTokenTTL      time.Duration `env:"ACCESS_TOKEN_TTL" env-default:"15m"`
+	RefreshTokenTTL     time.Duration `env:"REFRESH_TOKEN_TTL" env-default:"720h"`
+
+	PasswordHashAlgorithm  string `env:"PASSWORD_HASH_ALGORITHM" env-default:"argon2id"`
+	PasswordArgon2Time     uint32 `env:"PASSWORD_ARGON2_TIME" env-default:"3"`
+	PasswordArgon2Memory   uint32 `env:"PASSWORD_ARGON2_MEMORY" env-default:"65536"`
+	PasswordArgon2Threads  uint8  `env:"PASSWORD_ARGON2_THREADS" env-default:"2"`
+	PasswordBcryptCost     int    `env:"PASSWORD_BCRYPT_COST" env-default:"12"`
+	RequireHashedPasswords bool   `env:"REQUIRE_HASHED_PASSWORDS" env-default:"false"`
+
+	RateLimitRPS             float64 `env:"RATE_LIMIT_RPS" env-default:"20"`
+	RateLimitBurst           int     `env:"RATE_LIMIT_BURST" env-default:"40"`
+	RateLimitOperationsRPS   float64 `env:"RATE_LIMIT_OPERATIONS_RPS" env-default:"5"`
+	RateLimitOperationsBurst int     `env:"RATE_LIMIT_OPERATIONS_BURST" env-default:"10"`
+	RateLimitAuthRPS         float64 `env:"RATE_LIMIT_AUTH_RPS" env-default:"1"`
+	RateLimitAuthBurst       int     `env:"RATE_LIMIT_AUTH_BURST" env-default:"10"`
+
+	// XRProviders are tried in order: cbr, ecb and static.
+	XRProviders       []string      `env:"XR_PROVIDERS" env-default:"cbr,ecb"`
+	XRConverterHost   string        `env:"XR_CONVERTER_HOST" env-default:"http://www.cbr.ru/"`
+	XRECBURL          string        `env:"XR_ECB_URL" env-default:"https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"`
+	XRECBHistoryURL   string        `env:"XR_ECB_HISTORY_URL" env-default:"https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist-90d.xml"`
+	XRStaticRatesFile string        `env:"XR_STATIC_RATES_FILE"`
+	XRRateTTL         time.Duration `env:"XR_RATE_TTL" env-default:"1h"`
+	XRRefreshInterval time.Duration `env:"XR_REFRESH_INTERVAL" env-default:"30m"`
+	XRRequestTimeout  time.Duration `env:"XR_REQUEST_TIMEOUT" env-default:"10s"`
+	QuoteTTL          time.Duration `env:"FX_QUOTE_TTL" env-default:"1m"`
+	FeesFile          string        `env:"FX_FEES_FILE"`
+	// FeeSettlementInterval is how often the collected fees are credited to the revenue wallets.
+	FeeSettlementInterval time.Duration `env:"FX_FEE_SETTLEMENT_INTERVAL" env-default:"1m"`
+	// CurrenciesRefreshInterval is how soon currency changes made through other instances apply.
+	CurrenciesRefreshInterval time.Duration `env:"CURRENCIES_REFRESH_INTERVAL" env-default:"1m"`
+
+	IdempotencyKeyTTL   time.Duration `env:"IDEMPOTENCY_KEY_TTL" env-default:"24h"`
+	LedgerCheckInterval time.Duration `env:"LEDGER_CHECK_INTERVAL" env-default:"1h"`
+	HoldTTL             time.Duration `env:"HOLD_TTL" env-default:"168h"`
+	HoldsExpiryInterval time.Duration `env:"HOLDS_EXPIRY_INTERVAL" env-default:"1m"`
+	SchedulerInterval   time.Duration `env:"SCHEDULER_INTERVAL" env-default:"30s"`
+	SchedulerBatchSize  int           `env:"SCHEDULER_BATCH_SIZE" env-default:"100"`
+	// WalletRestorePeriod is how long a deleted wallet can be restored before it is purged.
+	WalletRestorePeriod time.Duration `env:"WALLET_RESTORE_PERIOD" env-default:"720h"`
+	CleanerInterval     time.Duration `env:"CLEANER_INTERVAL" env-default:"1m"`
+	// DormancyPeriod is how long an empty wallet stays inactive before it becomes dormant, 0 disables it.
+	DormancyPeriod        time.Duration `env:"DORMANCY_PERIOD" env-default:"8760h"`
+	DormancyWarningPeriod time.Duration `env:"DORMANCY_WARNING_PERIOD" env-default:"720h"`
+	DormancyBatchSize     int           `env:"DORMANCY_BATCH_SIZE" env-default:"100"`
+
+	OutboxPollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" env-default:"1s"`
+	OutboxBatchSize    int           `env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+	OutboxMaxAttempts  int           `env:"OUTBOX_MAX_ATTEMPTS" env-default:"10"`
+	OutboxRetryBackoff time.Duration `env:"OUTBOX_RETRY_BACKOFF" env-default:"1s"`
+	OutboxClaimTimeout time.Duration `env:"OUTBOX_CLAIM_TIMEOUT" env-default:"1m"`
+	// OutboxRetention is how long published outbox messages are kept before they are deleted.
+	OutboxRetention time.Duration `env:"OUTBOX_RETENTION" env-default:"168h"`
 }
 
 func NewConfig() Config {
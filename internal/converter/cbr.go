@@ -0,0 +1,169 @@
+package converter
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+const (
+	currencyEndpoint = "scripts/XML_dynamic.asp?date_req1="
+	cbrDateFormat    = "02.01.2006"
+	// lookbackDays covers weekends and holidays, including the New Year ones, when the CBR publishes no rates.
+	lookbackDays = 14
+)
+
+var (
+	errNoRecords           = errors.New("no rate records")
+	errUnsupportedEncoding = errors.New("unsupported encoding")
+)
+
+// CBRProvider fetches rouble rates from the Central Bank of Russia.
+type CBRProvider struct {
+	host   string
+	client *http.Client
+}
+
+type exRate struct {
+	ID         string   `xml:"ID,attr"`
+	DateRange1 string   `xml:"DateRange1,attr"`
+	DateRange2 string   `xml:"DateRange2,attr"`
+	Name       string   `xml:"name,attr"`
+	Records    []Record `xml:"Record"`
+}
+
+type Record struct {
+	Date      string `xml:"Date,attr"`
+	ID        string `xml:"Id,attr"`
+	Nominal   int    `xml:"Nominal"`
+	Value     string `xml:"Value"`
+	VunitRate string `xml:"VunitRate"`
+}
+
+func NewCBRProvider(host string, client *http.Client) *CBRProvider {
+	return &CBRProvider{host: host, client: client}
+}
+
+func (p *CBRProvider) Name() string {
+	return models.RateSourceCBR
+}
+
+func (p *CBRProvider) Base() string {
+	return "RUR"
+}
+
+// FetchRate requests the rates of the days before the date and returns the latest one. The response only
+// has records for the days the CBR published rates on, so weekends and holidays fall back to the last
+// working day.
+func (p *CBRProvider) FetchRate(ctx context.Context, currency string, date time.Time) (*models.ExchangeRate, error) {
+	currencyCode, err := models.GetCurrencyProviderID(currency, models.RateSourceCBR)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get currency code: %w", err)
+	}
+
+	if currencyCode == "" {
+		return nil, errUnsupportedCurrency
+	}
+
+	dateFrom := date.AddDate(0, 0, -lookbackDays)
+
+	reqURLString := p.host + currencyEndpoint + dateFrom.Format("02/01/2006") +
+		"&date_req2=" + date.Format("02/01/2006") + "&VAL_NM_RQ=" + currencyCode
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURLString, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest(\"GET\", reqURLString, nil) err: %w", err)
+	}
+
+	req.Header.Set("User-Agent", "YourAppName/1.0")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("p.client.Do(req) err: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d", errUnexpectedStatus, resp.StatusCode)
+	}
+
+	reader := transform.NewReader(resp.Body, charmap.Windows1251.NewDecoder())
+
+	decoder := xml.NewDecoder(reader)
+	decoder.CharsetReader = func(encoding string, input io.Reader) (io.Reader, error) {
+		if encoding != "windows-1251" {
+			return nil, fmt.Errorf("%w: %s", errUnsupportedEncoding, encoding)
+		}
+
+		return transform.NewReader(input, charmap.Windows1251.NewDecoder()), nil
+	}
+
+	var exRate exRate
+	if err := decoder.Decode(&exRate); err != nil {
+		return nil, fmt.Errorf("xml.Unmarshal(err): %w", err)
+	}
+
+	record, recordDate, err := latestRecord(exRate.Records, date)
+	if err != nil {
+		return nil, fmt.Errorf("%w (currencyCode was %s)", err, currencyCode)
+	}
+
+	rate, err := models.ParseDecimal(strings.ReplaceAll(record.Value, ",", "."))
+	if err != nil {
+		return nil, fmt.Errorf("models.ParseDecimal(record.Value) err: %w", err)
+	}
+
+	if record.Nominal > 1 {
+		rate, err = rate.Div(models.NewDecimalFromInt(int64(record.Nominal)))
+		if err != nil {
+			return nil, fmt.Errorf("rate.Div(nominal) err: %w", err)
+		}
+	}
+
+	return &models.ExchangeRate{
+		Currency:  currency,
+		Base:      p.Base(),
+		Date:      recordDate,
+		Rate:      rate,
+		Source:    p.Name(),
+		FetchedAt: time.Now(),
+	}, nil
+}
+
+// latestRecord returns the latest record published on or before the date. The CBR sets the rates
+// of the next working day in advance, so the response may have records dated after it.
+func latestRecord(records []Record, date time.Time) (*Record, time.Time, error) {
+	var (
+		latest     *Record
+		latestDate time.Time
+	)
+
+	for i := range records {
+		recordDate, err := time.Parse(cbrDateFormat, records[i].Date)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("time.Parse(record.Date) err: %w", err)
+		}
+
+		if recordDate.After(date) || recordDate.Before(latestDate) {
+			continue
+		}
+
+		latest, latestDate = &records[i], recordDate
+	}
+
+	if latest == nil {
+		return nil, time.Time{}, errNoRecords
+	}
+
+	return latest, latestDate, nil
+}
@@ -2,139 +2,371 @@ package converter
 
 import (
 	"context"
-	"encoding/xml"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"strconv"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/iurikman/cashFlowManager/internal/models"
-	"golang.org/x/text/encoding/charmap"
-	"golang.org/x/text/transform"
+	log "github.com/sirupsen/logrus"
 )
 
-const currencyEndpoint = "scripts/XML_dynamic.asp?date_req1="
+// staleRateRetry is how long a stale stored rate is used, and a failed provider is skipped,
+// before the provider is asked again.
+const staleRateRetry = time.Minute
 
-type Converter struct {
-	host string
+type Config struct {
+	// RateTTL is how long a fetched rate is used without asking the provider again.
+	RateTTL         time.Duration
+	RefreshInterval time.Duration
+}
+
+type rateStore interface {
+	SaveExchangeRate(ctx context.Context, rate models.ExchangeRate) error
+	GetLatestExchangeRate(ctx context.Context, source, currency string) (*models.ExchangeRate, error)
+	GetExchangeRateAt(ctx context.Context, source, currency string, date time.Time) (*models.ExchangeRate, error)
 }
 
-type exRate struct {
-	ID         string   `xml:"ID,attr"`
-	DateRange1 string   `xml:"DateRange1,attr"`
-	DateRange2 string   `xml:"DateRange2,attr"`
-	Name       string   `xml:"name,attr"`
-	Records    []Record `xml:"Record"`
+// Converter converts amounts at the rates of a chain of providers. Rates are cached in memory and
+// persisted, so that conversions don't wait for the providers and keep working while they are down.
+type Converter struct {
+	cfg       Config
+	providers []Provider
+	store     rateStore
+
+	mu       sync.RWMutex
+	cache    map[string]cachedRate
+	failedAt map[string]time.Time
 }
 
-type Record struct {
-	Date      string `xml:"Date,attr"`
-	ID        string `xml:"Id,attr"`
-	Nominal   int    `xml:"Nominal"`
-	Value     string `xml:"Value"`
-	VunitRate string `xml:"VunitRate"`
+type cachedRate struct {
+	rate      models.ExchangeRate
+	expiresAt time.Time
 }
 
+// lookup is how far getRate goes to find a rate.
+type lookup int
+
+const (
+	// lookupFresh only uses cached and stored rates within the TTL.
+	lookupFresh lookup = iota
+	// lookupFetch asks the provider when there is no fresh rate.
+	lookupFetch
+	// lookupStale uses the latest stored rate however old it is.
+	lookupStale
+)
+
 type Currency struct {
-	Amount float64
+	Amount models.Decimal
 	Name   string
 }
 
-func NewConverter(host string) *Converter {
+func NewConverter(cfg Config, providers []Provider, store rateStore) *Converter {
 	return &Converter{
-		host,
+		cfg:       cfg,
+		providers: providers,
+		store:     store,
+		cache:     make(map[string]cachedRate),
+		failedAt:  make(map[string]time.Time),
+	}
+}
+
+// Convert converts currencyFrom.Amount into currencyTo.Name, rounded to the minor units of the target
+// currency, and reports the rate applied and where it came from.
+func (c *Converter) Convert(ctx context.Context, currencyFrom, currencyTo Currency) (*models.Conversion, error) {
+	rateFrom, rateTo, err := c.getPairRates(ctx, currencyFrom.Name, currencyTo.Name)
+	if err != nil {
+		return nil, err
 	}
+
+	return conversion(currencyFrom, currencyTo, rateFrom, rateTo)
 }
 
-func (c *Converter) Convert(ctx context.Context, currencyFrom, currencyTo Currency) (float64, error) {
-	codeCurrFrom, err := models.GetCurrencyCode(currencyFrom.Name)
+// ConvertAt converts at the rates in effect on the date, which are the latest ones published on or
+// before it. Conversions at today's or later dates use the current rates.
+func (c *Converter) ConvertAt(ctx context.Context, currencyFrom, currencyTo Currency, date time.Time) (
+	*models.Conversion, error,
+) {
+	date = startOfDay(date)
+
+	if !date.Before(startOfDay(time.Now())) {
+		return c.Convert(ctx, currencyFrom, currencyTo)
+	}
+
+	rateFrom, rateTo, err := c.getPairRatesAt(ctx, currencyFrom.Name, currencyTo.Name, date)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get currency code: %w", err)
+		return nil, err
 	}
 
-	codeCurrTo, err := models.GetCurrencyCode(currencyTo.Name)
+	return conversion(currencyFrom, currencyTo, rateFrom, rateTo)
+}
+
+func conversion(currencyFrom, currencyTo Currency, rateFrom, rateTo *models.ExchangeRate) (*models.Conversion, error) {
+	rate, err := rateFrom.Rate.Div(rateTo.Rate)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get currency code: %w", err)
+		return nil, fmt.Errorf("rateFrom.Div(rateTo) err: %w", err)
 	}
 
-	switch {
-	case currencyTo.Name == "RUR":
-		changeRateCurrFrom, err := c.fetchRate(ctx, *codeCurrFrom)
-		if err != nil {
-			return 0, fmt.Errorf("c.fetchRate(codeCurrFrom) err: %w", err)
+	rateDate := rateFrom.Date
+	if rateTo.Date.Before(rateDate) {
+		rateDate = rateTo.Date
+	}
+
+	return &models.Conversion{
+		Amount:     currencyFrom.Amount.Mul(rate).RoundForCurrency(currencyTo.Name),
+		Rate:       rate,
+		RateSource: rateFrom.Source,
+		RateDate:   rateDate,
+	}, nil
+}
+
+// StartRefresher keeps the stored rates fresh, so that conversions rarely have to call the providers.
+func (c *Converter) StartRefresher(ctx context.Context) error {
+	ticker := time.NewTicker(c.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		c.refreshRates(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
 		}
+	}
+}
 
-		result := currencyFrom.Amount * changeRateCurrFrom
+func (c *Converter) refreshRates(ctx context.Context) {
+	for _, provider := range c.providers {
+		for _, currency := range models.GetCurrencies() {
+			if currency == provider.Base() {
+				continue
+			}
 
-		return result, nil
-	default:
-		changeRateCurrFrom, err := c.fetchRate(ctx, *codeCurrFrom)
-		if err != nil {
-			return 0, fmt.Errorf("c.fetchRate(codeCurrFrom) err: %w", err)
+			_, err := c.fetchAndSave(ctx, provider, currency)
+			if err != nil && !errors.Is(err, errUnsupportedCurrency) {
+				log.Warnf("failed to refresh %s exchange rate from %s: %v", currency, provider.Name(), err)
+			}
 		}
+	}
+}
+
+// getPairRates returns the rates of both currencies from the same provider, since rates of
+// different providers have different bases. Fresh rates of any provider are preferred to asking
+// the providers, and any rate fetched before is preferred to failing the conversion.
+func (c *Converter) getPairRates(ctx context.Context, from, to string) (
+	*models.ExchangeRate, *models.ExchangeRate, error,
+) {
+	var errs []error
+
+	for _, mode := range []lookup{lookupFresh, lookupFetch, lookupStale} {
+		for _, provider := range c.providers {
+			rateFrom, err := c.getRate(ctx, provider, from, mode)
+			if err == nil {
+				var rateTo *models.ExchangeRate
+
+				if rateTo, err = c.getRate(ctx, provider, to, mode); err == nil {
+					if mode == lookupStale {
+						log.Warnf("converting %s to %s at %s rates of %s and %s", from, to, provider.Name(),
+							rateFrom.Date.Format(time.DateOnly), rateTo.Date.Format(time.DateOnly))
+					}
 
-		changeRateCurrTo, err := c.fetchRate(ctx, *codeCurrTo)
-		if err != nil {
-			return 0, fmt.Errorf("c.fetchRate(codeCurrTo) err: %w", err)
+					return rateFrom, rateTo, nil
+				}
+			}
+
+			// Missing fresh rates are expected; the errors of providers that were asked explain the failure.
+			if mode == lookupFetch {
+				errs = append(errs, err)
+			}
 		}
+	}
+
+	return nil, nil, fmt.Errorf("%w: %s/%s: %w", models.ErrExchangeRateNotFound, from, to, errors.Join(errs...))
+}
+
+// getPairRatesAt returns the rates of both currencies in effect on a past date from the same provider.
+// Stored rates published on the date are used as is. Otherwise the providers are asked, since only they
+// know whether a rate was published between the stored one and the date, and the nearest stored rate
+// is preferred to failing the conversion.
+func (c *Converter) getPairRatesAt(ctx context.Context, from, to string, date time.Time) (
+	*models.ExchangeRate, *models.ExchangeRate, error,
+) {
+	var errs []error
+
+	for _, mode := range []lookup{lookupFetch, lookupStale} {
+		for _, provider := range c.providers {
+			rateFrom, err := c.getRateAt(ctx, provider, from, date, mode)
+			if err == nil {
+				var rateTo *models.ExchangeRate
 
-		result := (currencyFrom.Amount * changeRateCurrFrom) / changeRateCurrTo
+				if rateTo, err = c.getRateAt(ctx, provider, to, date, mode); err == nil {
+					return rateFrom, rateTo, nil
+				}
+			}
 
-		return result, nil
+			if mode == lookupFetch {
+				errs = append(errs, err)
+			}
+		}
 	}
+
+	return nil, nil, fmt.Errorf("%w: %s/%s on %s: %w", models.ErrExchangeRateNotFound, from, to,
+		date.Format(time.DateOnly), errors.Join(errs...))
 }
 
-//nolint:err113
-func (c *Converter) fetchRate(ctx context.Context, currencyCode string) (float64, error) {
-	date := time.Now().AddDate(0, 0, -2)
-	dateString := fmt.Sprintf("%02d/%02d/%d", date.Day(), date.Month(), date.Year())
+func (c *Converter) getRateAt(ctx context.Context, provider Provider, currency string, date time.Time, mode lookup) (
+	*models.ExchangeRate, error,
+) {
+	if _, ok := models.LookupCurrency(currency); !ok {
+		return nil, models.ErrCurrencyNotAllowed
+	}
+
+	if currency == provider.Base() {
+		return baseRate(provider, currency, date), nil
+	}
+
+	stored, err := c.store.GetExchangeRateAt(ctx, provider.Name(), currency, date)
+	if err == nil && stored.Date.Equal(date) {
+		return stored, nil
+	}
 
-	reqURLString := c.host + currencyEndpoint + dateString + "&date_req2=" + dateString + "&VAL_NM_RQ=" + currencyCode
+	if mode == lookupFetch {
+		return c.fetch(ctx, provider, currency, date)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURLString, nil)
 	if err != nil {
-		return 0, fmt.Errorf("http.NewRequest(\"GET\", reqURLString, nil) err: %w", err)
+		return nil, fmt.Errorf("c.store.GetExchangeRateAt(%s, %s) err: %w", provider.Name(), currency, err)
 	}
 
-	req.Header.Set("User-Agent", "YourAppName/1.0")
+	log.Warnf("using %s rate of %s of %s for %s", provider.Name(), currency,
+		stored.Date.Format(time.DateOnly), date.Format(time.DateOnly))
 
-	client := &http.Client{}
+	return stored, nil
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, fmt.Errorf("client.Do(req) err: %w", err)
+// baseRate is the rate of the base currency of the provider, which is one on any date.
+func baseRate(provider Provider, currency string, date time.Time) *models.ExchangeRate {
+	return &models.ExchangeRate{
+		Currency:  currency,
+		Base:      currency,
+		Date:      startOfDay(date),
+		Rate:      models.NewDecimalFromInt(1),
+		Source:    provider.Name(),
+		FetchedAt: time.Now(),
 	}
+}
 
-	defer resp.Body.Close()
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
 
-	reader := transform.NewReader(resp.Body, charmap.Windows1251.NewDecoder())
+func (c *Converter) getRate(ctx context.Context, provider Provider, currency string, mode lookup) (
+	*models.ExchangeRate, error,
+) {
+	if _, ok := models.LookupCurrency(currency); !ok {
+		return nil, models.ErrCurrencyNotAllowed
+	}
 
-	decoder := xml.NewDecoder(reader)
-	decoder.CharsetReader = func(encoding string, input io.Reader) (io.Reader, error) {
-		if encoding != "windows-1251" {
-			return nil, fmt.Errorf("unsupported encoding: %s", encoding)
-		}
+	if currency == provider.Base() {
+		return baseRate(provider, currency, time.Now()), nil
+	}
+
+	if rate, ok := c.getCached(provider.Name(), currency); ok {
+		return rate, nil
+	}
 
-		return transform.NewReader(input, charmap.Windows1251.NewDecoder()), nil
+	if mode == lookupFetch {
+		return c.fetchAndSave(ctx, provider, currency)
 	}
 
-	var exRate exRate
-	if err := decoder.Decode(&exRate); err != nil {
-		return 0, fmt.Errorf("xml.Unmarshal(err): %w", err)
+	stored, err := c.store.GetLatestExchangeRate(ctx, provider.Name(), currency)
+	if err != nil {
+		return nil, fmt.Errorf("c.store.GetLatestExchangeRate(%s, %s) err: %w", provider.Name(), currency, err)
 	}
 
-	if len(exRate.Records) == 0 {
-		return 0, fmt.Errorf("len(exRate.Records) == 0 (currencyCode was %s)", currencyCode)
+	switch {
+	case time.Since(stored.FetchedAt) < c.cfg.RateTTL:
+		c.setCached(*stored, stored.FetchedAt.Add(c.cfg.RateTTL))
+	case mode == lookupStale:
+		c.setCached(*stored, time.Now().Add(staleRateRetry))
+	default:
+		return nil, fmt.Errorf("%w: %s rate of %s is stale", models.ErrExchangeRateNotFound, currency, provider.Name())
 	}
 
-	value := strings.ReplaceAll(exRate.Records[0].Value, ",", ".")
+	return stored, nil
+}
 
-	rate, err := strconv.ParseFloat(value, 64)
+// fetchAndSave fetches the latest rate and caches it.
+func (c *Converter) fetchAndSave(ctx context.Context, provider Provider, currency string) (*models.ExchangeRate, error) {
+	rate, err := c.fetch(ctx, provider, currency, time.Now())
 	if err != nil {
-		return 0, fmt.Errorf("strconv.ParseFloat(exRate.Records[0].Value, 64) err: %w", err)
+		return nil, err
+	}
+
+	c.setCached(*rate, rate.FetchedAt.Add(c.cfg.RateTTL))
+
+	return rate, nil
+}
+
+// fetch asks the provider for the rate in effect on the date and persists it.
+func (c *Converter) fetch(ctx context.Context, provider Provider, currency string, date time.Time) (
+	*models.ExchangeRate, error,
+) {
+	if c.isFailing(provider.Name()) {
+		return nil, fmt.Errorf("%w: %s failed recently", models.ErrExchangeRateNotFound, provider.Name())
+	}
+
+	rate, err := provider.FetchRate(ctx, currency, date)
+
+	switch {
+	// A provider without the rate, as for dates before its history, is working and isn't skipped.
+	case errors.Is(err, errUnsupportedCurrency), errors.Is(err, errNoRecords):
+		return nil, fmt.Errorf("%s: %w", provider.Name(), err)
+	case err != nil:
+		c.setFailed(provider.Name())
+
+		return nil, fmt.Errorf("%s: provider.FetchRate(%s) err: %w", provider.Name(), currency, err)
+	}
+
+	if err = c.store.SaveExchangeRate(ctx, *rate); err != nil {
+		log.Warnf("c.store.SaveExchangeRate(%s) err: %v", currency, err)
 	}
 
 	return rate, nil
 }
+
+func (c *Converter) getCached(source, currency string) (*models.ExchangeRate, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cached, ok := c.cache[source+":"+currency]
+	if !ok || time.Now().After(cached.expiresAt) {
+		return nil, false
+	}
+
+	rate := cached.rate
+
+	return &rate, true
+}
+
+func (c *Converter) setCached(rate models.ExchangeRate, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cache[rate.Source+":"+rate.Currency] = cachedRate{rate: rate, expiresAt: expiresAt}
+}
+
+func (c *Converter) isFailing(source string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return time.Since(c.failedAt[source]) < staleRateRetry
+}
+
+func (c *Converter) setFailed(source string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.failedAt[source] = time.Now()
+}
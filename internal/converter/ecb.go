@@ -0,0 +1,140 @@
+package converter
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+// ECBProvider fetches the euro reference rates of the European Central Bank. The daily feed only
+// has the latest rates, so the rates of past dates are read from the history feed.
+type ECBProvider struct {
+	url        string
+	historyURL string
+	client     *http.Client
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Cube []ecbDay `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+type ecbDay struct {
+	Time  string `xml:"time,attr"`
+	Rates []struct {
+		Currency string `xml:"currency,attr"`
+		Rate     string `xml:"rate,attr"`
+	} `xml:"Cube"`
+}
+
+func NewECBProvider(url, historyURL string, client *http.Client) *ECBProvider {
+	return &ECBProvider{url: url, historyURL: historyURL, client: client}
+}
+
+func (p *ECBProvider) Name() string {
+	return models.RateSourceECB
+}
+
+func (p *ECBProvider) Base() string {
+	return "EUR"
+}
+
+// FetchRate reads the rates of the latest day of the feed on or before the date. The feeds quote
+// currency units per euro, so the rate is inverted.
+func (p *ECBProvider) FetchRate(ctx context.Context, currency string, date time.Time) (*models.ExchangeRate, error) {
+	isoCode, err := models.GetCurrencyISOCode(currency)
+	if err != nil {
+		return nil, fmt.Errorf("models.GetCurrencyISOCode() err: %w", err)
+	}
+
+	url := p.url
+	if date.Before(startOfDay(time.Now())) {
+		url = p.historyURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequest(\"GET\", url, nil) err: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("p.client.Do(req) err: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d", errUnexpectedStatus, resp.StatusCode)
+	}
+
+	var envelope ecbEnvelope
+	if err = xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("xml.Decode() err: %w", err)
+	}
+
+	day, dayDate, err := envelope.latestDay(date)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, quote := range day.Rates {
+		if quote.Currency != isoCode {
+			continue
+		}
+
+		perEuro, err := models.ParseDecimal(quote.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("models.ParseDecimal(quote.Rate) err: %w", err)
+		}
+
+		rate, err := models.NewDecimalFromInt(1).Div(perEuro)
+		if err != nil {
+			return nil, fmt.Errorf("invert rate err: %w", err)
+		}
+
+		return &models.ExchangeRate{
+			Currency:  currency,
+			Base:      p.Base(),
+			Date:      dayDate,
+			Rate:      rate,
+			Source:    p.Name(),
+			FetchedAt: time.Now(),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", errUnsupportedCurrency, currency)
+}
+
+// latestDay returns the rates of the latest day on or before the date. The history feed lists the days
+// newest first, but the order isn't relied on.
+func (e ecbEnvelope) latestDay(date time.Time) (*ecbDay, time.Time, error) {
+	var (
+		latest     *ecbDay
+		latestDate time.Time
+	)
+
+	for i := range e.Cube.Cube {
+		dayDate, err := time.Parse(time.DateOnly, e.Cube.Cube[i].Time)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("time.Parse(day.Time) err: %w", err)
+		}
+
+		if dayDate.After(date) || dayDate.Before(latestDate) {
+			continue
+		}
+
+		latest, latestDate = &e.Cube.Cube[i], dayDate
+	}
+
+	if latest == nil {
+		return nil, time.Time{}, errNoRecords
+	}
+
+	return latest, latestDate, nil
+}
@@ -0,0 +1,61 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+var (
+	errUnknownProvider     = errors.New("unknown exchange rate provider")
+	errUnsupportedCurrency = errors.New("currency is not quoted by the provider")
+	errUnexpectedStatus    = errors.New("unexpected response status")
+)
+
+// Provider is a source of exchange rates. Rates are prices of one unit of a currency in the base
+// currency of the provider, so any pair is converted through the base.
+type Provider interface {
+	Name() string
+	Base() string
+	// FetchRate returns the rate of the currency in effect on the date, which is the latest one published
+	// on or before it; the base currency itself isn't requested.
+	FetchRate(ctx context.Context, currency string, date time.Time) (*models.ExchangeRate, error)
+}
+
+type ProvidersConfig struct {
+	CBRHost         string
+	ECBURL          string
+	ECBHistoryURL   string
+	StaticRatesFile string
+	RequestTimeout  time.Duration
+}
+
+// NewProviders creates the providers in the order they are tried in.
+func NewProviders(names []string, cfg ProvidersConfig) ([]Provider, error) {
+	client := &http.Client{Timeout: cfg.RequestTimeout}
+	providers := make([]Provider, 0, len(names))
+
+	for _, name := range names {
+		switch name {
+		case models.RateSourceCBR:
+			providers = append(providers, NewCBRProvider(cfg.CBRHost, client))
+		case models.RateSourceECB:
+			providers = append(providers, NewECBProvider(cfg.ECBURL, cfg.ECBHistoryURL, client))
+		case models.RateSourceStatic:
+			provider, err := NewStaticProvider(cfg.StaticRatesFile)
+			if err != nil {
+				return nil, fmt.Errorf("NewStaticProvider(%s) err: %w", cfg.StaticRatesFile, err)
+			}
+
+			providers = append(providers, provider)
+		default:
+			return nil, fmt.Errorf("%w: %q", errUnknownProvider, name)
+		}
+	}
+
+	return providers, nil
+}
@@ -0,0 +1,100 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+var errBaseChanged = errors.New("base currency of static rates has changed")
+
+// StaticProvider reads rates from a JSON file, a last resort that is maintained by hand:
+//
+//	{"base": "RUR", "date": "2026-10-16", "rates": {"USD": "96.5", "EUR": "104.2"}}
+//
+// The file is read on every fetch, so edits apply without a restart, except for the base currency.
+type StaticProvider struct {
+	path string
+	base string
+}
+
+type staticRates struct {
+	Base  string                    `json:"base"`
+	Date  string                    `json:"date"`
+	Rates map[string]models.Decimal `json:"rates"`
+}
+
+func NewStaticProvider(path string) (*StaticProvider, error) {
+	provider := &StaticProvider{path: path}
+
+	rates, err := provider.read()
+	if err != nil {
+		return nil, err
+	}
+
+	provider.base = rates.Base
+
+	return provider, nil
+}
+
+func (p *StaticProvider) Name() string {
+	return models.RateSourceStatic
+}
+
+func (p *StaticProvider) Base() string {
+	return p.base
+}
+
+// FetchRate returns the rate of the file, unless the file is dated after the date.
+func (p *StaticProvider) FetchRate(_ context.Context, currency string, date time.Time) (*models.ExchangeRate, error) {
+	rates, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if rates.Base != p.base {
+		return nil, fmt.Errorf("%w: %s instead of %s", errBaseChanged, rates.Base, p.base)
+	}
+
+	rate, ok := rates.Rates[currency]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedCurrency, currency)
+	}
+
+	ratesDate, err := time.Parse(time.DateOnly, rates.Date)
+	if err != nil {
+		return nil, fmt.Errorf("time.Parse(rates.Date) err: %w", err)
+	}
+
+	if ratesDate.After(date) {
+		return nil, fmt.Errorf("%w: static rates are of %s", errNoRecords, rates.Date)
+	}
+
+	return &models.ExchangeRate{
+		Currency:  currency,
+		Base:      p.base,
+		Date:      ratesDate,
+		Rate:      rate,
+		Source:    p.Name(),
+		FetchedAt: time.Now(),
+	}, nil
+}
+
+func (p *StaticProvider) read() (*staticRates, error) {
+	payload, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile() err: %w", err)
+	}
+
+	var rates staticRates
+	if err = json.Unmarshal(payload, &rates); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal() err: %w", err)
+	}
+
+	return &rates, nil
+}
@@ -0,0 +1,310 @@
+package jwks
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	fetchTimeout = 10 * time.Second
+	// minRefreshInterval limits refreshes triggered by tokens with unknown key IDs.
+	minRefreshInterval = time.Minute
+)
+
+var (
+	errNoRSAKeys     = errors.New("no RSA signing keys")
+	errNotRSAKey     = errors.New("not an RSA public key")
+	errInvalidPEM    = errors.New("no PEM block found")
+	errUnexpectedRes = errors.New("unexpected response status")
+)
+
+type Config struct {
+	// PublicKeyFiles are PEM files in "kid=path" form; a bare path uses the file name without extension as the key ID.
+	PublicKeyFiles []string
+	// JWKSURL is fetched for keys of an external issuer; JWKSFile is a local stand-in with the same format.
+	JWKSURL         string
+	JWKSFile        string
+	RefreshInterval time.Duration
+}
+
+// KeySet holds the public keys tokens are verified with, by key ID. Keys from PEM files and added
+// keys are static; keys from the JWKS source are replaced on each refresh, so the issuer can rotate them.
+type KeySet struct {
+	cfg    Config
+	client *http.Client
+
+	mu          sync.RWMutex
+	static      map[string]*rsa.PublicKey
+	remote      map[string]*rsa.PublicKey
+	refreshedAt time.Time
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func New(ctx context.Context, cfg Config) (*KeySet, error) {
+	keySet := &KeySet{
+		cfg:    cfg,
+		client: &http.Client{Timeout: fetchTimeout},
+		static: make(map[string]*rsa.PublicKey),
+		remote: make(map[string]*rsa.PublicKey),
+	}
+
+	for _, entry := range cfg.PublicKeyFiles {
+		kid, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			path = entry
+			kid = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+
+		key, err := readPublicKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("readPublicKeyFile(%s) err: %w", path, err)
+		}
+
+		keySet.static[kid] = key
+	}
+
+	if keySet.hasJWKS() {
+		if err := keySet.Refresh(ctx); err != nil {
+			return nil, fmt.Errorf("keySet.Refresh() err: %w", err)
+		}
+	}
+
+	return keySet, nil
+}
+
+// Add registers a static key, e.g. the key of the local token generator.
+func (k *KeySet) Add(kid string, key *rsa.PublicKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.static[kid] = key
+}
+
+// Key returns the key with the given ID. Tokens without a key ID are accepted only while the set
+// holds a single key. An unknown key ID triggers a refresh, in case the issuer has rotated its keys.
+func (k *KeySet) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+
+	k.mu.RLock()
+	canRefresh := k.hasJWKS() && time.Since(k.refreshedAt) >= minRefreshInterval
+	k.mu.RUnlock()
+
+	if canRefresh {
+		if err := k.Refresh(ctx); err != nil {
+			log.Warnf("refreshing JWKS for key %q failed: %v", kid, err)
+		}
+
+		if key, ok := k.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", models.ErrUnknownKeyID, kid)
+}
+
+func (k *KeySet) lookup(kid string) (*rsa.PublicKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if kid == "" {
+		if len(k.static)+len(k.remote) != 1 {
+			return nil, false
+		}
+
+		for _, key := range k.static {
+			return key, true
+		}
+
+		for _, key := range k.remote {
+			return key, true
+		}
+	}
+
+	if key, ok := k.remote[kid]; ok {
+		return key, true
+	}
+
+	key, ok := k.static[kid]
+
+	return key, ok
+}
+
+// Refresh reloads the keys from the JWKS source.
+func (k *KeySet) Refresh(ctx context.Context) error {
+	payload, err := k.readJWKS(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(payload)
+	if err != nil {
+		return fmt.Errorf("parseJWKS() err: %w", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.remote = keys
+	k.refreshedAt = time.Now()
+
+	return nil
+}
+
+// Start periodically refreshes the keys from the JWKS source.
+func (k *KeySet) Start(ctx context.Context) error {
+	if !k.hasJWKS() {
+		return nil
+	}
+
+	ticker := time.NewTicker(k.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := k.Refresh(ctx); err != nil {
+				log.Errorf("refreshing JWKS failed: %v", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (k *KeySet) hasJWKS() bool {
+	return k.cfg.JWKSURL != "" || k.cfg.JWKSFile != ""
+}
+
+func (k *KeySet) readJWKS(ctx context.Context) ([]byte, error) {
+	if k.cfg.JWKSURL == "" {
+		payload, err := os.ReadFile(k.cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("os.ReadFile(%s) err: %w", k.cfg.JWKSFile, err)
+		}
+
+		return payload, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequestWithContext() err: %w", err)
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("k.client.Do(req) err: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d", errUnexpectedRes, resp.StatusCode)
+	}
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("io.ReadAll(resp.Body) err: %w", err)
+	}
+
+	return payload, nil
+}
+
+// parseJWKS returns the RSA signing keys of a JSON Web Key Set; other keys are skipped.
+func parseJWKS(payload []byte) (map[string]*rsa.PublicKey, error) {
+	var keySet jsonWebKeySet
+	if err := json.Unmarshal(payload, &keySet); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal() err: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+
+	for _, jwk := range keySet.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		modulus, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("decoding modulus of key %q err: %w", jwk.Kid, err)
+		}
+
+		exponent, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("decoding exponent of key %q err: %w", jwk.Kid, err)
+		}
+
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(modulus),
+			E: int(new(big.Int).SetBytes(exponent).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errNoRSAKeys
+	}
+
+	return keys, nil
+}
+
+func readPublicKeyFile(path string) (*rsa.PublicKey, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile() err: %w", err)
+	}
+
+	block, _ := pem.Decode(payload)
+	if block == nil {
+		return nil, errInvalidPEM
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("x509.ParsePKCS1PublicKey() err: %w", err)
+		}
+
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("x509.ParsePKIXPublicKey() err: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errNotRSAKey
+	}
+
+	return key, nil
+}
@@ -3,12 +3,16 @@ package jwtgenerator
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/iurikman/cashFlowManager/internal/models"
-	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -16,43 +20,104 @@ const (
 	validDays  = 24 * time.Hour
 )
 
+var (
+	errInvalidPEM = errors.New("no PEM block found")
+	errNotRSAKey  = errors.New("not an RSA private key")
+)
+
+type Config struct {
+	KeyID    string
+	Issuer   string
+	Audience string
+	// PrivateKeyFile is a PEM file with the signing key. Without it a key is generated on start,
+	// and tokens don't survive restarts or validate on other replicas.
+	PrivateKeyFile string
+	TTL            time.Duration
+}
+
+// JWTGenerator issues access tokens of the service.
 type JWTGenerator struct {
+	cfg        Config
 	publicKey  *rsa.PublicKey
 	privateKey *rsa.PrivateKey
 }
 
-func NewJWTGenerator() *JWTGenerator {
-	privateKey, err := rsa.GenerateKey(rand.Reader, readerBits)
-	if err != nil {
-		log.Warnf("rsa.GenerateKey err: %v", err)
+func NewJWTGenerator(cfg Config) (*JWTGenerator, error) {
+	if cfg.TTL == 0 {
+		cfg.TTL = validDays
+	}
+
+	var (
+		privateKey *rsa.PrivateKey
+		err        error
+	)
+
+	if cfg.PrivateKeyFile != "" {
+		privateKey, err = readPrivateKeyFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("readPrivateKeyFile(%s) err: %w", cfg.PrivateKeyFile, err)
+		}
+	} else {
+		privateKey, err = rsa.GenerateKey(rand.Reader, readerBits)
+		if err != nil {
+			return nil, fmt.Errorf("rsa.GenerateKey err: %w", err)
+		}
 	}
 
 	generator := &JWTGenerator{
+		cfg:        cfg,
 		publicKey:  &privateKey.PublicKey,
 		privateKey: privateKey,
 	}
 
-	return generator
+	return generator, nil
 }
 
 func (j *JWTGenerator) GetNewTokenString(user models.User) (string, error) {
+	accessToken, err := j.NewAccessToken(user.ID, uuid.Nil, user.Roles)
+	if err != nil {
+		return "", err
+	}
+
+	return accessToken.Token, nil
+}
+
+// NewAccessToken issues an access token of the user within a login session. The roles are
+// embedded in the token, so role changes take effect with the next token.
+func (j *JWTGenerator) NewAccessToken(userID, sessionID uuid.UUID, roles []string) (*models.AccessToken, error) {
+	now := time.Now()
+	expiresAt := now.Add(j.cfg.TTL)
+
 	claims := models.Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    "localhost:8080",
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(validDays)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			Subject:   userID.String(),
+			Issuer:    j.cfg.Issuer,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
-		UUID: user.ID,
+		UUID:      userID,
+		SessionID: sessionID,
+		Roles:     roles,
+	}
+
+	if j.cfg.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{j.cfg.Audience}
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodRS512, claims)
+	token.Header["kid"] = j.cfg.KeyID
 
 	ss, err := token.SignedString(j.privateKey)
 	if err != nil {
-		return "", fmt.Errorf("token.SignedString(j.privateKey) err: %w", err)
+		return nil, fmt.Errorf("token.SignedString(j.privateKey) err: %w", err)
 	}
 
-	return ss, nil
+	return &models.AccessToken{Token: ss, ExpiresAt: expiresAt}, nil
+}
+
+func (j *JWTGenerator) GetKeyID() string {
+	return j.cfg.KeyID
 }
 
 func (j *JWTGenerator) GetPublicKey() *rsa.PublicKey {
@@ -60,3 +125,36 @@ func (j *JWTGenerator) GetPublicKey() *rsa.PublicKey {
 
 	return &key
 }
+
+func readPrivateKeyFile(path string) (*rsa.PrivateKey, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("os.ReadFile() err: %w", err)
+	}
+
+	block, _ := pem.Decode(payload)
+	if block == nil {
+		return nil, errInvalidPEM
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("x509.ParsePKCS1PrivateKey() err: %w", err)
+		}
+
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("x509.ParsePKCS8PrivateKey() err: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errNotRSAKey
+	}
+
+	return key, nil
+}
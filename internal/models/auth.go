@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TokenTypeBearer  = "Bearer"
+	TokenTypeAccess  = "access_token"
+	TokenTypeRefresh = "refresh_token"
+)
+
+// Credentials identify a user by username, email or phone.
+type Credentials struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+func (c Credentials) Validate() error {
+	if c.Login == "" || c.Password == "" {
+		return ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+// TokenPair is issued on login and refresh. The refresh token is opaque and can be used once:
+// refreshing rotates it, and reusing a rotated token revokes the whole session.
+type TokenPair struct {
+	AccessToken           string    `json:"accessToken"`
+	TokenType             string    `json:"tokenType"`
+	ExpiresAt             time.Time `json:"expiresAt"`
+	RefreshToken          string    `json:"refreshToken"`
+	RefreshTokenExpiresAt time.Time `json:"refreshTokenExpiresAt"`
+}
+
+type AccessToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// RefreshToken is the server-side record of a refresh token; only the hash of the token is stored.
+// All refresh tokens issued by rotation within a login share the session ID.
+type RefreshToken struct {
+	ID        uuid.UUID
+	SessionID uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type IntrospectionRequest struct {
+	Token string `json:"token"`
+}
+
+// TokenIntrospection describes a token; inactive tokens carry no other fields.
+type TokenIntrospection struct {
+	Active    bool       `json:"active"`
+	TokenType string     `json:"tokenType,omitempty"`
+	Subject   *uuid.UUID `json:"sub,omitempty"`
+	SessionID *uuid.UUID `json:"sid,omitempty"`
+	Issuer    string     `json:"iss,omitempty"`
+	Audience  []string   `json:"aud,omitempty"`
+	IssuedAt  *time.Time `json:"iat,omitempty"`
+	ExpiresAt *time.Time `json:"exp,omitempty"`
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears bounds the search for the next activation, so that expressions that never
+// match (e.g. February 30th) don't loop forever.
+const cronSearchYears = 5
+
+const cronFieldsCount = 5
+
+// CronExpression is a standard five-field cron expression: minute, hour, day of month, month
+// and day of week. Fields support "*", lists, ranges and steps, e.g. "0 9 1,15 * *" or "*/30 * * * 1-5".
+type CronExpression struct {
+	minutes     map[int]struct{}
+	hours       map[int]struct{}
+	daysOfMonth map[int]struct{}
+	months      map[int]struct{}
+	daysOfWeek  map[int]struct{}
+	anyDay      bool
+	anyWeekday  bool
+}
+
+type cronField struct {
+	min, max int
+}
+
+//nolint:gochecknoglobals
+var cronFields = [cronFieldsCount]cronField{
+	{min: 0, max: 59},
+	{min: 0, max: 23},
+	{min: 1, max: 31},
+	{min: 1, max: 12},
+	{min: 0, max: 6},
+}
+
+func ParseCron(expression string) (*CronExpression, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != cronFieldsCount {
+		return nil, fmt.Errorf("%w: %q must have %d fields", ErrInvalidCron, expression, cronFieldsCount)
+	}
+
+	var values [cronFieldsCount]map[int]struct{}
+
+	for i, field := range fields {
+		parsed, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", ErrInvalidCron, expression, err)
+		}
+
+		values[i] = parsed
+	}
+
+	return &CronExpression{
+		minutes:     values[0],
+		hours:       values[1],
+		daysOfMonth: values[2],
+		months:      values[3],
+		daysOfWeek:  values[4],
+		anyDay:      fields[2] == "*",
+		anyWeekday:  fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, bounds cronField) (map[int]struct{}, error) {
+	values := make(map[int]struct{})
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+
+		if hasStep {
+			parsed, err := strconv.Atoi(stepPart)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid step %q", stepPart)
+			}
+
+			step = parsed
+		}
+
+		from, to := bounds.min, bounds.max
+
+		if rangePart != "*" {
+			fromPart, toPart, isRange := strings.Cut(rangePart, "-")
+
+			parsed, err := strconv.Atoi(fromPart)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", fromPart)
+			}
+
+			from, to = parsed, parsed
+
+			switch {
+			case isRange:
+				if to, err = strconv.Atoi(toPart); err != nil {
+					return nil, fmt.Errorf("invalid value %q", toPart)
+				}
+			case hasStep:
+				to = bounds.max
+			}
+		}
+
+		if from < bounds.min || to > bounds.max || from > to {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, bounds.min, bounds.max)
+		}
+
+		for value := from; value <= to; value += step {
+			values[value] = struct{}{}
+		}
+	}
+
+	return values, nil
+}
+
+// Next returns the first activation strictly after the given time, in the location of that time.
+func (c *CronExpression) Next(after time.Time) (time.Time, bool) {
+	next := after.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(cronSearchYears, 0, 0)
+
+	for next.Before(limit) {
+		switch {
+		case !contains(c.months, int(next.Month())):
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+		case !c.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case !contains(c.hours, next.Hour()):
+			next = next.Truncate(time.Hour).Add(time.Hour)
+		case !contains(c.minutes, next.Minute()):
+			next = next.Add(time.Minute)
+		default:
+			return next, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (c *CronExpression) dayMatches(t time.Time) bool {
+	dayMatches := contains(c.daysOfMonth, t.Day())
+	weekdayMatches := contains(c.daysOfWeek, int(t.Weekday()))
+
+	// As in classic cron, a restricted day of month and day of week match if either does.
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekdayMatches
+	case c.anyWeekday:
+		return dayMatches
+	default:
+		return dayMatches || weekdayMatches
+	}
+}
+
+func contains(values map[int]struct{}, value int) bool {
+	_, ok := values[value]
+
+	return ok
+}
@@ -0,0 +1,196 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMinorUnits = 2
+	maxMinorUnits     = 8
+	maxNumericCode    = 999
+)
+
+//nolint:gochecknoglobals
+var currencyCodeRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Currency is a currency wallets and operations can be held in. Code is the code used in the
+// service, which may differ from the ISO 4217 code, and ProviderIDs are the codes rate providers
+// know the currency by, keyed by the provider name. Disabled currencies can't be used in new
+// wallets and operations, while the existing wallets keep them.
+type Currency struct {
+	Code        string            `json:"code"`
+	ISOCode     string            `json:"isoCode"`
+	NumericCode int               `json:"numericCode"`
+	MinorUnits  int32             `json:"minorUnits"`
+	ProviderIDs map[string]string `json:"providerIds,omitempty"`
+	Enabled     bool              `json:"enabled"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+func (c Currency) Validate() error {
+	switch {
+	case !currencyCodeRegexp.MatchString(c.Code):
+		return fmt.Errorf("%w: code must be three capital letters", ErrInvalidCurrency)
+	case !currencyCodeRegexp.MatchString(c.ISOCode):
+		return fmt.Errorf("%w: ISO code must be three capital letters", ErrInvalidCurrency)
+	case c.NumericCode <= 0 || c.NumericCode > maxNumericCode:
+		return fmt.Errorf("%w: numeric code must be in [1, %d]", ErrInvalidCurrency, maxNumericCode)
+	case c.MinorUnits < 0 || c.MinorUnits > maxMinorUnits:
+		return fmt.Errorf("%w: minor units must be in [0, %d]", ErrInvalidCurrency, maxMinorUnits)
+	}
+
+	for provider, id := range c.ProviderIDs {
+		if provider == "" || id == "" {
+			return fmt.Errorf("%w: empty provider id", ErrInvalidCurrency)
+		}
+	}
+
+	return nil
+}
+
+// CurrencyDTO changes a registered currency. The minor units can't change, since existing amounts
+// in the currency are rounded to them; ProviderIDs, when set, replace all the provider ids.
+type CurrencyDTO struct {
+	ISOCode     *string           `json:"isoCode,omitempty"`
+	NumericCode *int              `json:"numericCode,omitempty"`
+	ProviderIDs map[string]string `json:"providerIds,omitempty"`
+	Enabled     *bool             `json:"enabled,omitempty"`
+}
+
+// Apply returns the currency with the changes applied.
+func (c CurrencyDTO) Apply(currency Currency) Currency {
+	if c.ISOCode != nil {
+		currency.ISOCode = *c.ISOCode
+	}
+
+	if c.NumericCode != nil {
+		currency.NumericCode = *c.NumericCode
+	}
+
+	if c.ProviderIDs != nil {
+		currency.ProviderIDs = c.ProviderIDs
+	}
+
+	if c.Enabled != nil {
+		currency.Enabled = *c.Enabled
+	}
+
+	return currency
+}
+
+type currencyRegistry struct {
+	mu         sync.RWMutex
+	currencies map[string]Currency
+}
+
+// currencies is the registry the validation and rounding read. It starts with the currencies the
+// store is seeded with and is replaced by the store contents with SetCurrencies.
+//
+//nolint:gochecknoglobals
+var currencies = &currencyRegistry{currencies: map[string]Currency{
+	"RUR": {Code: "RUR", ISOCode: "RUB", NumericCode: 643, MinorUnits: 2, Enabled: true},
+	"CHY": {
+		Code: "CHY", ISOCode: "CNY", NumericCode: 156, MinorUnits: 2, Enabled: true,
+		ProviderIDs: map[string]string{RateSourceCBR: "R01375"},
+	},
+	"AED": {
+		Code: "AED", ISOCode: "AED", NumericCode: 784, MinorUnits: 2, Enabled: true,
+		ProviderIDs: map[string]string{RateSourceCBR: "R01230"},
+	},
+	"INR": {
+		Code: "INR", ISOCode: "INR", NumericCode: 356, MinorUnits: 2, Enabled: true,
+		ProviderIDs: map[string]string{RateSourceCBR: "R01270"},
+	},
+	"EUR": {
+		Code: "EUR", ISOCode: "EUR", NumericCode: 978, MinorUnits: 2, Enabled: true,
+		ProviderIDs: map[string]string{RateSourceCBR: "R01239"},
+	},
+	"USD": {
+		Code: "USD", ISOCode: "USD", NumericCode: 840, MinorUnits: 2, Enabled: true,
+		ProviderIDs: map[string]string{RateSourceCBR: "R01235"},
+	},
+}}
+
+// SetCurrencies replaces the registered currencies.
+func SetCurrencies(list []*Currency) {
+	registered := make(map[string]Currency, len(list))
+
+	for _, currency := range list {
+		registered[currency.Code] = *currency
+	}
+
+	currencies.mu.Lock()
+	defer currencies.mu.Unlock()
+
+	currencies.currencies = registered
+}
+
+// LookupCurrency returns the registered currency, whether it is enabled or not.
+func LookupCurrency(code string) (Currency, bool) {
+	currencies.mu.RLock()
+	defer currencies.mu.RUnlock()
+
+	currency, ok := currencies.currencies[code]
+
+	return currency, ok
+}
+
+// currencyAllowed reports whether new wallets and operations can use the currency.
+func currencyAllowed(code string) bool {
+	currency, ok := LookupCurrency(code)
+
+	return ok && currency.Enabled
+}
+
+// GetCurrencies returns the codes of the enabled currencies in alphabetical order.
+func GetCurrencies() []string {
+	currencies.mu.RLock()
+	defer currencies.mu.RUnlock()
+
+	codes := make([]string, 0, len(currencies.currencies))
+
+	for code, currency := range currencies.currencies {
+		if currency.Enabled {
+			codes = append(codes, code)
+		}
+	}
+
+	sort.Strings(codes)
+
+	return codes
+}
+
+// GetCurrencyProviderID returns the code the provider knows the currency by, or an empty string
+// when the provider doesn't quote the currency.
+func GetCurrencyProviderID(code, provider string) (string, error) {
+	currency, ok := LookupCurrency(code)
+	if !ok {
+		return "", ErrCurrencyNotAllowed
+	}
+
+	return currency.ProviderIDs[provider], nil
+}
+
+// GetCurrencyISOCode returns the ISO 4217 code of the currency, which providers other than the CBR use.
+func GetCurrencyISOCode(code string) (string, error) {
+	currency, ok := LookupCurrency(code)
+	if !ok {
+		return "", ErrCurrencyNotAllowed
+	}
+
+	return currency.ISOCode, nil
+}
+
+// GetCurrencyMinorUnits returns the number of fractional digits amounts in the currency are rounded to.
+func GetCurrencyMinorUnits(code string) int32 {
+	if currency, ok := LookupCurrency(code); ok {
+		return currency.MinorUnits
+	}
+
+	return defaultMinorUnits
+}
@@ -0,0 +1,258 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// divisionScale is the number of fractional digits kept for values that have no
+// finite decimal representation (e.g. the result of 1/3).
+const divisionScale = 18
+
+const (
+	decimalBase = 10
+	factorTwo   = 2
+	factorFive  = 5
+)
+
+// decimalPattern is a plain decimal number with an optional exponent, as JSON allows. Go literal
+// forms that big.Rat accepts, such as "0x10", "0b11" or "1_000", are not money amounts. The exponent
+// is limited to two digits: money amounts don't need more, and values like "1e999999" are costly to
+// parse and round.
+//
+//nolint:gochecknoglobals
+var decimalPattern = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][-+]?\d{1,2})?$`)
+
+// Decimal is an arbitrary-precision decimal number used for money amounts and
+// exchange rates. The zero value is 0.
+type Decimal struct {
+	rat *big.Rat
+}
+
+func NewDecimalFromInt(value int64) Decimal {
+	return Decimal{rat: new(big.Rat).SetInt64(value)}
+}
+
+func ParseDecimal(value string) (Decimal, error) {
+	value = strings.TrimSpace(value)
+	if !decimalPattern.MatchString(value) {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+	}
+
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, value)
+	}
+
+	return Decimal{rat: rat}, nil
+}
+
+// MustDecimal is like ParseDecimal but panics if the value can't be parsed.
+func MustDecimal(value string) Decimal {
+	d, err := ParseDecimal(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+func (d Decimal) value() *big.Rat {
+	if d.rat == nil {
+		return new(big.Rat)
+	}
+
+	return d.rat
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Add(d.value(), other.value())}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Sub(d.value(), other.value())}
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{rat: new(big.Rat).Mul(d.value(), other.value())}
+}
+
+func (d Decimal) Div(other Decimal) (Decimal, error) {
+	if other.IsZero() {
+		return Decimal{}, ErrDivisionByZero
+	}
+
+	return Decimal{rat: new(big.Rat).Quo(d.value(), other.value())}, nil
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{rat: new(big.Rat).Neg(d.value())}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{rat: new(big.Rat).Abs(d.value())}
+}
+
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	return d.value().Cmp(other.value())
+}
+
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// Round rounds the value to the given number of fractional digits, half away from zero.
+func (d Decimal) Round(places int32) Decimal {
+	scale := new(big.Int).Exp(big.NewInt(decimalBase), big.NewInt(int64(places)), nil)
+
+	scaled := new(big.Rat).Mul(d.value(), new(big.Rat).SetInt(scale))
+
+	quo, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(factorTwo)).Cmp(scaled.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(scaled.Sign())))
+	}
+
+	return Decimal{rat: new(big.Rat).SetFrac(quo, scale)}
+}
+
+// RoundForCurrency rounds the value to the minor units of the given currency.
+func (d Decimal) RoundForCurrency(currency string) Decimal {
+	return d.Round(GetCurrencyMinorUnits(currency))
+}
+
+// scale returns the number of fractional digits needed to print the value exactly,
+// or divisionScale if the value has no finite decimal representation.
+func (d Decimal) scale() int {
+	denom := new(big.Int).Set(d.value().Denom())
+	mod := new(big.Int)
+
+	countFactor := func(factor int64) int {
+		count := 0
+		f := big.NewInt(factor)
+
+		for {
+			quo, rem := new(big.Int).QuoRem(denom, f, mod)
+			if rem.Sign() != 0 {
+				return count
+			}
+
+			denom = quo
+			count++
+		}
+	}
+
+	twos := countFactor(factorTwo)
+	fives := countFactor(factorFive)
+
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return divisionScale
+	}
+
+	return max(twos, fives)
+}
+
+func (d Decimal) String() string {
+	s := d.value().FloatString(d.scale())
+
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+
+	if s == "-0" {
+		return "0"
+	}
+
+	return s
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts both JSON strings and JSON numbers.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = Decimal{}
+
+		return nil
+	}
+
+	return d.UnmarshalText(bytes.Trim(data, `"`))
+}
+
+// ScanNumeric implements pgtype.NumericScanner. NULL is scanned as zero.
+func (d *Decimal) ScanNumeric(v pgtype.Numeric) error {
+	if !v.Valid {
+		*d = Decimal{}
+
+		return nil
+	}
+
+	if v.NaN || v.InfinityModifier != pgtype.Finite {
+		return fmt.Errorf("%w: non-finite numeric", ErrInvalidDecimal)
+	}
+
+	rat := new(big.Rat).SetInt(v.Int)
+	exp := new(big.Int).Exp(big.NewInt(decimalBase), big.NewInt(int64(abs(v.Exp))), nil)
+
+	if v.Exp < 0 {
+		rat.Quo(rat, new(big.Rat).SetInt(exp))
+	} else {
+		rat.Mul(rat, new(big.Rat).SetInt(exp))
+	}
+
+	*d = Decimal{rat: rat}
+
+	return nil
+}
+
+// NumericValue implements pgtype.NumericValuer.
+func (d Decimal) NumericValue() (pgtype.Numeric, error) {
+	places := d.scale()
+
+	scaled := new(big.Rat).Mul(
+		d.Round(int32(places)).value(),
+		new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(decimalBase), big.NewInt(int64(places)), nil)),
+	)
+
+	coefficient := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+
+	return pgtype.Numeric{Int: coefficient, Exp: int32(-places), Valid: true}, nil
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DormancyActionWarned  = "warned"
+	DormancyActionDormant = "dormant"
+
+	NoticeDormancyWarning = "dormancy_warning"
+	NoticeWalletDormant   = "wallet_dormant"
+)
+
+// DormancyNotice tells the owner that the wallet is about to become dormant, or has become dormant.
+type DormancyNotice struct {
+	Type           string    `json:"type"`
+	WalletID       uuid.UUID `json:"walletId"`
+	OwnerID        uuid.UUID `json:"ownerId"`
+	LastActivityAt time.Time `json:"lastActivityAt"`
+	DormantAt      time.Time `json:"dormantAt"`
+}
+
+// DormantAt returns when an inactive wallet becomes dormant: once the dormancy period passes since
+// its last activity, but never sooner than the warning period after its owner was warned.
+func DormantAt(lastActivityAt, warnedAt time.Time, dormancyPeriod, warningPeriod time.Duration) time.Time {
+	dormantAt := lastActivityAt.Add(dormancyPeriod)
+
+	if warned := warnedAt.Add(warningPeriod); warned.After(dormantAt) {
+		return warned
+	}
+
+	return dormantAt
+}
+
+// DormancyRun reports the wallets a run of the dormancy policy warned about or made dormant.
+type DormancyRun struct {
+	ID        uuid.UUID           `json:"id"`
+	StartedAt time.Time           `json:"startedAt"`
+	Wallets   []DormancyRunWallet `json:"wallets"`
+}
+
+type DormancyRunWallet struct {
+	WalletID       uuid.UUID `json:"walletId"`
+	OwnerID        uuid.UUID `json:"ownerId"`
+	Action         string    `json:"action"`
+	LastActivityAt time.Time `json:"lastActivityAt"`
+}
+
+// Count returns the number of wallets the run took the action on.
+func (r DormancyRun) Count(action string) int {
+	count := 0
+
+	for _, wallet := range r.Wallets {
+		if wallet.Action == action {
+			count++
+		}
+	}
+
+	return count
+}
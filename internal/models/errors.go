@@ -20,4 +20,58 @@ var (
 	ErrOperationTypeNotAllowed = errors.New("operation type not allowed")
 	ErrNameIsEmpty             = errors.New("name is empty")
 	ErrCurrencyIsEmpty         = errors.New("currency is empty")
+	ErrInvalidDecimal          = errors.New("invalid decimal")
+	ErrDivisionByZero          = errors.New("division by zero")
+	ErrSortingNotAllowed       = errors.New("sorting not allowed")
+	ErrDuplicateTransaction    = errors.New("duplicate transaction")
+	ErrDuplicateIdempotencyKey = errors.New("duplicate idempotency key")
+	ErrIdempotencyKeyNotFound  = errors.New("idempotency key not found")
+	ErrIdempotencyKeyTooLong   = errors.New("idempotency key is too long")
+	ErrIdempotencyKeyConflict  = errors.New("idempotency key was used with a different request")
+	ErrAmountPrecision         = errors.New("amount has more fractional digits than the currency allows")
+	ErrNotReversible           = errors.New("transaction can't be reversed")
+	ErrAlreadyReversed         = errors.New("transaction is already fully reversed")
+	ErrRefundExceedsAmount     = errors.New("refund exceeds the amount left to reverse")
+	ErrHoldNotFound            = errors.New("hold not found")
+	ErrDuplicateHold           = errors.New("duplicate hold")
+	ErrHoldNotActive           = errors.New("hold is not active")
+	ErrCaptureExceedsHold      = errors.New("capture exceeds the held amount")
+	ErrWalletHasHolds          = errors.New("wallet has active holds")
+	ErrInvalidCron             = errors.New("invalid cron expression")
+	ErrInvalidSchedule         = errors.New("invalid schedule")
+	ErrScheduleNotFound        = errors.New("schedule not found")
+	ErrUnknownKeyID            = errors.New("unknown signing key id")
+	ErrInvalidCredentials      = errors.New("invalid credentials")
+	ErrInvalidRefreshToken     = errors.New("invalid refresh token")
+	ErrUnknownRole             = errors.New("unknown role")
+	ErrRolesRequired           = errors.New("roles are required")
+	ErrWalletFrozen            = errors.New("wallet is frozen")
+	ErrExchangeRateNotFound    = errors.New("exchange rate not found")
+	ErrSameCurrencies          = errors.New("currencies of the conversion are the same")
+	ErrQuoteNotFound           = errors.New("quote not found")
+	ErrQuoteExpired            = errors.New("quote has expired")
+	ErrQuoteUsed               = errors.New("quote has already been used")
+	ErrQuoteMismatch           = errors.New("quote doesn't match the operation")
+	ErrUnknownTier             = errors.New("unknown tier")
+	ErrInvalidFeeSchedule      = errors.New("invalid fee schedule")
+	ErrRevenueWalletNotSet     = errors.New("revenue wallet is not configured for the currency")
+	ErrInvalidCurrency         = errors.New("invalid currency")
+	ErrCurrencyNotFound        = errors.New("currency not found")
+	ErrCurrencyExists          = errors.New("currency already exists")
+	ErrInvalidCurrencyPair     = errors.New("invalid currency pair")
+	ErrInvalidRateDate         = errors.New("invalid rate date")
+	ErrNotMultiCurrency        = errors.New("wallet is not multi-currency")
+	ErrMultiCurrencyWallet     = errors.New("currency of a multi-currency wallet can't change")
+	ErrWalletHasPockets        = errors.New("wallet has non-empty pockets")
+	ErrWalletClosing           = errors.New("wallet is closing")
+	ErrWalletClosed            = errors.New("wallet is closed")
+	ErrWalletNotEmpty          = errors.New("wallet balance is not zero")
+	ErrInvalidStatusTransition = errors.New("wallet status can't change that way")
+	ErrFrozenByStaff           = errors.New("wallet was frozen by staff")
+	ErrInvalidSweepTarget      = errors.New("invalid sweep target wallet")
+	ErrRestorePeriodExpired    = errors.New("restore period of the wallet expired")
+	ErrWalletDormant           = errors.New("wallet is dormant")
+	ErrHoldExpiresInPast       = errors.New("hold expiration must be in the future")
+	ErrWalletCurrencyChanged   = errors.New("wallet currency changed since the transaction")
+	ErrInvalidPagination       = errors.New("limit and offset can't be negative")
 )
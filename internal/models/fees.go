@@ -0,0 +1,123 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TierStandard = "standard"
+	TierPremium  = "premium"
+	TierBusiness = "business"
+)
+
+//nolint:gochecknoglobals
+var allowedTiers = map[string]struct{}{
+	TierStandard: {},
+	TierPremium:  {},
+	TierBusiness: {},
+}
+
+type TierUpdate struct {
+	Tier string `json:"tier"`
+}
+
+func (t TierUpdate) Validate() error {
+	if _, ok := allowedTiers[t.Tier]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTier, t.Tier)
+	}
+
+	return nil
+}
+
+// FeeRule sets the FX fee of operations converting CurrencyFrom to CurrencyTo for users of the
+// Tier; an empty field matches any value. Markup is the share of the converted amount taken as
+// a fee, and FlatFees are added on top, keyed by the currency the fee is charged in.
+type FeeRule struct {
+	CurrencyFrom string             `json:"currencyFrom,omitempty"`
+	CurrencyTo   string             `json:"currencyTo,omitempty"`
+	Tier         string             `json:"tier,omitempty"`
+	Markup       Decimal            `json:"markup"`
+	FlatFees     map[string]Decimal `json:"flatFees,omitempty"`
+}
+
+func (r FeeRule) matches(currencyFrom, currencyTo, tier string) bool {
+	return (r.CurrencyFrom == "" || r.CurrencyFrom == currencyFrom) &&
+		(r.CurrencyTo == "" || r.CurrencyTo == currencyTo) &&
+		(r.Tier == "" || r.Tier == tier)
+}
+
+// specificity is the number of fields the rule sets; the most specific matching rule applies.
+func (r FeeRule) specificity() int {
+	specificity := 0
+
+	for _, field := range []string{r.CurrencyFrom, r.CurrencyTo, r.Tier} {
+		if field != "" {
+			specificity++
+		}
+	}
+
+	return specificity
+}
+
+// FeeSchedule holds the FX fee rules and the wallets, one per currency, that collect the fees.
+type FeeSchedule struct {
+	RevenueWallets map[string]uuid.UUID `json:"revenueWallets"`
+	Rules          []FeeRule            `json:"rules"`
+}
+
+func (f FeeSchedule) Validate() error {
+	for currency := range f.RevenueWallets {
+		if !currencyAllowed(currency) {
+			return fmt.Errorf("%w: revenue wallet of unknown currency %q", ErrInvalidFeeSchedule, currency)
+		}
+	}
+
+	for i, rule := range f.Rules {
+		for _, currency := range []string{rule.CurrencyFrom, rule.CurrencyTo} {
+			if currency != "" && !currencyAllowed(currency) {
+				return fmt.Errorf("%w: rule %d: unknown currency %q", ErrInvalidFeeSchedule, i, currency)
+			}
+		}
+
+		if _, ok := allowedTiers[rule.Tier]; rule.Tier != "" && !ok {
+			return fmt.Errorf("%w: rule %d: unknown tier %q", ErrInvalidFeeSchedule, i, rule.Tier)
+		}
+
+		if rule.Markup.Sign() < 0 || rule.Markup.Cmp(NewDecimalFromInt(1)) >= 0 {
+			return fmt.Errorf("%w: rule %d: markup must be in [0, 1)", ErrInvalidFeeSchedule, i)
+		}
+
+		for currency, fee := range rule.FlatFees {
+			if !currencyAllowed(currency) {
+				return fmt.Errorf("%w: rule %d: flat fee of unknown currency %q", ErrInvalidFeeSchedule, i, currency)
+			}
+
+			if fee.Sign() < 0 {
+				return fmt.Errorf("%w: rule %d: negative flat fee", ErrInvalidFeeSchedule, i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Fee returns the fee, in feeCurrency, of converting currencyFrom to currencyTo for a user of the
+// tier, where amount is the operation amount in feeCurrency. Without a matching rule there is no fee.
+func (f FeeSchedule) Fee(currencyFrom, currencyTo, tier, feeCurrency string, amount Decimal) Decimal {
+	var rule *FeeRule
+
+	for i := range f.Rules {
+		if f.Rules[i].matches(currencyFrom, currencyTo, tier) &&
+			(rule == nil || f.Rules[i].specificity() > rule.specificity()) {
+			rule = &f.Rules[i]
+		}
+	}
+
+	if rule == nil {
+		return Decimal{}
+	}
+
+	return amount.Mul(rule.Markup).Add(rule.FlatFees[feeCurrency]).RoundForCurrency(feeCurrency)
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusVoided   = "voided"
+	HoldStatusExpired  = "expired"
+)
+
+// Hold reserves funds of a wallet: it reduces the available balance until it is captured,
+// voided or expires. ConvertedAmount is the reserved amount in the wallet currency.
+type Hold struct {
+	ID              uuid.UUID  `json:"id"`
+	WalletID        uuid.UUID  `json:"walletId"`
+	OwnerID         uuid.UUID  `json:"ownerId"`
+	Amount          Decimal    `json:"amount"`
+	Currency        string     `json:"currency"`
+	ConvertedAmount Decimal    `json:"convertedAmount"`
+	ExRate          Decimal    `json:"exRate"`
+	RateSource      string     `json:"rateSource,omitempty"`
+	RateDate        *time.Time `json:"rateDate,omitempty"`
+	CapturedAmount  Decimal    `json:"capturedAmount"`
+	TransactionID   uuid.UUID  `json:"transactionId"`
+	Status          string     `json:"status"`
+	ExpiresAt       time.Time  `json:"expiresAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+func (h Hold) Validate() error {
+	if h.WalletID == uuid.Nil {
+		return ErrWalletIDIsEmpty
+	}
+
+	if !currencyAllowed(h.Currency) {
+		return ErrCurrencyNotAllowed
+	}
+
+	if h.Amount.Sign() <= 0 {
+		return ErrAmountIsZero
+	}
+
+	if !h.Amount.Equal(h.Amount.RoundForCurrency(h.Currency)) {
+		return ErrAmountPrecision
+	}
+
+	return nil
+}
+
+// Capture is a request to capture a hold. A nil Amount captures the whole hold; the rest of a
+// partially captured hold is released.
+type Capture struct {
+	Amount *Decimal `json:"amount,omitempty"`
+}
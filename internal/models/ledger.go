@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// System ledger accounts. Wallet accounts share the ID of their wallet.
+//
+//nolint:gochecknoglobals
+var (
+	CashInAccountID         = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	CashOutAccountID        = uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	FXClearingAccountID     = uuid.MustParse("00000000-0000-0000-0000-000000000003")
+	OpeningBalanceAccountID = uuid.MustParse("00000000-0000-0000-0000-000000000004")
+	// FeeRevenueAccountID collects the FX fees until they are settled to the revenue wallets.
+	FeeRevenueAccountID = uuid.MustParse("00000000-0000-0000-0000-000000000005")
+)
+
+// Posting is a single ledger entry. Debits are positive and credits are negative, so the
+// postings of one transaction sum to zero in every currency.
+type Posting struct {
+	ID            int64     `json:"id"`
+	TransactionID uuid.UUID `json:"transactionId"`
+	AccountID     uuid.UUID `json:"accountId"`
+	Amount        Decimal   `json:"amount"`
+	Currency      string    `json:"currency"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type CurrencyImbalance struct {
+	Currency string  `json:"currency"`
+	Sum      Decimal `json:"sum"`
+}
+
+type WalletDiscrepancy struct {
+	WalletID      uuid.UUID `json:"walletId"`
+	Currency      string    `json:"currency"`
+	Balance       Decimal   `json:"balance"`
+	LedgerBalance Decimal   `json:"ledgerBalance"`
+}
+
+type LedgerReport struct {
+	CurrencyImbalances  []CurrencyImbalance `json:"currencyImbalances"`
+	WalletDiscrepancies []WalletDiscrepancy `json:"walletDiscrepancies"`
+}
+
+func (r LedgerReport) Balanced() bool {
+	return len(r.CurrencyImbalances) == 0 && len(r.WalletDiscrepancies) == 0
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,18 +15,55 @@ const UserInfoKey ctxKey = "userInfo"
 type ctxKey string
 
 type Wallet struct {
-	ID        uuid.UUID `json:"id"`
-	Owner     uuid.UUID `json:"owner"`
-	Name      string    `json:"name"`
+	ID               uuid.UUID `json:"id"`
+	Owner            uuid.UUID `json:"owner"`
+	Name             string    `json:"name"`
+	Currency         string    `json:"currency"`
+	Balance          Decimal   `json:"balance"`
+	AvailableBalance Decimal   `json:"availableBalance"`
+	Status           string    `json:"status"`
+	MultiCurrency    bool      `json:"multiCurrency"`
+	Savings          bool      `json:"savings"`
+	Pockets          []Pocket  `json:"pockets,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+	Deleted          bool      `json:"deleted"`
+	// DeletedAt starts the grace period during which a deleted wallet can be restored.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	// RestorableUntil is when the grace period of a deleted wallet ends and the wallet is purged.
+	RestorableUntil *time.Time `json:"restorableUntil,omitempty"`
+	PurgedAt        *time.Time `json:"purgedAt,omitempty"`
+}
+
+// Pocket is the balance of a multi-currency wallet in a currency other than the wallet currency.
+// Pockets are opened by the first deposit or exchange into the currency and can't be held.
+type Pocket struct {
 	Currency  string    `json:"currency"`
-	Balance   float64   `json:"balance"`
-	CreatedAt time.Time `json:"createdAt"`
+	Balance   Decimal   `json:"balance"`
 	UpdatedAt time.Time `json:"updatedAt"`
-	Deleted   bool      `json:"deleted"`
+}
+
+// PocketOf returns the pocket operations in the currency move, where an empty pocket is the main
+// balance of the wallet. Only multi-currency wallets keep other currencies in their own pockets.
+func (w Wallet) PocketOf(currency string) string {
+	if !w.MultiCurrency || currency == w.Currency {
+		return ""
+	}
+
+	return currency
+}
+
+// BalanceCurrency returns the currency of the pocket, where an empty pocket is the main balance.
+func (w Wallet) BalanceCurrency(pocket string) string {
+	if pocket == "" {
+		return w.Currency
+	}
+
+	return pocket
 }
 
 func (w Wallet) Validate() error {
-	if _, ok := allowedCurrencies[w.Currency]; !ok {
+	if !currencyAllowed(w.Currency) {
 		return ErrCurrencyNotAllowed
 	}
 
@@ -38,9 +78,49 @@ func (w Wallet) Validate() error {
 	return nil
 }
 
+const (
+	WalletStatusActive = "active"
+	WalletStatusFrozen = "frozen"
+	// WalletStatusClosing is a wallet that is being drained before it is closed: money can only
+	// leave it.
+	WalletStatusClosing = "closing"
+	WalletStatusClosed  = "closed"
+	// WalletStatusDormant is an empty wallet left inactive for the dormancy period. It is blocked
+	// until the owner reactivates it.
+	WalletStatusDormant = "dormant"
+)
+
+// CheckActive returns an error unless money can be moved to or from the wallet.
+func (w Wallet) CheckActive() error {
+	switch w.Status {
+	case WalletStatusFrozen:
+		return ErrWalletFrozen
+	case WalletStatusClosing:
+		return ErrWalletClosing
+	case WalletStatusClosed:
+		return ErrWalletClosed
+	case WalletStatusDormant:
+		return ErrWalletDormant
+	}
+
+	return nil
+}
+
+// CheckDebitable returns an error unless money can be moved out of the wallet, which a closing
+// wallet still allows.
+func (w Wallet) CheckDebitable() error {
+	if w.Status == WalletStatusClosing {
+		return nil
+	}
+
+	return w.CheckActive()
+}
+
 type WalletDTO struct {
-	Name     *string `json:"name,omitempty"`
-	Currency *string `json:"currency,omitempty"`
+	Name          *string `json:"name,omitempty"`
+	Currency      *string `json:"currency,omitempty"`
+	MultiCurrency *bool   `json:"multiCurrency,omitempty"`
+	Savings       *bool   `json:"savings,omitempty"`
 }
 
 func (w WalletDTO) Validate() error {
@@ -55,7 +135,7 @@ func (w WalletDTO) Validate() error {
 			return ErrCurrencyIsEmpty
 		}
 
-		if _, ok := allowedCurrencies[*w.Currency]; !ok {
+		if !currencyAllowed(*w.Currency) {
 			return ErrCurrencyNotAllowed
 		}
 	}
@@ -64,16 +144,30 @@ func (w WalletDTO) Validate() error {
 }
 
 type Transaction struct {
-	TransactionID   uuid.UUID `json:"id"`
-	WalletID        uuid.UUID `json:"walletId"`
-	OwnerID         uuid.UUID `json:"ownerId"`
-	TargetWalletID  uuid.UUID `json:"targetWalletId"`
-	Amount          float64   `json:"amount"`
-	Currency        string    `json:"currency"`
-	ConvertedAmount float64   `json:"convertedAmount"`
-	ExRate          float64   `json:"exRate"`
-	OperationType   string    `json:"transactionType"`
+	TransactionID   uuid.UUID  `json:"id"`
+	WalletID        uuid.UUID  `json:"walletId"`
+	OwnerID         uuid.UUID  `json:"ownerId"`
+	TargetWalletID  uuid.UUID  `json:"targetWalletId"`
+	TargetOwnerID   uuid.UUID  `json:"targetOwnerId"`
+	Recipient       string     `json:"recipient,omitempty"`
+	Amount          Decimal    `json:"amount"`
+	Currency        string     `json:"currency"`
+	ConvertedAmount Decimal    `json:"convertedAmount"`
+	ExRate          Decimal    `json:"exRate"`
+	RateSource      string     `json:"rateSource,omitempty"`
+	RateDate        *time.Time `json:"rateDate,omitempty"`
+	OperationType   string     `json:"transactionType"`
+	OriginalID      uuid.UUID  `json:"originalTransactionId"`
+	QuoteID         *uuid.UUID `json:"quoteId,omitempty"`
+	// Pocket is the currency of the pocket the transaction moved instead of the main wallet balance.
+	Pocket string `json:"pocket,omitempty"`
+	// TargetCurrency is the currency an exchange or a transfer credits.
+	TargetCurrency string `json:"targetCurrency,omitempty"`
+	// BalanceCurrency is the currency of the wallet balance the transaction moved when it was executed.
+	BalanceCurrency string    `json:"balanceCurrency,omitempty"`
+	Direction       string    `json:"direction,omitempty"`
 	ExecutedAt      time.Time `json:"executedAt"`
+	IdempotencyKey  string    `json:"-"`
 }
 
 func (t Transaction) Validate() error {
@@ -81,7 +175,7 @@ func (t Transaction) Validate() error {
 		return ErrWalletIDIsEmpty
 	}
 
-	if _, ok := allowedCurrencies[t.Currency]; !ok {
+	if !currencyAllowed(t.Currency) {
 		return ErrCurrencyNotAllowed
 	}
 
@@ -89,66 +183,167 @@ func (t Transaction) Validate() error {
 		return ErrOperationTypeNotAllowed
 	}
 
-	if t.Amount <= 0 {
+	if t.Amount.Sign() <= 0 {
 		return ErrAmountIsZero
 	}
 
+	if !t.Amount.Equal(t.Amount.RoundForCurrency(t.Currency)) {
+		return ErrAmountPrecision
+	}
+
 	if t.OperationType == "" {
 		return ErrTransactionTypeIsEmpty
 	}
 
+	if t.OperationType == OperationExchange {
+		if !currencyAllowed(t.TargetCurrency) {
+			return ErrCurrencyNotAllowed
+		}
+
+		if t.TargetCurrency == t.Currency {
+			return ErrSameCurrencies
+		}
+	}
+
 	return nil
 }
 
+// ApplyConversion sets the converted amount of the transaction and the provenance of its rate.
+func (t *Transaction) ApplyConversion(conversion Conversion) {
+	t.ConvertedAmount = conversion.Amount
+	t.ExRate = conversion.Rate
+	t.RateSource = conversion.RateSource
+	t.RateDate = nil
+
+	if !conversion.RateDate.IsZero() {
+		t.RateDate = &conversion.RateDate
+	}
+}
+
+// RequestHash returns a fingerprint of the client-supplied operation fields,
+// used to detect reuse of an idempotency key with a different payload.
+func (t Transaction) RequestHash() string {
+	fields := []string{
+		t.OperationType,
+		t.WalletID.String(),
+		t.TargetWalletID.String(),
+		t.Amount.String(),
+		t.Currency,
+	}
+
+	// Appended only when set so that keys stored before recipients existed still match.
+	if t.Recipient != "" {
+		fields = append(fields, t.Recipient)
+	}
+
+	if t.QuoteID != nil {
+		fields = append(fields, t.QuoteID.String())
+	}
+
+	if t.TargetCurrency != "" {
+		fields = append(fields, t.TargetCurrency)
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(fields, "|")))
+
+	return hex.EncodeToString(hash[:])
+}
+
+type IdempotencyKey struct {
+	OwnerID       uuid.UUID
+	Key           string
+	RequestHash   string
+	TransactionID uuid.UUID
+	CreatedAt     time.Time
+}
+
+const (
+	TransactionsTopic = "transactions"
+	// WalletNotificationsTopic carries notices to wallet owners that aren't tied to a transaction.
+	WalletNotificationsTopic = "wallet_notifications"
+)
+
+type OutboxMessage struct {
+	ID        int64
+	Topic     string
+	Key       string
+	Payload   []byte
+	CreatedAt time.Time
+	Attempts  int
+	LastError string
+}
+
 type User struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
 	Phone     string    `json:"phone"`
-	Password  string    `json:"password"`
+	Password  string    `json:"password,omitempty"`
+	Roles     []string  `json:"roles,omitempty"`
+	Tier      string    `json:"tier,omitempty"`
 	Wallets   []Wallet  `json:"wallets"`
 	CreatedAt time.Time `json:"createdAt"`
 	Deleted   bool      `json:"deleted"`
 }
 
 type UserInfo struct {
-	ID uuid.UUID
+	ID    uuid.UUID
+	Roles []string
 }
 
-//nolint:gochecknoglobals
-var allowedOperationTypes = map[string]struct{}{
-	"deposit":  {},
-	"transfer": {},
-	"withdraw": {},
-}
+const (
+	OperationDeposit    = "deposit"
+	OperationTransfer   = "transfer"
+	OperationWithdraw   = "withdraw"
+	OperationConversion = "conversion"
+	OperationReversal   = "reversal"
+	OperationFee        = "fee"
+	OperationExchange   = "exchange"
+	// OperationSweep moves the remaining balance of a wallet being closed to another wallet.
+	OperationSweep = "sweep"
+	// OperationFeeSettlement credits the fees collected in a currency to its revenue wallet.
+	OperationFeeSettlement = "fee_settlement"
+)
 
-//nolint:gochecknoglobals
-var allowedCurrencies = map[string]string{
-	"RUR": "",
-	"CHY": "R01375",
-	"AED": "R01230",
-	"INR": "R01270",
+// Reversal is a request to reverse a transaction. A nil Amount reverses everything that
+// hasn't been refunded yet.
+type Reversal struct {
+	Amount *Decimal `json:"amount,omitempty"`
 }
 
-func GetCurrencyCode(code string) (*string, error) {
-	if code, ok := allowedCurrencies[code]; ok {
-		return &code, nil
-	}
+// Direction of a transaction relative to the wallet whose history is requested.
+const (
+	DirectionOutgoing = "outgoing"
+	DirectionIncoming = "incoming"
+)
 
-	return nil, ErrCurrencyNotAllowed
+// allowedOperationTypes are the operation types clients may request.
+//
+//nolint:gochecknoglobals
+var allowedOperationTypes = map[string]struct{}{
+	OperationDeposit:  {},
+	OperationTransfer: {},
+	OperationWithdraw: {},
+	OperationExchange: {},
 }
 
 type Claims struct {
 	jwt.RegisteredClaims
-	UUID uuid.UUID `json:"uuid"`
+	UUID      uuid.UUID `json:"uuid"`
+	SessionID uuid.UUID `json:"sid"`
+	Roles     []string  `json:"roles,omitempty"`
 }
 
 type Params struct {
-	Offset         int    `schema:"offset,omitempty"`
-	Limit          int    `schema:"limit,omitempty"`
-	Sorting        string `schema:"sorting,omitempty"`
-	Descending     bool   `schema:"descending,omitempty"`
-	FilterDateFrom string `schema:"filterFrom,omitempty"`
-	FilterDateTo   string `schema:"filterTo,omitempty"`
-	FilterType     string `schema:"filterCurrency,omitempty"`
+	Offset         int      `schema:"offset,omitempty"`
+	Limit          int      `schema:"limit,omitempty"`
+	Sorting        string   `schema:"sorting,omitempty"`
+	Descending     bool     `schema:"descending,omitempty"`
+	FilterDateFrom string   `schema:"filterFrom,omitempty"`
+	FilterDateTo   string   `schema:"filterTo,omitempty"`
+	FilterType     string   `schema:"filterCurrency,omitempty"`
+	FilterName     string   `schema:"filterName,omitempty"`
+	IncludeDeleted bool     `schema:"includeDeleted,omitempty"`
+	BalanceFrom    *Decimal `schema:"balanceFrom,omitempty"`
+	BalanceTo      *Decimal `schema:"balanceTo,omitempty"`
 }
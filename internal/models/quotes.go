@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QuoteRequest asks for a rate to convert the amount from one currency to another for an operation
+// of the type. The type decides the currency the FX fee is quoted and charged in.
+type QuoteRequest struct {
+	CurrencyFrom  string  `json:"currencyFrom"`
+	CurrencyTo    string  `json:"currencyTo"`
+	Amount        Decimal `json:"amount"`
+	OperationType string  `json:"operationType"`
+}
+
+func (q QuoteRequest) Validate() error {
+	if !currencyAllowed(q.CurrencyFrom) {
+		return ErrCurrencyNotAllowed
+	}
+
+	if !currencyAllowed(q.CurrencyTo) {
+		return ErrCurrencyNotAllowed
+	}
+
+	if q.CurrencyFrom == q.CurrencyTo {
+		return ErrSameCurrencies
+	}
+
+	if q.Amount.Sign() <= 0 {
+		return ErrAmountIsZero
+	}
+
+	if !q.Amount.Equal(q.Amount.RoundForCurrency(q.CurrencyFrom)) {
+		return ErrAmountPrecision
+	}
+
+	switch q.OperationType {
+	case OperationDeposit, OperationWithdraw, OperationTransfer, OperationExchange:
+	default:
+		return ErrOperationTypeNotAllowed
+	}
+
+	return nil
+}
+
+// Quote locks the rate and the FX fee of a conversion until it expires. An operation of the type
+// referencing the quote converts exactly its amount at its rate and is charged its fee, and a quote
+// can be used only once.
+type Quote struct {
+	ID              uuid.UUID  `json:"id"`
+	OwnerID         uuid.UUID  `json:"ownerId"`
+	CurrencyFrom    string     `json:"currencyFrom"`
+	CurrencyTo      string     `json:"currencyTo"`
+	Amount          Decimal    `json:"amount"`
+	ConvertedAmount Decimal    `json:"convertedAmount"`
+	Rate            Decimal    `json:"rate"`
+	RateSource      string     `json:"rateSource"`
+	RateDate        time.Time  `json:"rateDate"`
+	OperationType   string     `json:"operationType,omitempty"`
+	Fee             Decimal    `json:"fee"`
+	FeeCurrency     string     `json:"feeCurrency"`
+	ExpiresAt       time.Time  `json:"expiresAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	TransactionID   *uuid.UUID `json:"transactionId,omitempty"`
+}
+
+// FeeBase returns the currency the FX fee of the quoted conversion is charged in and the amount
+// it is charged on. Deposits and withdraws are charged in the currency of the wallet, which is the
+// one converted to, other operations in the currency converted from.
+func (q Quote) FeeBase() (string, Decimal) {
+	if q.OperationType == OperationDeposit || q.OperationType == OperationWithdraw {
+		return q.CurrencyTo, q.ConvertedAmount
+	}
+
+	return q.CurrencyFrom, q.Amount
+}
+
+// CheckUsable reports whether the quote can lock the rate of converting the amount between the
+// currencies for an operation of the type.
+func (q Quote) CheckUsable(operationType, currencyFrom, currencyTo string, amount Decimal, now time.Time) error {
+	switch {
+	case q.TransactionID != nil:
+		return ErrQuoteUsed
+	case !q.ExpiresAt.After(now):
+		return ErrQuoteExpired
+	case q.CurrencyFrom != currencyFrom || q.CurrencyTo != currencyTo || !q.Amount.Equal(amount):
+		return ErrQuoteMismatch
+	case q.OperationType != operationType:
+		return ErrQuoteMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	RateSourceCBR    = "cbr"
+	RateSourceECB    = "ecb"
+	RateSourceStatic = "static"
+)
+
+// ExchangeRate is the price of one unit of the currency in the base currency of the source, as
+// published by the source for the date.
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	Base      string    `json:"base"`
+	Date      time.Time `json:"date"`
+	Rate      Decimal   `json:"rate"`
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// Conversion is an amount converted to another currency together with the provenance of the rate:
+// Rate is the price of one unit of the source currency in the target currency, and RateDate is the
+// date of the oldest rate it was derived from.
+type Conversion struct {
+	Amount     Decimal
+	Rate       Decimal
+	RateSource string
+	RateDate   time.Time
+}
+
+// RateRequest asks for the rate of converting CurrencyFrom to CurrencyTo in effect on the Date.
+type RateRequest struct {
+	CurrencyFrom string
+	CurrencyTo   string
+	Date         time.Time
+}
+
+// ParseRateRequest parses a pair such as "USD/RUR" and an optional date in the YYYY-MM-DD format,
+// which defaults to today and can't be in the future.
+func ParseRateRequest(pair, date string, now time.Time) (*RateRequest, error) {
+	currencyFrom, currencyTo, ok := strings.Cut(pair, "/")
+	if !ok {
+		return nil, fmt.Errorf("%w: %q, expected a pair such as USD/RUR", ErrInvalidCurrencyPair, pair)
+	}
+
+	for _, currency := range []string{currencyFrom, currencyTo} {
+		if _, ok := LookupCurrency(currency); !ok {
+			return nil, fmt.Errorf("%w: %q", ErrCurrencyNotAllowed, currency)
+		}
+	}
+
+	request := &RateRequest{
+		CurrencyFrom: currencyFrom,
+		CurrencyTo:   currencyTo,
+		Date:         time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+	}
+
+	if date == "" {
+		return request, nil
+	}
+
+	rateDate, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q, expected YYYY-MM-DD", ErrInvalidRateDate, date)
+	}
+
+	if rateDate.After(request.Date) {
+		return nil, fmt.Errorf("%w: %s is in the future", ErrInvalidRateDate, date)
+	}
+
+	request.Date = rateDate
+
+	return request, nil
+}
+
+// PairRate is the price of one unit of CurrencyFrom in CurrencyTo in effect on the Date. RateDate is
+// the date the rate was published for, which precedes the Date on weekends and holidays.
+type PairRate struct {
+	CurrencyFrom string    `json:"currencyFrom"`
+	CurrencyTo   string    `json:"currencyTo"`
+	Date         time.Time `json:"date"`
+	Rate         Decimal   `json:"rate"`
+	RateSource   string    `json:"rateSource"`
+	RateDate     time.Time `json:"rateDate"`
+}
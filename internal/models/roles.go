@@ -0,0 +1,71 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	RoleOwner   = "owner"
+	RoleAuditor = "auditor"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
+const (
+	// PermissionOwnWallets allows managing the user's own wallets and operations.
+	PermissionOwnWallets = "wallets:own"
+	// PermissionReadAll allows viewing wallets and transactions of any user.
+	PermissionReadAll     = "wallets:read_all"
+	PermissionFreeze      = "wallets:freeze"
+	PermissionReadUsers   = "users:read"
+	PermissionManageRoles = "users:manage_roles"
+	PermissionManageTiers = "users:manage_tiers"
+	// PermissionManageCurrencies allows registering currencies and enabling or disabling them.
+	PermissionManageCurrencies = "currencies:manage"
+	// PermissionCloseWallets allows closing a wallet of any user, sweeping its balance elsewhere.
+	PermissionCloseWallets = "wallets:close"
+	// PermissionReverse allows reversing transactions of any user.
+	PermissionReverse = "transactions:reverse"
+)
+
+//nolint:gochecknoglobals
+var rolePermissions = map[string][]string{
+	RoleOwner:   {PermissionOwnWallets},
+	RoleAuditor: {PermissionReadAll, PermissionReadUsers},
+	RoleSupport: {PermissionReadAll, PermissionReadUsers, PermissionFreeze, PermissionReverse},
+	RoleAdmin: {
+		PermissionOwnWallets, PermissionReadAll, PermissionReadUsers, PermissionFreeze,
+		PermissionManageRoles, PermissionManageTiers, PermissionManageCurrencies, PermissionCloseWallets,
+		PermissionReverse,
+	},
+}
+
+// HasPermission reports whether any of the roles grants the permission; unknown roles grant nothing.
+func HasPermission(roles []string, permission string) bool {
+	for _, role := range roles {
+		if slices.Contains(rolePermissions[role], permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type RolesUpdate struct {
+	Roles []string `json:"roles"`
+}
+
+func (r RolesUpdate) Validate() error {
+	if len(r.Roles) == 0 {
+		return ErrRolesRequired
+	}
+
+	for _, role := range r.Roles {
+		if _, ok := rolePermissions[role]; !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownRole, role)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RecurrenceOnce    = "once"
+	RecurrenceCron    = "cron"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+)
+
+const (
+	ScheduleRunSucceeded = "succeeded"
+	ScheduleRunFailed    = "failed"
+)
+
+const (
+	maxPercent    = 100
+	maxDayOfMonth = 31
+	maxWeekday    = 6
+	daysInWeek    = 7
+)
+
+// Schedule is a standing order: a deposit, withdraw or transfer executed at RunAt (once), by a
+// cron expression, or weekly/monthly at the time of day of RunAt. All times are in UTC.
+//
+// The operation moves either a fixed Amount in Currency, or Percent of the source wallet's
+// available balance at the time of the run.
+type Schedule struct {
+	ID             uuid.UUID  `json:"id"`
+	OwnerID        uuid.UUID  `json:"ownerId"`
+	Name           string     `json:"name"`
+	OperationType  string     `json:"transactionType"`
+	WalletID       uuid.UUID  `json:"walletId"`
+	TargetWalletID uuid.UUID  `json:"targetWalletId"`
+	Recipient      string     `json:"recipient,omitempty"`
+	Amount         *Decimal   `json:"amount,omitempty"`
+	Percent        *Decimal   `json:"percent,omitempty"`
+	Currency       string     `json:"currency"`
+	Recurrence     string     `json:"recurrence"`
+	RunAt          time.Time  `json:"runAt"`
+	Cron           string     `json:"cron,omitempty"`
+	Weekday        *int       `json:"weekday,omitempty"`
+	DayOfMonth     *int       `json:"dayOfMonth,omitempty"`
+	NextRunAt      *time.Time `json:"nextRunAt"`
+	Active         bool       `json:"active"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+func (s Schedule) Validate() error {
+	if s.WalletID == uuid.Nil {
+		return ErrWalletIDIsEmpty
+	}
+
+	if _, ok := allowedOperationTypes[s.OperationType]; !ok {
+		return ErrOperationTypeNotAllowed
+	}
+
+	if s.OperationType == OperationTransfer && s.TargetWalletID == uuid.Nil && s.Recipient == "" {
+		return fmt.Errorf("%w: targetWalletId or recipient is required for transfers", ErrInvalidSchedule)
+	}
+
+	if err := s.validateAmount(); err != nil {
+		return err
+	}
+
+	switch s.Recurrence {
+	case RecurrenceOnce:
+		if s.RunAt.IsZero() {
+			return fmt.Errorf("%w: runAt is required", ErrInvalidSchedule)
+		}
+	case RecurrenceCron:
+		if _, err := ParseCron(s.Cron); err != nil {
+			return err
+		}
+	case RecurrenceWeekly:
+		if s.Weekday == nil || *s.Weekday < 0 || *s.Weekday > maxWeekday {
+			return fmt.Errorf("%w: weekday must be between 0 (Sunday) and %d", ErrInvalidSchedule, maxWeekday)
+		}
+	case RecurrenceMonthly:
+		if s.DayOfMonth == nil || *s.DayOfMonth < 1 || *s.DayOfMonth > maxDayOfMonth {
+			return fmt.Errorf("%w: dayOfMonth must be between 1 and %d", ErrInvalidSchedule, maxDayOfMonth)
+		}
+	default:
+		return fmt.Errorf("%w: unknown recurrence %q", ErrInvalidSchedule, s.Recurrence)
+	}
+
+	return nil
+}
+
+func (s Schedule) validateAmount() error {
+	switch {
+	case (s.Amount == nil) == (s.Percent == nil):
+		return fmt.Errorf("%w: exactly one of amount and percent is required", ErrInvalidSchedule)
+	case s.Amount != nil:
+		if !currencyAllowed(s.Currency) {
+			return ErrCurrencyNotAllowed
+		}
+
+		if s.Amount.Sign() <= 0 {
+			return ErrAmountIsZero
+		}
+
+		if !s.Amount.Equal(s.Amount.RoundForCurrency(s.Currency)) {
+			return ErrAmountPrecision
+		}
+	case s.OperationType == OperationDeposit:
+		return fmt.Errorf("%w: percent is not supported for deposits", ErrInvalidSchedule)
+	case s.Percent.Sign() <= 0 || s.Percent.Cmp(NewDecimalFromInt(maxPercent)) > 0:
+		return fmt.Errorf("%w: percent must be above 0 and at most %d", ErrInvalidSchedule, maxPercent)
+	}
+
+	return nil
+}
+
+// NextRun returns the first run strictly after the given time, or false if the schedule has
+// no more runs.
+func (s Schedule) NextRun(after time.Time) (time.Time, bool) {
+	after = after.UTC()
+	runAt := s.RunAt.UTC()
+
+	switch s.Recurrence {
+	case RecurrenceOnce:
+		return runAt, runAt.After(after)
+	case RecurrenceCron:
+		cron, err := ParseCron(s.Cron)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return cron.Next(after)
+	case RecurrenceWeekly:
+		next := atTimeOfDay(after, runAt)
+		next = next.AddDate(0, 0, (*s.Weekday-int(next.Weekday())+daysInWeek)%daysInWeek)
+
+		if !next.After(after) {
+			next = next.AddDate(0, 0, daysInWeek)
+		}
+
+		return next, true
+	case RecurrenceMonthly:
+		for months := 0; ; months++ {
+			month := time.Date(after.Year(), after.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+			next := atTimeOfDay(month.AddDate(0, 0, min(*s.DayOfMonth, daysIn(month))-1), runAt)
+
+			if next.After(after) {
+				return next, true
+			}
+		}
+	default:
+		return time.Time{}, false
+	}
+}
+
+// atTimeOfDay returns the day of t at the time of day of clock.
+func atTimeOfDay(t, clock time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, time.UTC)
+}
+
+// daysIn returns the number of days in the month of t, so that the 31st falls on the last day of shorter months.
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// ScheduleRun is a record of a single execution of a schedule.
+type ScheduleRun struct {
+	ID            int64     `json:"id"`
+	ScheduleID    uuid.UUID `json:"scheduleId"`
+	ScheduledAt   time.Time `json:"scheduledAt"`
+	ExecutedAt    time.Time `json:"executedAt"`
+	Status        string    `json:"status"`
+	TransactionID uuid.UUID `json:"transactionId"`
+	Error         string    `json:"error,omitempty"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// walletStatusTransitions lists the statuses each wallet status can change to. A closed wallet
+// stays closed, and only an active wallet can become dormant.
+//
+//nolint:gochecknoglobals
+var walletStatusTransitions = map[string][]string{
+	WalletStatusActive:  {WalletStatusFrozen, WalletStatusClosing, WalletStatusClosed, WalletStatusDormant},
+	WalletStatusFrozen:  {WalletStatusActive, WalletStatusClosed},
+	WalletStatusClosing: {WalletStatusFrozen, WalletStatusClosed},
+	WalletStatusDormant: {WalletStatusActive, WalletStatusFrozen, WalletStatusClosed},
+}
+
+// CanChangeStatus reports whether the wallet status can change to the status.
+func (w Wallet) CanChangeStatus(status string) bool {
+	return slices.Contains(walletStatusTransitions[w.Status], status)
+}
+
+// WalletStatusChange records a change of the wallet status and who made it.
+type WalletStatusChange struct {
+	ID         uuid.UUID `json:"id"`
+	WalletID   uuid.UUID `json:"walletId"`
+	StatusFrom string    `json:"statusFrom"`
+	StatusTo   string    `json:"statusTo"`
+	ChangedBy  uuid.UUID `json:"changedBy"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// WalletStatusRequest is the body of a status change. SweepTo only applies to closing a wallet:
+// its remaining balance, pockets included, is moved to that wallet.
+type WalletStatusRequest struct {
+	Reason  string     `json:"reason,omitempty"`
+	SweepTo *uuid.UUID `json:"sweepTo,omitempty"`
+}
@@ -0,0 +1,217 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+
+	argon2idPrefix = "$argon2id$"
+	saltLength     = 16
+	keyLength      = 32
+	// argon2idParts is the number of "$"-separated parts of an argon2id hash, including the empty first one.
+	argon2idParts = 6
+	// bcryptHashLength is the length of any bcrypt hash: the prefix, the cost, the salt and the key.
+	bcryptHashLength = 60
+
+	// The parameters of the hashes verified are capped, so that a crafted hash can't exhaust the
+	// memory or the CPU at login. maxArgon2Memory is in KiB.
+	maxArgon2Time    = 10
+	maxArgon2Memory  = 256 * 1024
+	maxArgon2Threads = 16
+	maxBcryptCost    = 16
+)
+
+var (
+	errUnknownAlgorithm = errors.New("unknown password hash algorithm")
+	errInvalidCost      = errors.New("invalid bcrypt cost")
+	errInvalidParams    = errors.New("invalid argon2id parameters")
+	errInvalidHash      = errors.New("invalid password hash")
+)
+
+//nolint:gochecknoglobals
+var bcryptPrefixes = []string{"$2a$", "$2b$", "$2y$"}
+
+type Config struct {
+	Algorithm string
+	// Argon2Time, Argon2Memory (in KiB) and Argon2Threads are the argon2id parameters.
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+	BcryptCost    int
+}
+
+// Hasher hashes passwords with the configured algorithm and verifies hashes of any supported
+// algorithm, so that the algorithm and its parameters can be changed without invalidating stored hashes.
+type Hasher struct {
+	cfg Config
+}
+
+func NewHasher(cfg Config) (*Hasher, error) {
+	switch cfg.Algorithm {
+	case AlgorithmArgon2id:
+		if !argon2idParamsAllowed(argon2idParams{time: cfg.Argon2Time, memory: cfg.Argon2Memory, threads: cfg.Argon2Threads}) {
+			return nil, fmt.Errorf(
+				"%w: time, memory and threads must be within 1-%d, 1-%d and 1-%d",
+				errInvalidParams, maxArgon2Time, maxArgon2Memory, maxArgon2Threads,
+			)
+		}
+	case AlgorithmBcrypt:
+		if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > maxBcryptCost {
+			return nil, fmt.Errorf("%w: %d is out of range %d-%d", errInvalidCost, cfg.BcryptCost, bcrypt.MinCost, maxBcryptCost)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownAlgorithm, cfg.Algorithm)
+	}
+
+	return &Hasher{cfg: cfg}, nil
+}
+
+// IsHash reports whether the value is a hash of a supported algorithm rather than a plaintext password.
+// A value that only starts like a hash but doesn't parse as one is a plaintext password.
+func IsHash(value string) bool {
+	_, _, _, err := decodeArgon2id(value)
+
+	return err == nil || isBcrypt(value)
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == AlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("bcrypt.GenerateFromPassword() err: %w", err)
+		}
+
+		return string(hash), nil
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("rand.Read() err: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.cfg.Argon2Time, h.cfg.Argon2Memory, h.cfg.Argon2Threads, keyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.cfg.Argon2Memory,
+		h.cfg.Argon2Time,
+		h.cfg.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify checks the password against the stored value. A value that isn't a hash is a plaintext
+// password stored before hashing was introduced, even if it starts like a hash. needsRehash reports that the stored value
+// should be replaced with a hash made with the current configuration.
+func (h *Hasher) Verify(password, stored string) (ok, needsRehash bool, err error) {
+	if params, salt, key, decodeErr := decodeArgon2id(stored); decodeErr == nil {
+		computed := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		ok = subtle.ConstantTimeCompare(key, computed) == 1
+		needsRehash = h.cfg.Algorithm != AlgorithmArgon2id || params != h.argon2idParams()
+
+		return ok, needsRehash, nil
+	}
+
+	if isBcrypt(stored) {
+		err = bcrypt.CompareHashAndPassword([]byte(stored), []byte(password))
+
+		switch {
+		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+			return false, false, nil
+		case err != nil:
+			return false, false, fmt.Errorf("bcrypt.CompareHashAndPassword() err: %w", err)
+		}
+
+		cost, err := bcrypt.Cost([]byte(stored))
+		if err != nil {
+			return false, false, fmt.Errorf("bcrypt.Cost() err: %w", err)
+		}
+
+		return true, h.cfg.Algorithm != AlgorithmBcrypt || cost != h.cfg.BcryptCost, nil
+	}
+
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1, true, nil
+}
+
+type argon2idParams struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+}
+
+func (h *Hasher) argon2idParams() argon2idParams {
+	return argon2idParams{time: h.cfg.Argon2Time, memory: h.cfg.Argon2Memory, threads: h.cfg.Argon2Threads}
+}
+
+// decodeArgon2id parses a hash in the PHC string format: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>.
+func decodeArgon2id(encoded string) (argon2idParams, []byte, []byte, error) {
+	var (
+		params  argon2idParams
+		version int
+	)
+
+	if !strings.HasPrefix(encoded, argon2idPrefix) {
+		return params, nil, nil, errInvalidHash
+	}
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != argon2idParts {
+		return params, nil, nil, errInvalidHash
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errInvalidHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(salt) == 0 || len(key) == 0 || !argon2idParamsAllowed(params) {
+		return params, nil, nil, errInvalidHash
+	}
+
+	return params, salt, key, nil
+}
+
+func argon2idParamsAllowed(params argon2idParams) bool {
+	return params.time > 0 && params.time <= maxArgon2Time &&
+		params.memory > 0 && params.memory <= maxArgon2Memory &&
+		params.threads > 0 && params.threads <= maxArgon2Threads
+}
+
+// isBcrypt reports whether the value is a well-formed bcrypt hash.
+func isBcrypt(value string) bool {
+	if len(value) != bcryptHashLength {
+		return false
+	}
+
+	for _, prefix := range bcryptPrefixes {
+		if strings.HasPrefix(value, prefix) {
+			cost, err := bcrypt.Cost([]byte(value))
+
+			return err == nil && cost <= maxBcryptCost
+		}
+	}
+
+	return false
+}
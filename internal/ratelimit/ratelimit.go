@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket: Rate tokens per second are added up to Burst. A zero Rate disables limiting.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// fillTime is how long an empty bucket takes to become full again.
+func (l Limit) fillTime() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// Limiter keeps a token bucket per key, e.g. per user. Buckets that have refilled are dropped,
+// since a new bucket starts full anyway.
+type Limiter struct {
+	limit Limit
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	cleanedAt time.Time
+}
+
+func New(limit Limit) *Limiter {
+	return &Limiter{
+		limit:     limit,
+		buckets:   make(map[string]*bucket),
+		cleanedAt: time.Now(),
+	}
+}
+
+// Allow takes a token from the bucket of the key. When the bucket is empty it returns false and
+// the time until the next token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if !l.limit.Enabled() {
+		return true, 0
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.clean(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), updatedAt: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(float64(l.limit.Burst), b.tokens+now.Sub(b.updatedAt).Seconds()*l.limit.Rate)
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.limit.Rate * float64(time.Second))
+	}
+
+	b.tokens--
+
+	return true, 0
+}
+
+func (l *Limiter) clean(now time.Time) {
+	fillTime := l.limit.fillTime()
+
+	if now.Sub(l.cleanedAt) < fillTime {
+		return
+	}
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updatedAt) >= fillTime {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.cleanedAt = now
+}
@@ -0,0 +1,312 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *Server) findUsers(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("findUsers", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	params, err := parseParams(r.URL.Query())
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid query parameters")
+
+		return
+	}
+
+	users, err := s.service.FindUsers(r.Context(), *params)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to find users: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, users)
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getUser", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid user id")
+
+		return
+	}
+
+	user, err := s.service.GetUser(r.Context(), id)
+
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get user: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, user)
+}
+
+func (s *Server) setUserRoles(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("setUserRoles", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var rolesUpdate models.RolesUpdate
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&rolesUpdate); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if err := rolesUpdate.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid user id")
+
+		return
+	}
+
+	user, err := s.service.SetUserRoles(r.Context(), id, rolesUpdate.Roles)
+
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to set user roles: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, user)
+}
+
+func (s *Server) setUserTier(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("setUserTier", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var tierUpdate models.TierUpdate
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&tierUpdate); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if err := tierUpdate.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid user id")
+
+		return
+	}
+
+	user, err := s.service.SetUserTier(r.Context(), id, tierUpdate.Tier)
+
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to set user tier: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, user)
+}
+
+func (s *Server) getUserWallets(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getUserWallets", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	params, err := parseParams(r.URL.Query())
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid query parameters")
+
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid user id")
+
+		return
+	}
+
+	wallets, err := s.service.GetUserWallets(r.Context(), id, *params)
+
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case errors.Is(err, models.ErrSortingNotAllowed):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get user wallets: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, wallets)
+}
+
+func (s *Server) getAnyWallet(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getAnyWallet", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid wallet id")
+
+		return
+	}
+
+	wallet, err := s.service.GetAnyWallet(r.Context(), id)
+
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get wallet: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, wallet)
+}
+
+func (s *Server) getAnyTransactions(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getAnyTransactions", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	params, err := parseParams(r.URL.Query())
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid query parameters")
+
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid wallet id")
+
+		return
+	}
+
+	transactions, err := s.service.GetAnyTransactions(r.Context(), id, *params)
+
+	switch {
+	case errors.Is(err, models.ErrTransactionsNotFound), errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "wallet not found")
+
+		return
+	case errors.Is(err, models.ErrSortingNotAllowed):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get transactions: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, transactions)
+}
+
+func (s *Server) freezeWallet(w http.ResponseWriter, r *http.Request) {
+	s.changeWalletStatus(w, r, "freezeWallet", func(ctx context.Context, id, actorID uuid.UUID, request models.WalletStatusRequest) (
+		*models.Wallet, error,
+	) {
+		return s.service.SetWalletStatus(ctx, id, actorID, models.WalletStatusFrozen, request.Reason)
+	})
+}
+
+func (s *Server) unfreezeWallet(w http.ResponseWriter, r *http.Request) {
+	s.changeWalletStatus(w, r, "unfreezeWallet", func(ctx context.Context, id, actorID uuid.UUID, request models.WalletStatusRequest) (
+		*models.Wallet, error,
+	) {
+		return s.service.SetWalletStatus(ctx, id, actorID, models.WalletStatusActive, request.Reason)
+	})
+}
+
+func (s *Server) getDormancyRuns(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getDormancyRuns", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	params, err := parseParams(r.URL.Query())
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid query parameters")
+
+		return
+	}
+
+	runs, err := s.service.GetDormancyRuns(r.Context(), *params)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get dormancy runs: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, runs)
+}
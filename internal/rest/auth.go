@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("login", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var credentials models.Credentials
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if err := credentials.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	tokenPair, err := s.service.Login(r.Context(), credentials)
+
+	switch {
+	case errors.Is(err, models.ErrInvalidCredentials):
+		writeErrorResponse(w, http.StatusUnauthorized, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to login: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, tokenPair)
+}
+
+func (s *Server) refreshTokens(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("refreshTokens", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var refreshRequest models.RefreshRequest
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&refreshRequest); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	tokenPair, err := s.service.RefreshTokens(r.Context(), refreshRequest.RefreshToken)
+
+	switch {
+	case errors.Is(err, models.ErrInvalidRefreshToken):
+		writeErrorResponse(w, http.StatusUnauthorized, models.ErrInvalidRefreshToken.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to refresh tokens: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, tokenPair)
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("logout", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var refreshRequest models.RefreshRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&refreshRequest); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	err := s.service.Logout(r.Context(), refreshRequest.RefreshToken)
+
+	switch {
+	case errors.Is(err, models.ErrInvalidRefreshToken):
+		writeErrorResponse(w, http.StatusUnauthorized, models.ErrInvalidRefreshToken.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to logout: %v", err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// introspectToken describes an access or refresh token. Tokens that fail verification are
+// reported as inactive rather than rejected.
+func (s *Server) introspectToken(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("introspectToken", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var introspectionRequest models.IntrospectionRequest
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&introspectionRequest); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	var (
+		introspection *models.TokenIntrospection
+		err           error
+	)
+
+	claims, parseErr := s.parseToken(r.Context(), introspectionRequest.Token)
+
+	switch {
+	case parseErr == nil:
+		introspection, err = s.service.IntrospectAccessToken(r.Context(), *claims)
+	case errors.Is(parseErr, models.ErrInvalidAccessToken):
+		introspection, err = s.service.IntrospectRefreshToken(r.Context(), introspectionRequest.Token)
+	default:
+		introspection = &models.TokenIntrospection{Active: false}
+	}
+
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to introspect token: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, introspection)
+}
@@ -0,0 +1,105 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *Server) getCurrencies(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getCurrencies", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	currencies, err := s.service.GetCurrencies(r.Context())
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get currencies: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, currencies)
+}
+
+func (s *Server) createCurrency(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("createCurrency", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var currency models.Currency
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&currency); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if err := currency.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	createdCurrency, err := s.service.CreateCurrency(r.Context(), currency)
+
+	switch {
+	case errors.Is(err, models.ErrCurrencyExists):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to create currency: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusCreated, createdCurrency)
+}
+
+func (s *Server) updateCurrency(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("updateCurrency", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var currencyDTO models.CurrencyDTO
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&currencyDTO); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	updatedCurrency, err := s.service.UpdateCurrency(r.Context(), chi.URLParam(r, "code"), currencyDTO)
+
+	switch {
+	case errors.Is(err, models.ErrCurrencyNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case errors.Is(err, models.ErrInvalidCurrency):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to update currency: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, updatedCurrency)
+}
@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *Server) createQuote(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("createQuote", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var request models.QuoteRequest
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if err := request.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	quote, err := s.service.CreateQuote(r.Context(), request, ownerID)
+
+	switch {
+	case errors.Is(err, models.ErrExchangeRateNotFound):
+		writeErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to create quote: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusCreated, quote)
+}
+
+func (s *Server) getExchangeRate(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getExchangeRate", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	request, err := models.ParseRateRequest(r.URL.Query().Get("pair"), r.URL.Query().Get("date"), time.Now())
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	rate, err := s.service.GetExchangeRate(r.Context(), *request)
+
+	switch {
+	case errors.Is(err, models.ErrExchangeRateNotFound):
+		writeErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get exchange rate: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, rate)
+}
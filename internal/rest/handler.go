@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"time"
@@ -16,17 +17,63 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-const standartPage = 10
+const (
+	standartPage            = 10
+	maxPage                 = 100
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+)
 
 type service interface {
 	CreateWallet(context context.Context, wallet models.Wallet) (*models.Wallet, error)
 	GetWalletByID(ctx context.Context, id, ownerID uuid.UUID) (*models.Wallet, error)
+	GetWallets(ctx context.Context, ownerID uuid.UUID, params models.Params) ([]*models.Wallet, error)
 	UpdateWallet(ctx context.Context, walletID, ownerID uuid.UUID, walletDTO models.WalletDTO) (*models.Wallet, error)
 	DeleteWallet(context context.Context, id, ownerID uuid.UUID) error
-	Deposit(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error
-	Transfer(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error
-	Withdraw(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error
-	GetTransactions(ctx context.Context, ID uuid.UUID, params models.Params) ([]*models.Transaction, error)
+	GetDeletedWallets(ctx context.Context, ownerID uuid.UUID) ([]*models.Wallet, error)
+	RestoreWallet(ctx context.Context, id, ownerID uuid.UUID) (*models.Wallet, error)
+	Deposit(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error)
+	Transfer(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error)
+	Withdraw(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error)
+	Exchange(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error)
+	GetTransactions(ctx context.Context, id, ownerID uuid.UUID, params models.Params) ([]*models.Transaction, error)
+	Reverse(ctx context.Context, id uuid.UUID, amount *models.Decimal) (*models.Transaction, error)
+	CreateHold(ctx context.Context, hold models.Hold, ownerID uuid.UUID) (*models.Hold, error)
+	GetHold(ctx context.Context, id, ownerID uuid.UUID) (*models.Hold, error)
+	CaptureHold(ctx context.Context, id, ownerID uuid.UUID, amount *models.Decimal) (*models.Transaction, error)
+	VoidHold(ctx context.Context, id, ownerID uuid.UUID) (*models.Hold, error)
+	CreateSchedule(ctx context.Context, schedule models.Schedule, ownerID uuid.UUID) (*models.Schedule, error)
+	GetSchedules(ctx context.Context, ownerID uuid.UUID) ([]*models.Schedule, error)
+	GetSchedule(ctx context.Context, id, ownerID uuid.UUID) (*models.Schedule, error)
+	UpdateSchedule(ctx context.Context, id, ownerID uuid.UUID, schedule models.Schedule) (*models.Schedule, error)
+	DeleteSchedule(ctx context.Context, id, ownerID uuid.UUID) error
+	GetScheduleRuns(ctx context.Context, id, ownerID uuid.UUID, params models.Params) ([]*models.ScheduleRun, error)
+	CreateQuote(ctx context.Context, request models.QuoteRequest, ownerID uuid.UUID) (*models.Quote, error)
+	GetExchangeRate(ctx context.Context, request models.RateRequest) (*models.PairRate, error)
+	Login(ctx context.Context, credentials models.Credentials) (*models.TokenPair, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*models.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	IntrospectAccessToken(ctx context.Context, claims models.Claims) (*models.TokenIntrospection, error)
+	IntrospectRefreshToken(ctx context.Context, refreshToken string) (*models.TokenIntrospection, error)
+	FindUsers(ctx context.Context, params models.Params) ([]*models.User, error)
+	GetUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+	SetUserRoles(ctx context.Context, id uuid.UUID, roles []string) (*models.User, error)
+	SetUserTier(ctx context.Context, id uuid.UUID, tier string) (*models.User, error)
+	GetCurrencies(ctx context.Context) ([]*models.Currency, error)
+	CreateCurrency(ctx context.Context, currency models.Currency) (*models.Currency, error)
+	UpdateCurrency(ctx context.Context, code string, currencyDTO models.CurrencyDTO) (*models.Currency, error)
+	GetUserWallets(ctx context.Context, userID uuid.UUID, params models.Params) ([]*models.Wallet, error)
+	GetAnyWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	GetAnyTransactions(ctx context.Context, id uuid.UUID, params models.Params) ([]*models.Transaction, error)
+	SetWalletStatus(ctx context.Context, id, actorID uuid.UUID, status, reason string) (*models.Wallet, error)
+	FreezeWallet(ctx context.Context, id, ownerID uuid.UUID, reason string) (*models.Wallet, error)
+	UnfreezeWallet(ctx context.Context, id, ownerID uuid.UUID, reason string) (*models.Wallet, error)
+	ReactivateWallet(ctx context.Context, id, ownerID uuid.UUID, reason string) (*models.Wallet, error)
+	CloseWallet(ctx context.Context, id, ownerID uuid.UUID, request models.WalletStatusRequest) (*models.Wallet, error)
+	CloseAnyWallet(ctx context.Context, id, actorID uuid.UUID, request models.WalletStatusRequest) (*models.Wallet, error)
+	GetWalletStatusChanges(ctx context.Context, id, ownerID uuid.UUID) ([]*models.WalletStatusChange, error)
+	GetAnyWalletStatusChanges(ctx context.Context, id uuid.UUID) ([]*models.WalletStatusChange, error)
+	GetDormancyRuns(ctx context.Context, params models.Params) ([]*models.DormancyRun, error)
 }
 
 type HTTPResponse struct {
@@ -112,6 +159,38 @@ func (s *Server) getWalletByID(w http.ResponseWriter, r *http.Request) {
 	writeOkResponse(w, http.StatusOK, wallet)
 }
 
+func (s *Server) getWallets(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getWallets", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	params, err := parseParams(r.URL.Query())
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid query parameters")
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	wallets, err := s.service.GetWallets(r.Context(), ownerID, *params)
+
+	switch {
+	case errors.Is(err, models.ErrSortingNotAllowed):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get wallets: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, wallets)
+}
+
 func (s *Server) updateWallet(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	defer func() {
@@ -144,7 +223,19 @@ func (s *Server) updateWallet(w http.ResponseWriter, r *http.Request) {
 	ownerID := s.getOwnerIDFromRequest(r)
 
 	wallet, err := s.service.UpdateWallet(r.Context(), walletID, ownerID, walletDTO)
-	if err != nil {
+
+	switch {
+	case errors.Is(err, models.ErrWalletHasHolds),
+		errors.Is(err, models.ErrMultiCurrencyWallet),
+		errors.Is(err, models.ErrWalletHasPockets):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case errors.Is(err, models.ErrExchangeRateNotFound):
+		writeErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+
+		return
+	case err != nil:
 		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
 		log.Warnf("failed to update wallet: %v", err)
 
@@ -186,6 +277,57 @@ func (s *Server) deleteWallet(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (s *Server) getDeletedWallets(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getDeletedWallets", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	wallets, err := s.service.GetDeletedWallets(r.Context(), s.getOwnerIDFromRequest(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get deleted wallets: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, wallets)
+}
+
+func (s *Server) restoreWallet(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("restoreWallet", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	walletID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	wallet, err := s.service.RestoreWallet(r.Context(), walletID, s.getOwnerIDFromRequest(r))
+
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case errors.Is(err, models.ErrRestorePeriodExpired):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to restore wallet: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, wallet)
+}
+
 func (s *Server) deposit(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	defer func() {
@@ -202,6 +344,13 @@ func (s *Server) deposit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The operation type and the fields the service derives are not taken from the client.
+	transaction.OperationType = models.OperationDeposit
+	transaction.TargetWalletID = uuid.Nil
+	transaction.TargetOwnerID = uuid.Nil
+	transaction.TargetCurrency = ""
+	transaction.OriginalID = uuid.Nil
+
 	if err := transaction.Validate(); err != nil {
 		writeErrorResponse(w, http.StatusBadRequest, err.Error())
 
@@ -210,12 +359,40 @@ func (s *Server) deposit(w http.ResponseWriter, r *http.Request) {
 
 	ownerID := s.getOwnerIDFromRequest(r)
 
-	err := s.service.Deposit(r.Context(), transaction, ownerID)
+	idempotencyKey, err := getIdempotencyKey(r, transaction)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	transaction.IdempotencyKey = idempotencyKey
+
+	executedTransaction, err := s.service.Deposit(r.Context(), transaction, ownerID)
 
 	switch {
-	case errors.Is(err, models.ErrWalletNotFound):
+	case errors.Is(err, models.ErrWalletNotFound), errors.Is(err, models.ErrQuoteNotFound):
 		writeErrorResponse(w, http.StatusNotFound, err.Error())
 
+		return
+	case errors.Is(err, models.ErrBalanceBelowZero), errors.Is(err, models.ErrQuoteMismatch):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case errors.Is(err, models.ErrIdempotencyKeyConflict),
+		errors.Is(err, models.ErrDuplicateTransaction),
+		errors.Is(err, models.ErrWalletFrozen),
+		errors.Is(err, models.ErrWalletClosing),
+		errors.Is(err, models.ErrWalletClosed),
+		errors.Is(err, models.ErrWalletDormant),
+		errors.Is(err, models.ErrQuoteExpired),
+		errors.Is(err, models.ErrQuoteUsed):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case errors.Is(err, models.ErrExchangeRateNotFound):
+		writeErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+
 		return
 	case err != nil:
 		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
@@ -224,7 +401,7 @@ func (s *Server) deposit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeOkResponse(w, http.StatusOK, nil)
+	writeOkResponse(w, http.StatusOK, executedTransaction)
 }
 
 //nolint:dupl
@@ -246,22 +423,51 @@ func (s *Server) transfer(w http.ResponseWriter, r *http.Request) {
 
 	ownerID := s.getOwnerIDFromRequest(r)
 
+	// The operation type and the fields the service derives are not taken from the client.
+	transaction.OperationType = models.OperationTransfer
+	transaction.TargetOwnerID = uuid.Nil
+	transaction.OriginalID = uuid.Nil
+
 	if err := transaction.Validate(); err != nil {
 		writeErrorResponse(w, http.StatusBadRequest, err.Error())
 
 		return
 	}
 
-	err := s.service.Transfer(r.Context(), transaction, ownerID)
+	idempotencyKey, err := getIdempotencyKey(r, transaction)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	transaction.IdempotencyKey = idempotencyKey
+
+	executedTransaction, err := s.service.Transfer(r.Context(), transaction, ownerID)
 
 	switch {
-	case errors.Is(err, models.ErrWalletNotFound):
+	case errors.Is(err, models.ErrWalletNotFound), errors.Is(err, models.ErrQuoteNotFound):
 		writeErrorResponse(w, http.StatusNotFound, err.Error())
 
 		return
-	case errors.Is(err, models.ErrBalanceBelowZero):
+	case errors.Is(err, models.ErrBalanceBelowZero), errors.Is(err, models.ErrQuoteMismatch):
 		writeErrorResponse(w, http.StatusBadRequest, err.Error())
 
+		return
+	case errors.Is(err, models.ErrIdempotencyKeyConflict),
+		errors.Is(err, models.ErrDuplicateTransaction),
+		errors.Is(err, models.ErrWalletFrozen),
+		errors.Is(err, models.ErrWalletClosing),
+		errors.Is(err, models.ErrWalletClosed),
+		errors.Is(err, models.ErrWalletDormant),
+		errors.Is(err, models.ErrQuoteExpired),
+		errors.Is(err, models.ErrQuoteUsed):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case errors.Is(err, models.ErrExchangeRateNotFound):
+		writeErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+
 		return
 	case err != nil:
 		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
@@ -270,7 +476,7 @@ func (s *Server) transfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeOkResponse(w, http.StatusOK, nil)
+	writeOkResponse(w, http.StatusOK, executedTransaction)
 }
 
 //nolint:dupl
@@ -292,22 +498,53 @@ func (s *Server) withdraw(w http.ResponseWriter, r *http.Request) {
 
 	ownerID := s.getOwnerIDFromRequest(r)
 
+	// The operation type and the fields the service derives are not taken from the client.
+	transaction.OperationType = models.OperationWithdraw
+	transaction.TargetWalletID = uuid.Nil
+	transaction.TargetOwnerID = uuid.Nil
+	transaction.TargetCurrency = ""
+	transaction.OriginalID = uuid.Nil
+
 	if err := transaction.Validate(); err != nil {
 		writeErrorResponse(w, http.StatusBadRequest, err.Error())
 
 		return
 	}
 
-	err := s.service.Withdraw(r.Context(), transaction, ownerID)
+	idempotencyKey, err := getIdempotencyKey(r, transaction)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	transaction.IdempotencyKey = idempotencyKey
+
+	executedTransaction, err := s.service.Withdraw(r.Context(), transaction, ownerID)
 
 	switch {
-	case errors.Is(err, models.ErrWalletNotFound):
+	case errors.Is(err, models.ErrWalletNotFound), errors.Is(err, models.ErrQuoteNotFound):
 		writeErrorResponse(w, http.StatusNotFound, err.Error())
 
 		return
-	case errors.Is(err, models.ErrBalanceBelowZero):
+	case errors.Is(err, models.ErrBalanceBelowZero), errors.Is(err, models.ErrQuoteMismatch):
 		writeErrorResponse(w, http.StatusBadRequest, err.Error())
 
+		return
+	case errors.Is(err, models.ErrIdempotencyKeyConflict),
+		errors.Is(err, models.ErrDuplicateTransaction),
+		errors.Is(err, models.ErrWalletFrozen),
+		errors.Is(err, models.ErrWalletClosing),
+		errors.Is(err, models.ErrWalletClosed),
+		errors.Is(err, models.ErrWalletDormant),
+		errors.Is(err, models.ErrQuoteExpired),
+		errors.Is(err, models.ErrQuoteUsed):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case errors.Is(err, models.ErrExchangeRateNotFound):
+		writeErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+
 		return
 	case err != nil:
 		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
@@ -316,7 +553,81 @@ func (s *Server) withdraw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeOkResponse(w, http.StatusOK, nil)
+	writeOkResponse(w, http.StatusOK, executedTransaction)
+}
+
+// exchange converts money between the balances of a multi-currency wallet.
+func (s *Server) exchange(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("exchange", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var transaction models.Transaction
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&transaction); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	transaction.OperationType = models.OperationExchange
+	transaction.OriginalID = uuid.Nil
+
+	if err := transaction.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	idempotencyKey, err := getIdempotencyKey(r, transaction)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	transaction.IdempotencyKey = idempotencyKey
+
+	executedTransaction, err := s.service.Exchange(r.Context(), transaction, ownerID)
+
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound), errors.Is(err, models.ErrQuoteNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case errors.Is(err, models.ErrBalanceBelowZero), errors.Is(err, models.ErrQuoteMismatch):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case errors.Is(err, models.ErrIdempotencyKeyConflict),
+		errors.Is(err, models.ErrDuplicateTransaction),
+		errors.Is(err, models.ErrWalletFrozen),
+		errors.Is(err, models.ErrWalletClosing),
+		errors.Is(err, models.ErrWalletClosed),
+		errors.Is(err, models.ErrWalletDormant),
+		errors.Is(err, models.ErrNotMultiCurrency),
+		errors.Is(err, models.ErrQuoteExpired),
+		errors.Is(err, models.ErrQuoteUsed):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case errors.Is(err, models.ErrExchangeRateNotFound):
+		writeErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to exchange transaction: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, executedTransaction)
 }
 
 func (s *Server) getTransactions(w http.ResponseWriter, r *http.Request) {
@@ -347,12 +658,18 @@ func (s *Server) getTransactions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transactions, err := s.service.GetTransactions(r.Context(), id, *params)
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	transactions, err := s.service.GetTransactions(r.Context(), id, ownerID, *params)
 
 	switch {
-	case errors.Is(err, models.ErrTransactionsNotFound):
+	case errors.Is(err, models.ErrTransactionsNotFound), errors.Is(err, models.ErrWalletNotFound):
 		writeErrorResponse(w, http.StatusNotFound, "wallet not found")
 
+		return
+	case errors.Is(err, models.ErrSortingNotAllowed):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
 		return
 	case err != nil:
 		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
@@ -364,6 +681,81 @@ func (s *Server) getTransactions(w http.ResponseWriter, r *http.Request) {
 	writeOkResponse(w, http.StatusOK, transactions)
 }
 
+func (s *Server) reverseTransaction(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("reverseTransaction", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var reversal models.Reversal
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&reversal); err != nil && !errors.Is(err, io.EOF) {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid transaction id")
+
+		return
+	}
+
+	executedTransaction, err := s.service.Reverse(r.Context(), id, reversal.Amount)
+
+	switch {
+	case errors.Is(err, models.ErrTransactionsNotFound), errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case errors.Is(err, models.ErrAmountIsZero),
+		errors.Is(err, models.ErrAmountPrecision),
+		errors.Is(err, models.ErrRefundExceedsAmount),
+		errors.Is(err, models.ErrBalanceBelowZero):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case errors.Is(err, models.ErrAlreadyReversed),
+		errors.Is(err, models.ErrWalletFrozen),
+		errors.Is(err, models.ErrWalletClosing),
+		errors.Is(err, models.ErrWalletClosed),
+		errors.Is(err, models.ErrWalletDormant),
+		errors.Is(err, models.ErrNotMultiCurrency),
+		errors.Is(err, models.ErrWalletCurrencyChanged):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case errors.Is(err, models.ErrNotReversible):
+		writeErrorResponse(w, http.StatusUnprocessableEntity, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to reverse transaction: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, executedTransaction)
+}
+
+// getIdempotencyKey returns the Idempotency-Key header, falling back to the client-supplied transaction ID.
+func getIdempotencyKey(r *http.Request, transaction models.Transaction) (string, error) {
+	key := r.Header.Get(idempotencyKeyHeader)
+
+	switch {
+	case len(key) > maxIdempotencyKeyLength:
+		return "", models.ErrIdempotencyKeyTooLong
+	case key == "" && transaction.TransactionID != uuid.Nil:
+		return transaction.TransactionID.String(), nil
+	}
+
+	return key, nil
+}
+
 func parseParams(query url.Values) (*models.Params, error) {
 	var params models.Params
 
@@ -371,8 +763,15 @@ func parseParams(query url.Values) (*models.Params, error) {
 		return nil, fmt.Errorf("schema.NewDecoder().Decode(params, query) err: %w", err)
 	}
 
-	if params.Limit == 0 {
+	if params.Limit < 0 || params.Offset < 0 {
+		return nil, models.ErrInvalidPagination
+	}
+
+	switch {
+	case params.Limit == 0:
 		params.Limit = standartPage
+	case params.Limit > maxPage:
+		params.Limit = maxPage
 	}
 
 	return &params, nil
@@ -0,0 +1,196 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *Server) createHold(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("createHold", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var hold models.Hold
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&hold); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if err := hold.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	createdHold, err := s.service.CreateHold(r.Context(), hold, ownerID)
+
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case errors.Is(err, models.ErrBalanceBelowZero), errors.Is(err, models.ErrHoldExpiresInPast):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case errors.Is(err, models.ErrDuplicateHold),
+		errors.Is(err, models.ErrWalletFrozen),
+		errors.Is(err, models.ErrWalletClosing),
+		errors.Is(err, models.ErrWalletClosed),
+		errors.Is(err, models.ErrWalletDormant):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case errors.Is(err, models.ErrExchangeRateNotFound):
+		writeErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to create hold: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusCreated, createdHold)
+}
+
+func (s *Server) getHold(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getHold", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid hold id")
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	hold, err := s.service.GetHold(r.Context(), id, ownerID)
+
+	switch {
+	case errors.Is(err, models.ErrHoldNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get hold: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, hold)
+}
+
+func (s *Server) captureHold(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("captureHold", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var capture models.Capture
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&capture); err != nil && !errors.Is(err, io.EOF) {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid hold id")
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	executedTransaction, err := s.service.CaptureHold(r.Context(), id, ownerID, capture.Amount)
+
+	switch {
+	case errors.Is(err, models.ErrHoldNotFound), errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case errors.Is(err, models.ErrAmountIsZero),
+		errors.Is(err, models.ErrAmountPrecision),
+		errors.Is(err, models.ErrCaptureExceedsHold),
+		errors.Is(err, models.ErrBalanceBelowZero):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case errors.Is(err, models.ErrHoldNotActive),
+		errors.Is(err, models.ErrWalletFrozen),
+		errors.Is(err, models.ErrWalletClosing),
+		errors.Is(err, models.ErrWalletClosed),
+		errors.Is(err, models.ErrWalletDormant):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to capture hold: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, executedTransaction)
+}
+
+func (s *Server) voidHold(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("voidHold", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid hold id")
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	hold, err := s.service.VoidHold(r.Context(), id, ownerID)
+
+	switch {
+	case errors.Is(err, models.ErrHoldNotFound), errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case errors.Is(err, models.ErrHoldNotActive):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to void hold: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, hold)
+}
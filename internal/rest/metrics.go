@@ -6,7 +6,8 @@ import (
 )
 
 type metrics struct {
-	requestsDuration *prometheus.HistogramVec
+	requestsDuration    *prometheus.HistogramVec
+	rateLimitedRequests *prometheus.CounterVec
 }
 
 const (
@@ -24,5 +25,12 @@ func newMetrics() *metrics {
 		},
 			[]string{"method", "path"},
 		),
+		promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: subsystem,
+			Name:      "rate_limited_requests_total",
+			Help:      "requests rejected by rate limits",
+		},
+			[]string{"limiter", "key_type"},
+		),
 	}
 }
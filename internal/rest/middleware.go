@@ -2,24 +2,34 @@ package rest
 
 import (
 	"context"
-	"crypto/rsa"
 	"errors"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/ratelimit"
 	log "github.com/sirupsen/logrus"
 )
 
 const headerLength = 2
 
+//nolint:gochecknoglobals
+var validSigningMethods = []string{
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodRS384.Alg(),
+	jwt.SigningMethodRS512.Alg(),
+}
+
 func (s *Server) jwtAuth(next http.Handler) http.Handler {
 	var fn http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
-		claims, err := getClaimsFromHeader(r.Header.Get("Authorization"), s.key)
+		claims, err := s.getClaimsFromHeader(r.Context(), r.Header.Get("Authorization"))
 		switch {
 		case errors.Is(err, models.ErrInvalidAccessToken):
 			writeErrorResponse(w, http.StatusUnauthorized, "invalid access token")
@@ -32,15 +42,20 @@ func (s *Server) jwtAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		expiresAtTime := time.Unix(claims.ExpiresAt.Unix(), 0)
-		if expiresAtTime.Before(time.Now()) {
+		if claims.ExpiresAt == nil || claims.ExpiresAt.Before(time.Now()) {
 			writeErrorResponse(w, http.StatusUnauthorized, "invalid access token")
 
 			return
 		}
 
 		userInfo := models.UserInfo{
-			ID: claims.UUID,
+			ID:    claims.UUID,
+			Roles: claims.Roles,
+		}
+
+		// Tokens issued before roles were introduced carry none; their holders are wallet owners.
+		if len(userInfo.Roles) == 0 {
+			userInfo.Roles = []string{models.RoleOwner}
 		}
 
 		r = r.WithContext(context.WithValue(r.Context(), models.UserInfoKey, userInfo))
@@ -50,7 +65,68 @@ func (s *Server) jwtAuth(next http.Handler) http.Handler {
 	return fn
 }
 
-func getClaimsFromHeader(authHeader string, key *rsa.PublicKey) (*models.Claims, error) {
+// requirePermission rejects requests whose token roles don't grant the permission. It must run after jwtAuth.
+func (s *Server) requirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		var fn http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
+			userInfo, _ := r.Context().Value(models.UserInfoKey).(models.UserInfo)
+
+			if !models.HasPermission(userInfo.Roles, permission) {
+				writeErrorResponse(w, http.StatusForbidden, "forbidden")
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return fn
+	}
+}
+
+// rateLimit rejects requests over the limit with 429 and a Retry-After header. Each call creates
+// its own buckets, so every route it is applied to is limited separately. Requests are counted
+// per user when jwtAuth has run before it, and per client IP otherwise.
+func (s *Server) rateLimit(name string, limit ratelimit.Limit) func(http.Handler) http.Handler {
+	limiter := ratelimit.New(limit)
+
+	return func(next http.Handler) http.Handler {
+		var fn http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
+			keyType, key := "user", ""
+
+			if userInfo, ok := r.Context().Value(models.UserInfoKey).(models.UserInfo); ok {
+				key = userInfo.ID.String()
+			} else {
+				keyType, key = "ip", clientIP(r)
+			}
+
+			allowed, retryAfter := limiter.Allow(keyType + ":" + key)
+			if !allowed {
+				s.metrics.rateLimitedRequests.WithLabelValues(name, keyType).Inc()
+
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				writeErrorResponse(w, http.StatusTooManyRequests, "too many requests")
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return fn
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func (s *Server) getClaimsFromHeader(ctx context.Context, authHeader string) (*models.Claims, error) {
 	if authHeader == "" {
 		return nil, models.ErrHeaderIsEmpty
 	}
@@ -61,31 +137,48 @@ func getClaimsFromHeader(authHeader string, key *rsa.PublicKey) (*models.Claims,
 		return nil, models.ErrInvalidAccessToken
 	}
 
-	claims, err := parseToken(headerParts[1], key)
+	claims, err := s.parseToken(ctx, headerParts[1])
 
 	switch {
 	case errors.Is(err, models.ErrInvalidAccessToken):
 		return nil, models.ErrInvalidAccessToken
 	case err != nil:
-		return nil, fmt.Errorf("jwt.parseToken(headerParts[1], key) err: %w", err)
+		return nil, fmt.Errorf("s.parseToken(headerParts[1]) err: %w", err)
 	}
 
 	return claims, nil
 }
 
-func parseToken(accessToken string, key *rsa.PublicKey) (*models.Claims, error) {
-	token, err := jwt.ParseWithClaims(accessToken, &models.Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, models.ErrInvalidAccessToken
-		}
+// parseToken verifies the token with the key selected by its "kid" header, requires it to expire,
+// and checks the issuer and audience when they are configured.
+func (s *Server) parseToken(ctx context.Context, accessToken string) (*models.Claims, error) {
+	options := []jwt.ParserOption{jwt.WithValidMethods(validSigningMethods), jwt.WithExpirationRequired()}
 
-		return key, nil
-	})
-	if errors.Is(err, jwt.ErrTokenMalformed) {
-		return nil, models.ErrInvalidAccessToken
+	if s.serverConfig.Issuer != "" {
+		options = append(options, jwt.WithIssuer(s.serverConfig.Issuer))
 	}
 
-	if err != nil {
+	if s.serverConfig.Audience != "" {
+		options = append(options, jwt.WithAudience(s.serverConfig.Audience))
+	}
+
+	token, err := jwt.ParseWithClaims(accessToken, &models.Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		return s.keys.Key(ctx, kid)
+	}, options...)
+
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed),
+		errors.Is(err, jwt.ErrTokenSignatureInvalid),
+		errors.Is(err, jwt.ErrTokenUnverifiable),
+		errors.Is(err, jwt.ErrTokenInvalidIssuer),
+		errors.Is(err, jwt.ErrTokenInvalidAudience),
+		errors.Is(err, jwt.ErrTokenExpired),
+		errors.Is(err, jwt.ErrTokenRequiredClaimMissing),
+		errors.Is(err, models.ErrUnknownKeyID):
+		return nil, models.ErrInvalidAccessToken
+	case err != nil:
 		return nil, fmt.Errorf("jwt.ParseWithClaims err: %w", err)
 	}
 
@@ -0,0 +1,232 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *Server) createSchedule(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("createSchedule", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var schedule models.Schedule
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if err := schedule.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	createdSchedule, err := s.service.CreateSchedule(r.Context(), schedule, ownerID)
+
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case errors.Is(err, models.ErrInvalidSchedule):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to create schedule: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusCreated, createdSchedule)
+}
+
+func (s *Server) getSchedules(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getSchedules", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	schedules, err := s.service.GetSchedules(r.Context(), ownerID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get schedules: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, schedules)
+}
+
+func (s *Server) getSchedule(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getSchedule", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid schedule id")
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	schedule, err := s.service.GetSchedule(r.Context(), id, ownerID)
+
+	switch {
+	case errors.Is(err, models.ErrScheduleNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get schedule: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, schedule)
+}
+
+func (s *Server) updateSchedule(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("updateSchedule", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var schedule models.Schedule
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	if err := schedule.Validate(); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid schedule id")
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	updatedSchedule, err := s.service.UpdateSchedule(r.Context(), id, ownerID, schedule)
+
+	switch {
+	case errors.Is(err, models.ErrScheduleNotFound), errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case errors.Is(err, models.ErrInvalidSchedule):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to update schedule: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, updatedSchedule)
+}
+
+func (s *Server) deleteSchedule(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("deleteSchedule", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid schedule id")
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	err = s.service.DeleteSchedule(r.Context(), id, ownerID)
+
+	switch {
+	case errors.Is(err, models.ErrScheduleNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to delete schedule: %v", err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getScheduleRuns(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getScheduleRuns", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	params, err := parseParams(r.URL.Query())
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid query parameters")
+
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid schedule id")
+
+		return
+	}
+
+	ownerID := s.getOwnerIDFromRequest(r)
+
+	runs, err := s.service.GetScheduleRuns(r.Context(), id, ownerID, *params)
+
+	switch {
+	case errors.Is(err, models.ErrScheduleNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get schedule runs: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, runs)
+}
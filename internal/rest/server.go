@@ -9,12 +9,28 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/ratelimit"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 )
 
 type ServerConfig struct {
 	BindAddress string
+	// Issuer and Audience are checked against the "iss" and "aud" claims of access tokens when set.
+	Issuer     string
+	Audience   string
+	RateLimits RateLimits
+}
+
+// RateLimits are applied per user, or per client IP before authentication.
+type RateLimits struct {
+	// Default applies to all authenticated requests together.
+	Default ratelimit.Limit
+	// Operations applies to each endpoint that moves money separately.
+	Operations ratelimit.Limit
+	// Auth applies to the token endpoints, per client IP.
+	Auth ratelimit.Limit
 }
 
 const (
@@ -26,20 +42,24 @@ const (
 type Server struct {
 	serverConfig ServerConfig
 	service      service
-	key          *rsa.PublicKey
+	keys         keySet
 	router       *chi.Mux
 	server       *http.Server
 	metrics      *metrics
 }
 
-func NewServer(serverConfig ServerConfig, srv service, key *rsa.PublicKey) (*Server, error) {
+type keySet interface {
+	Key(ctx context.Context, kid string) (*rsa.PublicKey, error)
+}
+
+func NewServer(serverConfig ServerConfig, srv service, keys keySet) (*Server, error) {
 	router := chi.NewRouter()
 
 	return &Server{
 		serverConfig: serverConfig,
 		service:      srv,
 		router:       router,
-		key:          key,
+		keys:         keys,
 		server: &http.Server{
 			Addr:              serverConfig.BindAddress,
 			Handler:           router,
@@ -74,21 +94,99 @@ func (s *Server) Start(ctx context.Context) error {
 }
 
 func (s *Server) configRouter() {
-	s.router.Route("/api", func(r chi.Router) {
-		r.Use(s.jwtAuth)
+	limits := s.serverConfig.RateLimits
 
+	s.router.Route("/api", func(r chi.Router) {
 		r.Route("/v1", func(r chi.Router) {
-			r.Route("/wallets", func(r chi.Router) {
-				r.Post("/", s.createWallet)
-				r.Get("/{id}", s.getWalletByID)
-				r.Patch("/{id}", s.updateWallet)
-				r.Delete("/{id}", s.deleteWallet)
+			r.Route("/auth", func(r chi.Router) {
+				r.Use(s.rateLimit("auth", limits.Auth))
+
+				r.Post("/login", s.login)
+				r.Post("/refresh", s.refreshTokens)
+				r.Post("/logout", s.logout)
+				r.With(s.jwtAuth).Post("/introspect", s.introspectToken)
+			})
 
-				r.Put("/withdraw", s.withdraw)
-				r.Put("/transfer", s.transfer)
-				r.Put("/deposit", s.deposit)
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(s.jwtAuth)
+				r.Use(s.rateLimit("admin", limits.Default))
+
+				r.Route("/users", func(r chi.Router) {
+					r.With(s.requirePermission(models.PermissionReadUsers)).Get("/", s.findUsers)
+					r.With(s.requirePermission(models.PermissionReadUsers)).Get("/{id}", s.getUser)
+					r.With(s.requirePermission(models.PermissionManageRoles)).Put("/{id}/roles", s.setUserRoles)
+					r.With(s.requirePermission(models.PermissionManageTiers)).Put("/{id}/tier", s.setUserTier)
+					r.With(s.requirePermission(models.PermissionReadAll)).Get("/{id}/wallets", s.getUserWallets)
+				})
+
+				r.Route("/currencies", func(r chi.Router) {
+					r.With(s.requirePermission(models.PermissionReadAll)).Get("/", s.getCurrencies)
+					r.With(s.requirePermission(models.PermissionManageCurrencies)).Post("/", s.createCurrency)
+					r.With(s.requirePermission(models.PermissionManageCurrencies)).Put("/{code}", s.updateCurrency)
+				})
+
+				r.Route("/wallets", func(r chi.Router) {
+					r.With(s.requirePermission(models.PermissionReadAll)).Get("/dormancy-runs", s.getDormancyRuns)
+					r.With(s.requirePermission(models.PermissionReadAll)).Get("/{id}", s.getAnyWallet)
+					r.With(s.requirePermission(models.PermissionReadAll)).Get("/{id}/transactions", s.getAnyTransactions)
+					r.With(s.requirePermission(models.PermissionFreeze)).Post("/{id}/freeze", s.freezeWallet)
+					r.With(s.requirePermission(models.PermissionFreeze)).Post("/{id}/unfreeze", s.unfreezeWallet)
+					r.With(s.requirePermission(models.PermissionCloseWallets)).Post("/{id}/close", s.closeAnyWallet)
+					r.With(s.requirePermission(models.PermissionReadAll)).Get("/{id}/status-history", s.getAnyWalletStatusChanges)
+				})
+
+				r.Route("/transactions", func(r chi.Router) {
+					r.With(s.requirePermission(models.PermissionReverse)).Post("/{id}/reverse", s.reverseTransaction)
+				})
+			})
 
-				r.Get("/{id}/transactions", s.getTransactions)
+			r.Group(func(r chi.Router) {
+				r.Use(s.jwtAuth)
+				r.Use(s.requirePermission(models.PermissionOwnWallets))
+				r.Use(s.rateLimit("default", limits.Default))
+
+				r.Route("/wallets", func(r chi.Router) {
+					r.Post("/", s.createWallet)
+					r.Get("/", s.getWallets)
+					r.Get("/deleted", s.getDeletedWallets)
+					r.Get("/{id}", s.getWalletByID)
+					r.Patch("/{id}", s.updateWallet)
+					r.Delete("/{id}", s.deleteWallet)
+					r.Post("/{id}/restore", s.restoreWallet)
+
+					r.With(s.rateLimit("withdraw", limits.Operations)).Put("/withdraw", s.withdraw)
+					r.With(s.rateLimit("transfer", limits.Operations)).Put("/transfer", s.transfer)
+					r.With(s.rateLimit("deposit", limits.Operations)).Put("/deposit", s.deposit)
+					r.With(s.rateLimit("exchange", limits.Operations)).Put("/exchange", s.exchange)
+
+					r.Get("/{id}/transactions", s.getTransactions)
+					r.Get("/{id}/status-history", s.getWalletStatusChanges)
+					r.Post("/{id}/freeze", s.freezeOwnWallet)
+					r.Post("/{id}/unfreeze", s.unfreezeOwnWallet)
+					r.Post("/{id}/reactivate", s.reactivateWallet)
+					r.With(s.rateLimit("closeWallet", limits.Operations)).Post("/{id}/close", s.closeWallet)
+				})
+
+				r.Route("/holds", func(r chi.Router) {
+					r.With(s.rateLimit("createHold", limits.Operations)).Post("/", s.createHold)
+					r.Get("/{id}", s.getHold)
+					r.With(s.rateLimit("captureHold", limits.Operations)).Post("/{id}/capture", s.captureHold)
+					r.Post("/{id}/void", s.voidHold)
+				})
+
+				r.Route("/schedules", func(r chi.Router) {
+					r.Post("/", s.createSchedule)
+					r.Get("/", s.getSchedules)
+					r.Get("/{id}", s.getSchedule)
+					r.Put("/{id}", s.updateSchedule)
+					r.Delete("/{id}", s.deleteSchedule)
+					r.Get("/{id}/runs", s.getScheduleRuns)
+				})
+
+				r.Route("/fx", func(r chi.Router) {
+					r.Post("/quotes", s.createQuote)
+					r.Get("/rates", s.getExchangeRate)
+				})
 			})
 		})
 	})
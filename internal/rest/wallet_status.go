@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// statusChange changes the status of the wallet on behalf of the user making the request.
+type statusChange func(ctx context.Context, id, actorID uuid.UUID, request models.WalletStatusRequest) (*models.Wallet, error)
+
+func (s *Server) freezeOwnWallet(w http.ResponseWriter, r *http.Request) {
+	s.changeWalletStatus(w, r, "freezeOwnWallet", func(ctx context.Context, id, actorID uuid.UUID, request models.WalletStatusRequest) (
+		*models.Wallet, error,
+	) {
+		return s.service.FreezeWallet(ctx, id, actorID, request.Reason)
+	})
+}
+
+func (s *Server) unfreezeOwnWallet(w http.ResponseWriter, r *http.Request) {
+	s.changeWalletStatus(w, r, "unfreezeOwnWallet", func(ctx context.Context, id, actorID uuid.UUID, request models.WalletStatusRequest) (
+		*models.Wallet, error,
+	) {
+		return s.service.UnfreezeWallet(ctx, id, actorID, request.Reason)
+	})
+}
+
+func (s *Server) reactivateWallet(w http.ResponseWriter, r *http.Request) {
+	s.changeWalletStatus(w, r, "reactivateWallet", func(ctx context.Context, id, actorID uuid.UUID, request models.WalletStatusRequest) (
+		*models.Wallet, error,
+	) {
+		return s.service.ReactivateWallet(ctx, id, actorID, request.Reason)
+	})
+}
+
+func (s *Server) closeWallet(w http.ResponseWriter, r *http.Request) {
+	s.changeWalletStatus(w, r, "closeWallet", s.service.CloseWallet)
+}
+
+func (s *Server) closeAnyWallet(w http.ResponseWriter, r *http.Request) {
+	s.changeWalletStatus(w, r, "closeAnyWallet", s.service.CloseAnyWallet)
+}
+
+func (s *Server) changeWalletStatus(w http.ResponseWriter, r *http.Request, handlerName string, change statusChange) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues(handlerName, r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	var request models.WalletStatusRequest
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid wallet id")
+
+		return
+	}
+
+	wallet, err := change(r.Context(), id, s.getOwnerIDFromRequest(r), request)
+
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case errors.Is(err, models.ErrInvalidSweepTarget):
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		return
+	case errors.Is(err, models.ErrInvalidStatusTransition),
+		errors.Is(err, models.ErrFrozenByStaff),
+		errors.Is(err, models.ErrWalletHasHolds),
+		errors.Is(err, models.ErrWalletNotEmpty),
+		errors.Is(err, models.ErrWalletFrozen),
+		errors.Is(err, models.ErrWalletClosing),
+		errors.Is(err, models.ErrWalletClosed),
+		errors.Is(err, models.ErrWalletDormant):
+		writeErrorResponse(w, http.StatusConflict, err.Error())
+
+		return
+	case errors.Is(err, models.ErrExchangeRateNotFound):
+		writeErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to change wallet status: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, wallet)
+}
+
+func (s *Server) getWalletStatusChanges(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getWalletStatusChanges", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid wallet id")
+
+		return
+	}
+
+	changes, err := s.service.GetWalletStatusChanges(r.Context(), id, s.getOwnerIDFromRequest(r))
+
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get wallet status changes: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, changes)
+}
+
+func (s *Server) getAnyWalletStatusChanges(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	defer func() {
+		s.metrics.requestsDuration.WithLabelValues("getAnyWalletStatusChanges", r.URL.Path).Observe(time.Since(startTime).Seconds())
+	}()
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid wallet id")
+
+		return
+	}
+
+	changes, err := s.service.GetAnyWalletStatusChanges(r.Context(), id)
+
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound):
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+
+		return
+	case err != nil:
+		writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		log.Warnf("failed to get wallet status changes: %v", err)
+
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, changes)
+}
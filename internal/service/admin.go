@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+func (s *Service) FindUsers(ctx context.Context, params models.Params) ([]*models.User, error) {
+	users, err := s.db.FindUsers(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.FindUsers() err: %w", err)
+	}
+
+	return users, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user, err := s.db.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetUserByID(id) err: %w", err)
+	}
+
+	user.Password = ""
+
+	return user, nil
+}
+
+// SetUserRoles replaces the roles of the user. Access tokens already issued keep their roles
+// until they are refreshed.
+func (s *Service) SetUserRoles(ctx context.Context, id uuid.UUID, roles []string) (*models.User, error) {
+	if err := s.db.SetUserRoles(ctx, id, roles); err != nil {
+		return nil, fmt.Errorf("s.db.SetUserRoles(id) err: %w", err)
+	}
+
+	return s.GetUser(ctx, id)
+}
+
+// SetUserTier moves the user to the tier, which selects the FX fees of the user's operations.
+func (s *Service) SetUserTier(ctx context.Context, id uuid.UUID, tier string) (*models.User, error) {
+	if err := s.db.SetUserTier(ctx, id, tier); err != nil {
+		return nil, fmt.Errorf("s.db.SetUserTier(id) err: %w", err)
+	}
+
+	return s.GetUser(ctx, id)
+}
+
+func (s *Service) GetUserWallets(ctx context.Context, userID uuid.UUID, params models.Params) ([]*models.Wallet, error) {
+	if _, err := s.db.GetUserByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("s.db.GetUserByID(userID) err: %w", err)
+	}
+
+	wallets, err := s.db.GetWallets(ctx, userID, params)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetWallets(userID) err: %w", err)
+	}
+
+	if err = s.attachPockets(ctx, wallets...); err != nil {
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
+func (s *Service) GetAnyWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	wallet, err := s.db.GetAnyWalletByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetAnyWalletByID(id) err: %w", err)
+	}
+
+	if err = s.attachPockets(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// GetAnyTransactions returns the history of a wallet of any owner.
+func (s *Service) GetAnyTransactions(ctx context.Context, id uuid.UUID, params models.Params) (
+	[]*models.Transaction, error,
+) {
+	if _, err := s.db.GetAnyWalletByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("s.db.GetAnyWalletByID(id) err: %w", err)
+	}
+
+	return s.getTransactions(ctx, id, params)
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/password"
+	log "github.com/sirupsen/logrus"
+)
+
+const refreshTokenBytes = 32
+
+const passwordMigrationBatchSize = 100
+
+type tokenGenerator interface {
+	NewAccessToken(userID, sessionID uuid.UUID, roles []string) (*models.AccessToken, error)
+}
+
+type passwordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, stored string) (ok, needsRehash bool, err error)
+}
+
+// Login verifies the credentials and starts a session with a new pair of tokens. An unknown login
+// takes as long as a wrong password, so that the response time doesn't tell which users exist.
+func (s *Service) Login(ctx context.Context, credentials models.Credentials) (*models.TokenPair, error) {
+	user, err := s.db.GetUserByLogin(ctx, credentials.Login)
+
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		s.verifyDummyPassword(credentials.Password)
+
+		return nil, models.ErrInvalidCredentials
+	case err != nil:
+		return nil, fmt.Errorf("s.db.GetUserByLogin() err: %w", err)
+	}
+
+	ok, needsRehash, err := s.passwordHasher.Verify(credentials.Password, user.Password)
+
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("s.passwordHasher.Verify() err: %w", err)
+	case !ok:
+		return nil, models.ErrInvalidCredentials
+	case needsRehash:
+		// The password is only known at login, so hashes made with outdated parameters are upgraded here.
+		if err = s.rehashPassword(ctx, *user, credentials.Password); err != nil {
+			log.Warnf("failed to rehash password of user %s: %v", user.ID, err)
+		}
+	}
+
+	return s.issueTokens(ctx, *user, uuid.New())
+}
+
+// verifyDummyPassword verifies the plaintext password against a hash made with the current configuration,
+// spending the time of verifying the password of an existing user.
+func (s *Service) verifyDummyPassword(plaintext string) {
+	s.dummyPasswordHashOnce.Do(func() {
+		hash, err := s.passwordHasher.Hash(uuid.NewString())
+		if err != nil {
+			log.Warnf("failed to hash dummy password: %v", err)
+
+			return
+		}
+
+		s.dummyPasswordHash = hash
+	})
+
+	if s.dummyPasswordHash == "" {
+		return
+	}
+
+	if _, _, err := s.passwordHasher.Verify(plaintext, s.dummyPasswordHash); err != nil {
+		log.Warnf("failed to verify dummy password: %v", err)
+	}
+}
+
+// MigratePlaintextPasswords hashes passwords stored in plaintext before hashing was introduced.
+// Whatever password.IsHash doesn't recognize as a hash is a plaintext password, including values
+// that only start like a hash.
+func (s *Service) MigratePlaintextPasswords(ctx context.Context) error {
+	migrated := 0
+	afterID := uuid.Nil
+
+	for {
+		users, err := s.db.GetUserPasswords(ctx, afterID, passwordMigrationBatchSize)
+		if err != nil {
+			return fmt.Errorf("s.db.GetUserPasswords(afterID) err: %w", err)
+		}
+
+		for _, user := range users {
+			afterID = user.ID
+
+			if password.IsHash(user.Password) {
+				continue
+			}
+
+			if err = s.rehashPassword(ctx, *user, user.Password); err != nil {
+				return err
+			}
+
+			migrated++
+		}
+
+		if len(users) < passwordMigrationBatchSize {
+			break
+		}
+	}
+
+	if migrated > 0 {
+		log.Infof("hashed %d plaintext passwords", migrated)
+	}
+
+	return nil
+}
+
+func (s *Service) rehashPassword(ctx context.Context, user models.User, plaintext string) error {
+	hash, err := s.passwordHasher.Hash(plaintext)
+	if err != nil {
+		return fmt.Errorf("s.passwordHasher.Hash() err: %w", err)
+	}
+
+	if err = s.db.UpdateUserPassword(ctx, user.ID, user.Password, hash); err != nil {
+		return fmt.Errorf("s.db.UpdateUserPassword() err: %w", err)
+	}
+
+	return nil
+}
+
+// RefreshTokens rotates the refresh token: the presented token is spent and a new pair is issued
+// within the same session. A spent token presented again means it has leaked, so the whole
+// session is revoked.
+func (s *Service) RefreshTokens(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	var (
+		tokenPair *models.TokenPair
+		reused    bool
+	)
+
+	err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		token, err := s.db.GetRefreshToken(ctx, hashRefreshToken(refreshToken))
+		if err != nil {
+			return fmt.Errorf("s.db.GetRefreshToken() err: %w", err)
+		}
+
+		now := time.Now()
+
+		switch {
+		case token.RevokedAt != nil || !token.ExpiresAt.After(now):
+			return models.ErrInvalidRefreshToken
+		case token.RotatedAt != nil:
+			reused = true
+
+			if err = s.db.RevokeSession(ctx, token.SessionID); err != nil {
+				return fmt.Errorf("s.db.RevokeSession() err: %w", err)
+			}
+
+			return nil
+		}
+
+		// The user is read again, so that the new access token carries the current roles.
+		user, err := s.db.GetUserByID(ctx, token.UserID)
+		if err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return models.ErrInvalidRefreshToken
+			}
+
+			return fmt.Errorf("s.db.GetUserByID() err: %w", err)
+		}
+
+		if err = s.db.RotateRefreshToken(ctx, token.ID, now); err != nil {
+			return fmt.Errorf("s.db.RotateRefreshToken() err: %w", err)
+		}
+
+		tokenPair, err = s.issueTokens(ctx, *user, token.SessionID)
+
+		return err
+	})
+
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	case reused:
+		return nil, models.ErrInvalidRefreshToken
+	}
+
+	return tokenPair, nil
+}
+
+// Logout revokes the session of the refresh token. Access tokens of the session stay valid until
+// they expire, but introspection reports them as inactive.
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	token, err := s.db.GetRefreshToken(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return fmt.Errorf("s.db.GetRefreshToken() err: %w", err)
+	}
+
+	if err = s.db.RevokeSession(ctx, token.SessionID); err != nil {
+		return fmt.Errorf("s.db.RevokeSession() err: %w", err)
+	}
+
+	return nil
+}
+
+// IntrospectAccessToken completes the introspection of a verified access token with the state of its session.
+func (s *Service) IntrospectAccessToken(ctx context.Context, claims models.Claims) (*models.TokenIntrospection, error) {
+	if claims.SessionID != uuid.Nil {
+		active, err := s.db.IsSessionActive(ctx, claims.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("s.db.IsSessionActive() err: %w", err)
+		}
+
+		if !active {
+			return &models.TokenIntrospection{Active: false}, nil
+		}
+	}
+
+	introspection := &models.TokenIntrospection{
+		Active:    true,
+		TokenType: models.TokenTypeAccess,
+		Subject:   &claims.UUID,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+	}
+
+	if claims.SessionID != uuid.Nil {
+		introspection.SessionID = &claims.SessionID
+	}
+
+	if claims.IssuedAt != nil {
+		introspection.IssuedAt = &claims.IssuedAt.Time
+	}
+
+	if claims.ExpiresAt != nil {
+		introspection.ExpiresAt = &claims.ExpiresAt.Time
+	}
+
+	return introspection, nil
+}
+
+func (s *Service) IntrospectRefreshToken(ctx context.Context, refreshToken string) (*models.TokenIntrospection, error) {
+	token, err := s.db.GetRefreshToken(ctx, hashRefreshToken(refreshToken))
+
+	switch {
+	case errors.Is(err, models.ErrInvalidRefreshToken):
+		return &models.TokenIntrospection{Active: false}, nil
+	case err != nil:
+		return nil, fmt.Errorf("s.db.GetRefreshToken() err: %w", err)
+	}
+
+	if token.RevokedAt != nil || token.RotatedAt != nil || !token.ExpiresAt.After(time.Now()) {
+		return &models.TokenIntrospection{Active: false}, nil
+	}
+
+	return &models.TokenIntrospection{
+		Active:    true,
+		TokenType: models.TokenTypeRefresh,
+		Subject:   &token.UserID,
+		SessionID: &token.SessionID,
+		IssuedAt:  &token.CreatedAt,
+		ExpiresAt: &token.ExpiresAt,
+	}, nil
+}
+
+func (s *Service) issueTokens(ctx context.Context, user models.User, sessionID uuid.UUID) (*models.TokenPair, error) {
+	accessToken, err := s.tokenGenerator.NewAccessToken(user.ID, sessionID, user.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("s.tokenGenerator.NewAccessToken() err: %w", err)
+	}
+
+	secret := make([]byte, refreshTokenBytes)
+	if _, err = rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("rand.Read() err: %w", err)
+	}
+
+	refreshToken := base64.RawURLEncoding.EncodeToString(secret)
+	now := time.Now()
+
+	token := models.RefreshToken{
+		ID:        uuid.New(),
+		SessionID: sessionID,
+		UserID:    user.ID,
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: now.Add(s.cfg.RefreshTokenTTL),
+		CreatedAt: now,
+	}
+
+	if err = s.db.SaveRefreshToken(ctx, token); err != nil {
+		return nil, fmt.Errorf("s.db.SaveRefreshToken() err: %w", err)
+	}
+
+	return &models.TokenPair{
+		AccessToken:           accessToken.Token,
+		TokenType:             models.TokenTypeBearer,
+		ExpiresAt:             accessToken.ExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: token.ExpiresAt,
+	}, nil
+}
+
+func hashRefreshToken(refreshToken string) string {
+	hash := sha256.Sum256([]byte(refreshToken))
+
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func (s *Service) GetCurrencies(ctx context.Context) ([]*models.Currency, error) {
+	currencies, err := s.db.GetCurrencies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetCurrencies() err: %w", err)
+	}
+
+	return currencies, nil
+}
+
+func (s *Service) CreateCurrency(ctx context.Context, currency models.Currency) (*models.Currency, error) {
+	createdCurrency, err := s.db.CreateCurrency(ctx, currency)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.CreateCurrency(currency) err: %w", err)
+	}
+
+	if err = s.RefreshCurrencies(ctx); err != nil {
+		return nil, err
+	}
+
+	return createdCurrency, nil
+}
+
+func (s *Service) UpdateCurrency(ctx context.Context, code string, currencyDTO models.CurrencyDTO) (*models.Currency, error) {
+	var updatedCurrency *models.Currency
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		currency, err := s.db.GetCurrency(ctx, code)
+		if err != nil {
+			return fmt.Errorf("s.db.GetCurrency(code) err: %w", err)
+		}
+
+		changedCurrency := currencyDTO.Apply(*currency)
+		if err = changedCurrency.Validate(); err != nil {
+			return fmt.Errorf("changedCurrency.Validate() err: %w", err)
+		}
+
+		updatedCurrency, err = s.db.UpdateCurrency(ctx, changedCurrency)
+		if err != nil {
+			return fmt.Errorf("s.db.UpdateCurrency(currency) err: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	if err := s.RefreshCurrencies(ctx); err != nil {
+		return nil, err
+	}
+
+	return updatedCurrency, nil
+}
+
+// RefreshCurrencies loads the registered currencies into the registry validation reads.
+func (s *Service) RefreshCurrencies(ctx context.Context) error {
+	currencies, err := s.db.GetCurrencies(ctx)
+	if err != nil {
+		return fmt.Errorf("s.db.GetCurrencies() err: %w", err)
+	}
+
+	models.SetCurrencies(currencies)
+
+	return nil
+}
+
+// StartCurrenciesRefresher picks up the currency changes made through other instances.
+func (s *Service) StartCurrenciesRefresher(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.CurrenciesRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+
+		if err := s.RefreshCurrencies(ctx); err != nil {
+			log.Errorf("currencies refresher failed: %v", err)
+		}
+	}
+}
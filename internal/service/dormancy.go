@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// RunDormancyPolicy warns the owners of empty wallets that are about to become dormant, and makes
+// dormant the wallets that stayed inactive through the warning period. Wallets with money in them
+// and savings wallets are left alone. The wallets affected by the run are reported and stored.
+func (s *Service) RunDormancyPolicy(ctx context.Context) (*models.DormancyRun, error) {
+	run := models.DormancyRun{ID: uuid.New(), StartedAt: time.Now()}
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		if err := s.warnDormantWallets(ctx, &run); err != nil {
+			return err
+		}
+
+		if err := s.makeWalletsDormant(ctx, &run); err != nil {
+			return err
+		}
+
+		if len(run.Wallets) == 0 {
+			return nil
+		}
+
+		if err := s.db.SaveDormancyRun(ctx, run); err != nil {
+			return fmt.Errorf("s.db.SaveDormancyRun() err: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	if len(run.Wallets) > 0 {
+		log.Infof(
+			"dormancy policy run %s: %d wallets warned, %d wallets made dormant",
+			run.ID, run.Count(models.DormancyActionWarned), run.Count(models.DormancyActionDormant),
+		)
+	}
+
+	return &run, nil
+}
+
+// warnDormantWallets notifies the owners of wallets that become dormant within the warning period.
+// Must be called within a DB transaction.
+func (s *Service) warnDormantWallets(ctx context.Context, run *models.DormancyRun) error {
+	inactiveBefore := run.StartedAt.Add(s.cfg.DormancyWarningPeriod - s.cfg.DormancyPeriod)
+
+	wallets, err := s.db.GetWalletsToWarn(ctx, inactiveBefore, s.cfg.DormancyBatchSize)
+	if err != nil {
+		return fmt.Errorf("s.db.GetWalletsToWarn() err: %w", err)
+	}
+
+	for _, wallet := range wallets {
+		if err = s.saveDormancyNotice(ctx, models.DormancyNotice{
+			Type:           models.NoticeDormancyWarning,
+			WalletID:       wallet.ID,
+			OwnerID:        wallet.Owner,
+			LastActivityAt: wallet.UpdatedAt,
+			DormantAt:      models.DormantAt(wallet.UpdatedAt, run.StartedAt, s.cfg.DormancyPeriod, s.cfg.DormancyWarningPeriod),
+		}); err != nil {
+			return err
+		}
+
+		if err = s.db.MarkDormancyWarned(ctx, wallet.ID, run.StartedAt); err != nil {
+			return fmt.Errorf("s.db.MarkDormancyWarned(id) err: %w", err)
+		}
+
+		run.Wallets = append(run.Wallets, models.DormancyRunWallet{
+			WalletID:       wallet.ID,
+			OwnerID:        wallet.Owner,
+			Action:         models.DormancyActionWarned,
+			LastActivityAt: wallet.UpdatedAt,
+		})
+	}
+
+	return nil
+}
+
+// makeWalletsDormant moves the wallets whose owners were warned and did nothing to the dormant
+// status. Must be called within a DB transaction.
+func (s *Service) makeWalletsDormant(ctx context.Context, run *models.DormancyRun) error {
+	wallets, err := s.db.GetWalletsToMakeDormant(
+		ctx,
+		run.StartedAt.Add(-s.cfg.DormancyPeriod),
+		run.StartedAt.Add(-s.cfg.DormancyWarningPeriod),
+		s.cfg.DormancyBatchSize,
+	)
+	if err != nil {
+		return fmt.Errorf("s.db.GetWalletsToMakeDormant() err: %w", err)
+	}
+
+	for _, wallet := range wallets {
+		reason := "inactive since " + wallet.UpdatedAt.Format(time.DateOnly)
+
+		if _, err = s.changeWalletStatus(ctx, *wallet, models.WalletStatusDormant, uuid.Nil, reason); err != nil {
+			return err
+		}
+
+		if err = s.saveDormancyNotice(ctx, models.DormancyNotice{
+			Type:           models.NoticeWalletDormant,
+			WalletID:       wallet.ID,
+			OwnerID:        wallet.Owner,
+			LastActivityAt: wallet.UpdatedAt,
+			DormantAt:      run.StartedAt,
+		}); err != nil {
+			return err
+		}
+
+		run.Wallets = append(run.Wallets, models.DormancyRunWallet{
+			WalletID:       wallet.ID,
+			OwnerID:        wallet.Owner,
+			Action:         models.DormancyActionDormant,
+			LastActivityAt: wallet.UpdatedAt,
+		})
+	}
+
+	return nil
+}
+
+// saveDormancyNotice stores the notice in the outbox within the current DB transaction.
+func (s *Service) saveDormancyNotice(ctx context.Context, notice models.DormancyNotice) error {
+	payload, err := json.Marshal(notice)
+	if err != nil {
+		return fmt.Errorf("json.Marshal(notice) err: %w", err)
+	}
+
+	if err = s.db.SaveOutboxMessage(ctx, models.OutboxMessage{
+		Topic:   models.WalletNotificationsTopic,
+		Key:     notice.WalletID.String(),
+		Payload: payload,
+	}); err != nil {
+		return fmt.Errorf("s.db.SaveOutboxMessage() err: %w", err)
+	}
+
+	return nil
+}
+
+// GetDormancyRuns returns the reports of the dormancy policy runs that affected any wallets.
+func (s *Service) GetDormancyRuns(ctx context.Context, params models.Params) ([]*models.DormancyRun, error) {
+	runs, err := s.db.GetDormancyRuns(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetDormancyRuns() err: %w", err)
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// LoadFeeSchedule reads the FX fee schedule from a JSON file:
+//
+//	{
+//	  "revenueWallets": {"RUR": "6d3c...", "USD": "0b6f..."},
+//	  "rules": [
+//	    {"markup": "0.01"},
+//	    {"tier": "premium", "markup": "0.005"},
+//	    {"currencyFrom": "USD", "currencyTo": "RUR", "markup": "0.02", "flatFees": {"RUR": "50"}}
+//	  ]
+//	}
+//
+// Without a file no fees are charged.
+func LoadFeeSchedule(path string) (models.FeeSchedule, error) {
+	var schedule models.FeeSchedule
+
+	if path == "" {
+		return schedule, nil
+	}
+
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return schedule, fmt.Errorf("os.ReadFile() err: %w", err)
+	}
+
+	if err = json.Unmarshal(payload, &schedule); err != nil {
+		return schedule, fmt.Errorf("json.Unmarshal() err: %w", err)
+	}
+
+	if err = schedule.Validate(); err != nil {
+		return schedule, fmt.Errorf("schedule.Validate() err: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// chargeFee moves the FX fee of the operation from the wallet balance in feeCurrency to the fee
+// revenue ledger account, recording it as a separate transaction linked to the operation. The fees
+// reach the revenue wallets when they are settled, so that operations don't contend for them.
+// amount is the operation amount in feeCurrency. Operations within one currency bear no fee, and
+// operations that used a quote are charged the fee it locked.
+func (s *Service) chargeFee(
+	ctx context.Context,
+	operation models.Transaction,
+	wallet models.Wallet,
+	currencyFrom, currencyTo, feeCurrency string,
+	amount models.Decimal,
+) error {
+	fee, err := s.operationFee(ctx, operation, wallet.Owner, currencyFrom, currencyTo, feeCurrency, amount)
+	if err != nil {
+		return err
+	}
+
+	if fee.Sign() <= 0 {
+		return nil
+	}
+
+	if _, ok := s.cfg.Fees.RevenueWallets[feeCurrency]; !ok {
+		return fmt.Errorf("%w: %s", models.ErrRevenueWalletNotSet, feeCurrency)
+	}
+
+	feeTransaction, err := s.db.Withdraw(ctx, models.Transaction{
+		TransactionID:   uuid.New(),
+		WalletID:        wallet.ID,
+		Amount:          fee,
+		Currency:        feeCurrency,
+		ConvertedAmount: fee,
+		ExRate:          models.NewDecimalFromInt(1),
+		OperationType:   models.OperationFee,
+		OriginalID:      operation.TransactionID,
+		Pocket:          wallet.PocketOf(feeCurrency),
+		BalanceCurrency: feeCurrency,
+	}, wallet.Owner)
+	if err != nil {
+		return fmt.Errorf("s.db.Withdraw(fee) err: %w", err)
+	}
+
+	if err = s.savePostings(ctx, movementPostings(
+		feeTransaction.TransactionID,
+		wallet.ID, fee, feeCurrency,
+		models.FeeRevenueAccountID, fee, feeCurrency,
+	)); err != nil {
+		return err
+	}
+
+	return s.saveTransactionEvent(ctx, *feeTransaction)
+}
+
+// operationFee returns the FX fee of the operation in feeCurrency: the one locked by the quote the
+// operation used, or the current one otherwise.
+func (s *Service) operationFee(
+	ctx context.Context,
+	operation models.Transaction,
+	ownerID uuid.UUID,
+	currencyFrom, currencyTo, feeCurrency string,
+	amount models.Decimal,
+) (models.Decimal, error) {
+	if operation.QuoteID == nil {
+		return s.getFee(ctx, ownerID, currencyFrom, currencyTo, feeCurrency, amount)
+	}
+
+	quote, err := s.db.GetQuoteByID(ctx, *operation.QuoteID, ownerID)
+	if err != nil {
+		return models.Decimal{}, fmt.Errorf("s.db.GetQuoteByID(quoteID) err: %w", err)
+	}
+
+	if quote.Fee.Sign() > 0 && quote.FeeCurrency != feeCurrency {
+		return models.Decimal{}, fmt.Errorf("%w: fee quoted in %s", models.ErrQuoteMismatch, quote.FeeCurrency)
+	}
+
+	return quote.Fee, nil
+}
+
+// getFee returns the FX fee the owner pays for converting the amount, in feeCurrency.
+func (s *Service) getFee(
+	ctx context.Context,
+	ownerID uuid.UUID,
+	currencyFrom, currencyTo, feeCurrency string,
+	amount models.Decimal,
+) (models.Decimal, error) {
+	if currencyFrom == currencyTo {
+		return models.Decimal{}, nil
+	}
+
+	owner, err := s.db.GetUserByID(ctx, ownerID)
+	if err != nil {
+		return models.Decimal{}, fmt.Errorf("s.db.GetUserByID(owner) err: %w", err)
+	}
+
+	return s.cfg.Fees.Fee(currencyFrom, currencyTo, owner.Tier, feeCurrency, amount), nil
+}
+
+// SettleFees credits the fees collected on the fee revenue account to the revenue wallet of each
+// currency. Fees refunded after their settlement are deducted from the next one.
+func (s *Service) SettleFees(ctx context.Context) error {
+	for currency, revenueWalletID := range s.cfg.Fees.RevenueWallets {
+		if err := s.settleFees(ctx, currency, revenueWalletID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) settleFees(ctx context.Context, currency string, revenueWalletID uuid.UUID) error {
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		// The lock of the revenue wallet keeps concurrent settlements from crediting the same fees twice.
+		revenueWallet, err := s.db.GetTransferTargetWallet(ctx, revenueWalletID)
+		if err != nil {
+			return fmt.Errorf("s.db.GetTransferTargetWallet(revenueWalletID) err: %w", err)
+		}
+
+		if revenueWallet.Currency != currency {
+			return fmt.Errorf("%w: revenue wallet %s is in %s", models.ErrRevenueWalletNotSet, revenueWallet.ID, revenueWallet.Currency)
+		}
+
+		collected, err := s.db.GetAccountBalance(ctx, models.FeeRevenueAccountID, currency)
+		if err != nil {
+			return fmt.Errorf("s.db.GetAccountBalance(feeRevenue) err: %w", err)
+		}
+
+		if collected.Sign() <= 0 {
+			return nil
+		}
+
+		settlement, err := s.db.Deposit(ctx, models.Transaction{
+			TransactionID:   uuid.New(),
+			WalletID:        revenueWallet.ID,
+			Amount:          collected,
+			Currency:        currency,
+			ConvertedAmount: collected,
+			ExRate:          models.NewDecimalFromInt(1),
+			OperationType:   models.OperationFeeSettlement,
+			BalanceCurrency: currency,
+		}, revenueWallet.Owner)
+		if err != nil {
+			return fmt.Errorf("s.db.Deposit(settlement) err: %w", err)
+		}
+
+		if err = s.savePostings(ctx, movementPostings(
+			settlement.TransactionID,
+			models.FeeRevenueAccountID, collected, currency,
+			revenueWallet.ID, collected, currency,
+		)); err != nil {
+			return err
+		}
+
+		return s.saveTransactionEvent(ctx, *settlement)
+	}); err != nil {
+		return fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return nil
+}
+
+// StartFeeSettler periodically settles the collected fees to the revenue wallets.
+func (s *Service) StartFeeSettler(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.FeeSettlementInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.SettleFees(ctx); err != nil {
+			log.Errorf("fee settlement failed: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/converter"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateHold reserves funds on the wallet. The hold expires after the configured TTL unless
+// the client sets an earlier expiration, which must be in the future.
+func (s *Service) CreateHold(ctx context.Context, hold models.Hold, ownerID uuid.UUID) (*models.Hold, error) {
+	var createdHold *models.Hold
+
+	if hold.ID == uuid.Nil {
+		hold.ID = uuid.New()
+	}
+
+	hold.OwnerID = ownerID
+	now := time.Now()
+
+	if !hold.ExpiresAt.IsZero() && !hold.ExpiresAt.After(now) {
+		return nil, models.ErrHoldExpiresInPast
+	}
+
+	if maxExpiresAt := now.Add(s.cfg.HoldTTL); hold.ExpiresAt.IsZero() || hold.ExpiresAt.After(maxExpiresAt) {
+		hold.ExpiresAt = maxExpiresAt
+	}
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		wallet, err := s.db.GetWalletByID(ctx, hold.WalletID, ownerID)
+		if err != nil {
+			return fmt.Errorf("s.db.GetWalletByID(walletID) err: %w", err)
+		}
+
+		if err = wallet.CheckActive(); err != nil {
+			return err
+		}
+
+		hold.ConvertedAmount = hold.Amount
+		hold.ExRate = models.NewDecimalFromInt(1)
+
+		if wallet.Currency != hold.Currency {
+			conversion, err := s.xrConverter.Convert(
+				ctx,
+				converter.Currency{Amount: hold.Amount, Name: hold.Currency},
+				converter.Currency{Amount: wallet.Balance, Name: wallet.Currency},
+			)
+			if err != nil {
+				return fmt.Errorf("s.xrConverter.Convert(...) err: %w", err)
+			}
+
+			hold.ConvertedAmount = conversion.Amount
+			hold.ExRate = conversion.Rate
+			hold.RateSource = conversion.RateSource
+			hold.RateDate = &conversion.RateDate
+		}
+
+		createdHold, err = s.db.CreateHold(ctx, hold)
+		if err != nil {
+			return fmt.Errorf("s.db.CreateHold() err: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return createdHold, nil
+}
+
+func (s *Service) GetHold(ctx context.Context, id, ownerID uuid.UUID) (*models.Hold, error) {
+	hold, err := s.db.GetHoldByID(ctx, id, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetHoldByID(id) err: %w", err)
+	}
+
+	return hold, nil
+}
+
+// CaptureHold turns the hold into a withdraw of the given amount, or of the whole hold if the
+// amount is nil, and releases the rest of the reservation.
+func (s *Service) CaptureHold(ctx context.Context, id, ownerID uuid.UUID, amount *models.Decimal) (*models.Transaction, error) {
+	var executedTransaction *models.Transaction
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		hold, err := s.getActiveHold(ctx, id, ownerID)
+		if err != nil {
+			return err
+		}
+
+		wallet, err := s.db.GetWalletByID(ctx, hold.WalletID, ownerID)
+		if err != nil {
+			return fmt.Errorf("s.db.GetWalletByID(walletID) err: %w", err)
+		}
+
+		if err = wallet.CheckActive(); err != nil {
+			return err
+		}
+
+		captured, convertedCaptured := hold.Amount, hold.ConvertedAmount
+
+		if amount != nil {
+			switch {
+			case amount.Sign() <= 0:
+				return models.ErrAmountIsZero
+			case !amount.Equal(amount.RoundForCurrency(hold.Currency)):
+				return models.ErrAmountPrecision
+			case amount.Cmp(hold.Amount) > 0:
+				return models.ErrCaptureExceedsHold
+			case amount.Cmp(hold.Amount) < 0:
+				captured = *amount
+				convertedCaptured = amount.Mul(hold.ExRate).RoundForCurrency(wallet.Currency)
+			}
+		}
+
+		executedTransaction, err = s.db.CaptureHold(ctx, *hold, models.Transaction{
+			TransactionID:   uuid.New(),
+			WalletID:        hold.WalletID,
+			Amount:          captured,
+			Currency:        hold.Currency,
+			ConvertedAmount: convertedCaptured,
+			ExRate:          hold.ExRate,
+			RateSource:      hold.RateSource,
+			RateDate:        hold.RateDate,
+			OperationType:   models.OperationWithdraw,
+			BalanceCurrency: wallet.Currency,
+		})
+		if err != nil {
+			return fmt.Errorf("s.db.CaptureHold() err: %w", err)
+		}
+
+		if err = s.savePostings(ctx, movementPostings(
+			executedTransaction.TransactionID,
+			wallet.ID, executedTransaction.ConvertedAmount, wallet.Currency,
+			models.CashOutAccountID, executedTransaction.Amount, executedTransaction.Currency,
+		)); err != nil {
+			return err
+		}
+
+		if err = s.saveTransactionEvent(ctx, *executedTransaction); err != nil {
+			return err
+		}
+
+		// A capture is charged the FX fee the way a withdraw is.
+		return s.chargeFee(
+			ctx, *executedTransaction, *wallet,
+			hold.Currency, wallet.Currency, wallet.Currency, executedTransaction.ConvertedAmount,
+		)
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return executedTransaction, nil
+}
+
+func (s *Service) VoidHold(ctx context.Context, id, ownerID uuid.UUID) (*models.Hold, error) {
+	var voidedHold *models.Hold
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		hold, err := s.getActiveHold(ctx, id, ownerID)
+		if err != nil {
+			return err
+		}
+
+		voidedHold, err = s.db.VoidHold(ctx, *hold)
+		if err != nil {
+			return fmt.Errorf("s.db.VoidHold() err: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return voidedHold, nil
+}
+
+// getActiveHold locks the hold and checks that it can still be captured or voided. A hold past its
+// expiration is treated as expired even if the expirer hasn't released it yet.
+func (s *Service) getActiveHold(ctx context.Context, id, ownerID uuid.UUID) (*models.Hold, error) {
+	hold, err := s.db.GetHoldByID(ctx, id, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetHoldByID(id) err: %w", err)
+	}
+
+	if hold.Status != models.HoldStatusActive || !hold.ExpiresAt.After(time.Now()) {
+		return nil, models.ErrHoldNotActive
+	}
+
+	return hold, nil
+}
+
+// StartHoldsExpirer periodically releases expired holds.
+func (s *Service) StartHoldsExpirer(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.HoldsExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := s.db.ExpireHolds(ctx, time.Now())
+
+		switch {
+		case err != nil:
+			log.Errorf("holds expirer failed: %v", err)
+		case expired > 0:
+			log.Infof("%d holds expired", expired)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
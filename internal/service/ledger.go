@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// movementPostings moves fromAmount out of the from account and toAmount into the to account.
+// Cross-currency movements are routed through the FX clearing account, keeping every
+// currency balanced.
+func movementPostings(
+	transactionID uuid.UUID,
+	from uuid.UUID, fromAmount models.Decimal, fromCurrency string,
+	to uuid.UUID, toAmount models.Decimal, toCurrency string,
+) []models.Posting {
+	if fromCurrency == toCurrency {
+		return []models.Posting{
+			{TransactionID: transactionID, AccountID: from, Amount: fromAmount.Neg(), Currency: fromCurrency},
+			{TransactionID: transactionID, AccountID: to, Amount: fromAmount, Currency: toCurrency},
+		}
+	}
+
+	return []models.Posting{
+		{TransactionID: transactionID, AccountID: from, Amount: fromAmount.Neg(), Currency: fromCurrency},
+		{TransactionID: transactionID, AccountID: models.FXClearingAccountID, Amount: fromAmount, Currency: fromCurrency},
+		{TransactionID: transactionID, AccountID: models.FXClearingAccountID, Amount: toAmount.Neg(), Currency: toCurrency},
+		{TransactionID: transactionID, AccountID: to, Amount: toAmount, Currency: toCurrency},
+	}
+}
+
+func (s *Service) savePostings(ctx context.Context, postings []models.Posting) error {
+	if err := s.db.SavePostings(ctx, postings); err != nil {
+		return fmt.Errorf("s.db.SavePostings() err: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) CheckLedger(ctx context.Context) (*models.LedgerReport, error) {
+	report, err := s.db.GetLedgerReport(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetLedgerReport() err: %w", err)
+	}
+
+	s.metrics.SetLedgerReport(*report)
+
+	return report, nil
+}
+
+// StartLedgerAuditor periodically verifies that the ledger sums to zero per currency and
+// that wallet balances match their postings.
+func (s *Service) StartLedgerAuditor(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.LedgerCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		report, err := s.CheckLedger(ctx)
+
+		switch {
+		case err != nil:
+			log.Errorf("ledger check failed: %v", err)
+		case !report.Balanced():
+			log.Errorf("ledger is not balanced: %+v", *report)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
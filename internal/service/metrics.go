@@ -1,12 +1,15 @@
 package service
 
 import (
+	"github.com/iurikman/cashFlowManager/internal/models"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 type metrics struct {
-	xrRequests *prometheus.CounterVec
+	xrRequests          *prometheus.CounterVec
+	currencyImbalances  prometheus.Gauge
+	walletDiscrepancies prometheus.Gauge
 }
 
 func newMetrics() *metrics {
@@ -24,9 +27,26 @@ func newMetrics() *metrics {
 		},
 			[]string{"currency_from", "currency_to"},
 		),
+		promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "ledger_currency_imbalances",
+			Help:      "currencies whose ledger postings don't sum to zero",
+		}),
+		promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "ledger_wallet_discrepancies",
+			Help:      "wallets whose balance differs from their ledger postings",
+		}),
 	}
 }
 
 func (m *metrics) IncrXRRequests(currencyFrom string, currencyTo string) {
 	m.xrRequests.WithLabelValues(currencyFrom, currencyTo).Inc()
 }
+
+func (m *metrics) SetLedgerReport(report models.LedgerReport) {
+	m.currencyImbalances.Set(float64(len(report.CurrencyImbalances)))
+	m.walletDiscrepancies.Set(float64(len(report.WalletDiscrepancies)))
+}
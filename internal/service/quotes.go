@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/converter"
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+// CreateQuote locks the current rate of the conversion for the configured quote TTL. The quote
+// shows the FX fee the owner pays for the conversion on top of it.
+func (s *Service) CreateQuote(ctx context.Context, request models.QuoteRequest, ownerID uuid.UUID) (*models.Quote, error) {
+	conversion, err := s.xrConverter.Convert(
+		ctx,
+		converter.Currency{Amount: request.Amount, Name: request.CurrencyFrom},
+		converter.Currency{Name: request.CurrencyTo},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("s.xrConverter.Convert(...) err: %w", err)
+	}
+
+	now := time.Now()
+
+	quote := models.Quote{
+		ID:              uuid.New(),
+		OwnerID:         ownerID,
+		CurrencyFrom:    request.CurrencyFrom,
+		CurrencyTo:      request.CurrencyTo,
+		Amount:          request.Amount,
+		ConvertedAmount: conversion.Amount,
+		Rate:            conversion.Rate,
+		RateSource:      conversion.RateSource,
+		RateDate:        conversion.RateDate,
+		OperationType:   request.OperationType,
+		ExpiresAt:       now.Add(s.cfg.QuoteTTL),
+		CreatedAt:       now,
+	}
+
+	feeCurrency, feeAmount := quote.FeeBase()
+
+	quote.FeeCurrency = feeCurrency
+	quote.Fee, err = s.getFee(ctx, ownerID, quote.CurrencyFrom, quote.CurrencyTo, feeCurrency, feeAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	savedQuote, err := s.db.SaveQuote(ctx, quote)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.SaveQuote() err: %w", err)
+	}
+
+	return savedQuote, nil
+}
+
+// convertTransaction converts the amount of the transaction to currencyTo. A quote referenced by the
+// transaction supplies its locked rate and is spent by the transaction, otherwise the current rate is
+// used. Must be called within a DB transaction.
+func (s *Service) convertTransaction(
+	ctx context.Context,
+	transaction models.Transaction,
+	ownerID uuid.UUID,
+	currencyFrom, currencyTo string,
+) (*models.Conversion, error) {
+	if transaction.QuoteID != nil {
+		quote, err := s.db.GetQuoteByID(ctx, *transaction.QuoteID, ownerID)
+		if err != nil {
+			return nil, fmt.Errorf("s.db.GetQuoteByID(quoteID) err: %w", err)
+		}
+
+		if err = quote.CheckUsable(transaction.OperationType, currencyFrom, currencyTo, transaction.Amount, time.Now()); err != nil {
+			return nil, err
+		}
+
+		if err = s.db.UseQuote(ctx, quote.ID, transaction.TransactionID); err != nil {
+			return nil, fmt.Errorf("s.db.UseQuote(quoteID) err: %w", err)
+		}
+
+		return &models.Conversion{
+			Amount:     quote.ConvertedAmount,
+			Rate:       quote.Rate,
+			RateSource: quote.RateSource,
+			RateDate:   quote.RateDate,
+		}, nil
+	}
+
+	if currencyFrom == currencyTo {
+		return &models.Conversion{Amount: transaction.Amount, Rate: models.NewDecimalFromInt(1)}, nil
+	}
+
+	conversion, err := s.xrConverter.Convert(
+		ctx,
+		converter.Currency{Amount: transaction.Amount, Name: currencyFrom},
+		converter.Currency{Name: currencyTo},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("s.xrConverter.Convert(...) err: %w", err)
+	}
+
+	return conversion, nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/iurikman/cashFlowManager/internal/converter"
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+// GetExchangeRate returns the rate of the pair in effect on the date of the request.
+func (s *Service) GetExchangeRate(ctx context.Context, request models.RateRequest) (*models.PairRate, error) {
+	conversion, err := s.xrConverter.ConvertAt(
+		ctx,
+		converter.Currency{Amount: models.NewDecimalFromInt(1), Name: request.CurrencyFrom},
+		converter.Currency{Name: request.CurrencyTo},
+		request.Date,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("s.xrConverter.ConvertAt(...) err: %w", err)
+	}
+
+	s.metrics.IncrXRRequests(request.CurrencyFrom, request.CurrencyTo)
+
+	return &models.PairRate{
+		CurrencyFrom: request.CurrencyFrom,
+		CurrencyTo:   request.CurrencyTo,
+		Date:         request.Date,
+		Rate:         conversion.Rate,
+		RateSource:   conversion.RateSource,
+		RateDate:     conversion.RateDate,
+	}, nil
+}
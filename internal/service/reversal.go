@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+// Reverse creates a compensating transaction for a deposit, withdraw or transfer of any owner on
+// behalf of staff, and refunds the FX fee charged for it. Amount limits the refund, otherwise
+// everything that hasn't been reversed yet is refunded. Balances are restored at the exchange rate
+// of the original transaction, so they can't be once the currency of a wallet has changed.
+func (s *Service) Reverse(ctx context.Context, id uuid.UUID, amount *models.Decimal) (*models.Transaction, error) {
+	var executedTransaction *models.Transaction
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		original, err := s.db.GetAnyTransactionByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("s.db.GetAnyTransactionByID(id) err: %w", err)
+		}
+
+		switch original.OperationType {
+		case models.OperationDeposit, models.OperationWithdraw, models.OperationTransfer:
+		default:
+			return models.ErrNotReversible
+		}
+
+		if err = s.db.LockWallets(ctx, original.WalletID, original.TargetWalletID); err != nil {
+			return fmt.Errorf("s.db.LockWallets(walletID, targetWalletID) err: %w", err)
+		}
+
+		executedTransaction, err = s.reverse(ctx, *original, amount)
+		if err != nil {
+			return err
+		}
+
+		return s.reverseFee(ctx, *original, *executedTransaction)
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return executedTransaction, nil
+}
+
+// reverse refunds the amount of the transaction, see Reverse. Must be called within a DB transaction
+// holding the locks of the wallets.
+func (s *Service) reverse(ctx context.Context, original models.Transaction, amount *models.Decimal) (*models.Transaction, error) {
+	wallet, err := s.db.GetWalletByID(ctx, original.WalletID, original.OwnerID)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetWalletByID(walletID) err: %w", err)
+	}
+
+	if err = wallet.CheckActive(); err != nil {
+		return nil, err
+	}
+
+	if original.Pocket != "" && !wallet.MultiCurrency {
+		return nil, models.ErrNotMultiCurrency
+	}
+
+	// The amount is in the transaction currency and the converted amount is in the
+	// currency of the wallet balance that was credited or debited, except for transfers.
+	balanceCurrency := wallet.BalanceCurrency(original.Pocket)
+	amountCurrency, convertedCurrency := original.Currency, balanceCurrency
+
+	// Transactions recorded before their balance currencies were stored are not checked.
+	if original.BalanceCurrency != "" && original.BalanceCurrency != balanceCurrency {
+		return nil, fmt.Errorf("%w: %s is in %s now", models.ErrWalletCurrencyChanged, wallet.ID, balanceCurrency)
+	}
+
+	var walletTo *models.Wallet
+
+	if original.OperationType == models.OperationTransfer {
+		walletTo, err = s.db.GetTransferTargetWallet(ctx, original.TargetWalletID)
+		if err != nil {
+			return nil, fmt.Errorf("s.db.GetTransferTargetWallet(targetWalletID) err: %w", err)
+		}
+
+		if err = walletTo.CheckActive(); err != nil {
+			return nil, err
+		}
+
+		if original.TargetCurrency != "" && original.TargetCurrency != walletTo.Currency {
+			return nil, fmt.Errorf("%w: %s is in %s now", models.ErrWalletCurrencyChanged, walletTo.ID, walletTo.Currency)
+		}
+
+		amountCurrency, convertedCurrency = balanceCurrency, walletTo.Currency
+	}
+
+	reversal, err := s.newReversal(ctx, original, amount, amountCurrency, convertedCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	executedTransaction, err := s.db.Reverse(ctx, reversal, original)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.Reverse() err: %w", err)
+	}
+
+	postings := reversalPostings(*executedTransaction, original.OperationType, wallet.ID, balanceCurrency, walletTo)
+
+	if err = s.savePostings(ctx, postings); err != nil {
+		return nil, err
+	}
+
+	if original.OperationType == models.OperationTransfer {
+		err = s.saveTransferEvents(ctx, *executedTransaction)
+	} else {
+		err = s.saveTransactionEvent(ctx, *executedTransaction)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return executedTransaction, nil
+}
+
+// reverseFee refunds the FX fee charged for the original transaction in proportion to the reversal,
+// and whatever is left of the fee once the transaction is fully reversed. Must be called within a DB
+// transaction holding the lock of the wallet.
+func (s *Service) reverseFee(ctx context.Context, original, reversal models.Transaction) error {
+	fee, err := s.db.GetFeeTransaction(ctx, original.TransactionID)
+
+	switch {
+	case errors.Is(err, models.ErrTransactionsNotFound):
+		return nil
+	case err != nil:
+		return fmt.Errorf("s.db.GetFeeTransaction(id) err: %w", err)
+	}
+
+	reversedAmount, _, err := s.db.GetReversedAmounts(ctx, original.TransactionID)
+	if err != nil {
+		return fmt.Errorf("s.db.GetReversedAmounts(id) err: %w", err)
+	}
+
+	reversedFee, _, err := s.db.GetReversedAmounts(ctx, fee.TransactionID)
+	if err != nil {
+		return fmt.Errorf("s.db.GetReversedAmounts(feeID) err: %w", err)
+	}
+
+	remainingFee := fee.Amount.Sub(reversedFee)
+	if remainingFee.Sign() <= 0 {
+		return nil
+	}
+
+	// A nil refund takes everything that is left of the fee.
+	var refund *models.Decimal
+
+	if reversedAmount.Cmp(original.Amount) < 0 {
+		share, err := fee.Amount.Mul(reversal.Amount).Div(original.Amount)
+		if err != nil {
+			return fmt.Errorf("calculating fee share err: %w", err)
+		}
+
+		share = share.RoundForCurrency(fee.Currency)
+
+		switch {
+		case share.Sign() <= 0:
+			return nil
+		case share.Cmp(remainingFee) < 0:
+			refund = &share
+		}
+	}
+
+	_, err = s.reverse(ctx, *fee, refund)
+
+	return err
+}
+
+// newReversal builds the compensating transaction, refusing refunds beyond what is left to reverse.
+func (s *Service) newReversal(
+	ctx context.Context,
+	original models.Transaction,
+	amount *models.Decimal,
+	amountCurrency, convertedCurrency string,
+) (models.Transaction, error) {
+	reversedAmount, reversedConvertedAmount, err := s.db.GetReversedAmounts(ctx, original.TransactionID)
+	if err != nil {
+		return models.Transaction{}, fmt.Errorf("s.db.GetReversedAmounts(id) err: %w", err)
+	}
+
+	remaining := original.Amount.Sub(reversedAmount)
+	if remaining.Sign() <= 0 {
+		return models.Transaction{}, models.ErrAlreadyReversed
+	}
+
+	refund := remaining
+
+	if amount != nil {
+		switch {
+		case amount.Sign() <= 0:
+			return models.Transaction{}, models.ErrAmountIsZero
+		case !amount.Equal(amount.RoundForCurrency(amountCurrency)):
+			return models.Transaction{}, models.ErrAmountPrecision
+		case amount.Cmp(remaining) > 0:
+			return models.Transaction{}, models.ErrRefundExceedsAmount
+		}
+
+		refund = *amount
+	}
+
+	rate := original.ExRate
+	if rate.IsZero() {
+		rate = exchangeRate(original.Amount, original.ConvertedAmount)
+	}
+
+	// The last refund takes whatever is left, so that rounding never leaves a remainder behind.
+	convertedRefund := refund.Mul(rate).RoundForCurrency(convertedCurrency)
+	if refund.Equal(remaining) {
+		convertedRefund = original.ConvertedAmount.Sub(reversedConvertedAmount)
+	}
+
+	return models.Transaction{
+		TransactionID:   uuid.New(),
+		WalletID:        original.WalletID,
+		TargetWalletID:  original.TargetWalletID,
+		TargetOwnerID:   original.TargetOwnerID,
+		Amount:          refund,
+		Currency:        original.Currency,
+		ConvertedAmount: convertedRefund,
+		ExRate:          rate,
+		RateSource:      original.RateSource,
+		RateDate:        original.RateDate,
+		OperationType:   models.OperationReversal,
+		OriginalID:      original.TransactionID,
+		Pocket:          original.Pocket,
+		TargetCurrency:  original.TargetCurrency,
+		BalanceCurrency: original.BalanceCurrency,
+	}, nil
+}
+
+// reversalPostings mirrors the postings of the original operation, which moved the wallet balance
+// in balanceCurrency.
+func reversalPostings(
+	reversal models.Transaction,
+	operationType string,
+	walletID uuid.UUID,
+	balanceCurrency string,
+	walletTo *models.Wallet,
+) []models.Posting {
+	switch operationType {
+	case models.OperationDeposit:
+		return movementPostings(
+			reversal.TransactionID,
+			walletID, reversal.ConvertedAmount, balanceCurrency,
+			models.CashInAccountID, reversal.Amount, reversal.Currency,
+		)
+	case models.OperationWithdraw:
+		return movementPostings(
+			reversal.TransactionID,
+			models.CashOutAccountID, reversal.Amount, reversal.Currency,
+			walletID, reversal.ConvertedAmount, balanceCurrency,
+		)
+	case models.OperationFee:
+		return movementPostings(
+			reversal.TransactionID,
+			models.FeeRevenueAccountID, reversal.Amount, reversal.Currency,
+			walletID, reversal.ConvertedAmount, balanceCurrency,
+		)
+	default:
+		return movementPostings(
+			reversal.TransactionID,
+			walletTo.ID, reversal.ConvertedAmount, walletTo.Currency,
+			walletID, reversal.Amount, balanceCurrency,
+		)
+	}
+}
+
+// exchangeRate returns the effective rate at which amount was converted.
+func exchangeRate(amount, convertedAmount models.Decimal) models.Decimal {
+	rate, err := convertedAmount.Div(amount)
+	if err != nil {
+		return models.NewDecimalFromInt(1)
+	}
+
+	return rate
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const percentBase = 100
+
+func (s *Service) CreateSchedule(ctx context.Context, schedule models.Schedule, ownerID uuid.UUID) (*models.Schedule, error) {
+	schedule.ID = uuid.New()
+	schedule.OwnerID = ownerID
+	schedule.Active = true
+
+	if err := s.prepareSchedule(ctx, &schedule); err != nil {
+		return nil, err
+	}
+
+	createdSchedule, err := s.db.CreateSchedule(ctx, schedule)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.CreateSchedule() err: %w", err)
+	}
+
+	return createdSchedule, nil
+}
+
+func (s *Service) GetSchedules(ctx context.Context, ownerID uuid.UUID) ([]*models.Schedule, error) {
+	schedules, err := s.db.GetSchedules(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetSchedules() err: %w", err)
+	}
+
+	return schedules, nil
+}
+
+func (s *Service) GetSchedule(ctx context.Context, id, ownerID uuid.UUID) (*models.Schedule, error) {
+	schedule, err := s.db.GetScheduleByID(ctx, id, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetScheduleByID(id) err: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// UpdateSchedule replaces the definition of the schedule and recalculates its next run.
+func (s *Service) UpdateSchedule(ctx context.Context, id, ownerID uuid.UUID, schedule models.Schedule) (*models.Schedule, error) {
+	var updatedSchedule *models.Schedule
+
+	schedule.ID = id
+	schedule.OwnerID = ownerID
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.db.GetScheduleByID(ctx, id, ownerID); err != nil {
+			return fmt.Errorf("s.db.GetScheduleByID(id) err: %w", err)
+		}
+
+		if err := s.prepareSchedule(ctx, &schedule); err != nil {
+			return err
+		}
+
+		var err error
+
+		updatedSchedule, err = s.db.UpdateSchedule(ctx, schedule)
+		if err != nil {
+			return fmt.Errorf("s.db.UpdateSchedule() err: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return updatedSchedule, nil
+}
+
+func (s *Service) DeleteSchedule(ctx context.Context, id, ownerID uuid.UUID) error {
+	if err := s.db.DeleteSchedule(ctx, id, ownerID); err != nil {
+		return fmt.Errorf("s.db.DeleteSchedule(id) err: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetScheduleRuns(ctx context.Context, id, ownerID uuid.UUID, params models.Params) ([]*models.ScheduleRun, error) {
+	if _, err := s.db.GetScheduleByID(ctx, id, ownerID); err != nil {
+		return nil, fmt.Errorf("s.db.GetScheduleByID(id) err: %w", err)
+	}
+
+	runs, err := s.db.GetScheduleRuns(ctx, id, params)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetScheduleRuns(id) err: %w", err)
+	}
+
+	return runs, nil
+}
+
+// prepareSchedule checks that the source wallet belongs to the owner and sets the next run.
+func (s *Service) prepareSchedule(ctx context.Context, schedule *models.Schedule) error {
+	if _, err := s.db.GetWalletByID(ctx, schedule.WalletID, schedule.OwnerID); err != nil {
+		return fmt.Errorf("s.db.GetWalletByID(walletID) err: %w", err)
+	}
+
+	schedule.NextRunAt = nil
+
+	if !schedule.Active {
+		return nil
+	}
+
+	nextRunAt, ok := schedule.NextRun(time.Now())
+	if !ok {
+		return fmt.Errorf("%w: schedule has no future runs", models.ErrInvalidSchedule)
+	}
+
+	schedule.NextRunAt = &nextRunAt
+
+	return nil
+}
+
+// StartScheduler periodically executes due schedules. Replicas don't execute the same run twice:
+// due schedules stay locked until their runs are recorded, and each run uses an idempotency key.
+func (s *Service) StartScheduler(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.SchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunDueSchedules(ctx); err != nil {
+			log.Errorf("scheduler failed: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// RunDueSchedules executes a batch of due schedules, records their runs and moves them to their next runs.
+func (s *Service) RunDueSchedules(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	err := s.db.DoWithTx(ctx, func(txCtx context.Context) error {
+		schedules, err := s.db.GetDueSchedules(txCtx, now, s.cfg.SchedulerBatchSize)
+		if err != nil {
+			return fmt.Errorf("s.db.GetDueSchedules() err: %w", err)
+		}
+
+		for _, schedule := range schedules {
+			// Operations run in their own transactions, outside of the one holding the schedule locks.
+			run := s.executeSchedule(ctx, *schedule)
+
+			if err = s.db.SaveScheduleRun(txCtx, run); err != nil {
+				return fmt.Errorf("s.db.SaveScheduleRun() err: %w", err)
+			}
+
+			var nextRunAt *time.Time
+
+			// Runs missed while no scheduler was running are executed once, not caught up one by one.
+			if next, ok := schedule.NextRun(now); ok {
+				nextRunAt = &next
+			}
+
+			if err = s.db.SetScheduleNextRun(txCtx, schedule.ID, nextRunAt); err != nil {
+				return fmt.Errorf("s.db.SetScheduleNextRun() err: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) executeSchedule(ctx context.Context, schedule models.Schedule) models.ScheduleRun {
+	scheduledAt := *schedule.NextRunAt
+
+	run := models.ScheduleRun{
+		ScheduleID:  schedule.ID,
+		ScheduledAt: scheduledAt,
+		Status:      models.ScheduleRunFailed,
+	}
+
+	executedTransaction, err := s.executeScheduledOperation(ctx, schedule, scheduledAt)
+
+	run.ExecutedAt = time.Now().UTC()
+
+	if err != nil {
+		run.Error = err.Error()
+
+		log.Warnf("schedule %s run at %s failed: %v", schedule.ID, scheduledAt, err)
+
+		return run
+	}
+
+	run.Status = models.ScheduleRunSucceeded
+	run.TransactionID = executedTransaction.TransactionID
+
+	return run
+}
+
+func (s *Service) executeScheduledOperation(ctx context.Context, schedule models.Schedule, scheduledAt time.Time) (
+	*models.Transaction, error,
+) {
+	runKey := fmt.Sprintf("schedule:%s:%d", schedule.ID, scheduledAt.Unix())
+
+	transaction := models.Transaction{
+		TransactionID:  uuid.NewSHA1(schedule.ID, []byte(runKey)),
+		WalletID:       schedule.WalletID,
+		TargetWalletID: schedule.TargetWalletID,
+		Recipient:      schedule.Recipient,
+		Currency:       schedule.Currency,
+		OperationType:  schedule.OperationType,
+		IdempotencyKey: runKey,
+	}
+
+	if schedule.Amount != nil {
+		transaction.Amount = *schedule.Amount
+	} else {
+		wallet, err := s.db.GetWalletByID(ctx, schedule.WalletID, schedule.OwnerID)
+		if err != nil {
+			return nil, fmt.Errorf("s.db.GetWalletByID(walletID) err: %w", err)
+		}
+
+		share, err := wallet.AvailableBalance.Mul(*schedule.Percent).Div(models.NewDecimalFromInt(percentBase))
+		if err != nil {
+			return nil, fmt.Errorf("calculating percent of balance err: %w", err)
+		}
+
+		transaction.Amount = share.RoundForCurrency(wallet.Currency)
+		transaction.Currency = wallet.Currency
+	}
+
+	if err := transaction.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch schedule.OperationType {
+	case models.OperationDeposit:
+		return s.Deposit(ctx, transaction, schedule.OwnerID)
+	case models.OperationWithdraw:
+		return s.Withdraw(ctx, transaction, schedule.OwnerID)
+	default:
+		return s.Transfer(ctx, transaction, schedule.OwnerID)
+	}
+}
@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,44 +14,139 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-const cleaningEvery = 5 * time.Second
+type Config struct {
+	IdempotencyKeyTTL         time.Duration
+	LedgerCheckInterval       time.Duration
+	HoldTTL                   time.Duration
+	HoldsExpiryInterval       time.Duration
+	SchedulerInterval         time.Duration
+	SchedulerBatchSize        int
+	RefreshTokenTTL           time.Duration
+	QuoteTTL                  time.Duration
+	Fees                      models.FeeSchedule
+	FeeSettlementInterval     time.Duration
+	CurrenciesRefreshInterval time.Duration
+	WalletRestorePeriod       time.Duration
+	CleanerInterval           time.Duration
+	OutboxRetention           time.Duration
+	// The dormancy policy is off when DormancyPeriod is zero.
+	DormancyPeriod        time.Duration
+	DormancyWarningPeriod time.Duration
+	DormancyBatchSize     int
+}
 
 type Service struct {
-	db                   db
-	xrConverter          xrConverter
-	transactionsProducer transactionsProducer
-	metrics              *metrics
+	db             db
+	xrConverter    xrConverter
+	tokenGenerator tokenGenerator
+	passwordHasher passwordHasher
+	metrics        *metrics
+	cfg            Config
+
+	// dummyPasswordHash is verified against when the login is unknown, see Login.
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
 }
 
-func NewService(db db, xrConverter xrConverter, transactionsProducer transactionsProducer) *Service {
+func NewService(db db, xrConverter xrConverter, tokenGenerator tokenGenerator, passwordHasher passwordHasher, cfg Config) *Service {
 	return &Service{
-		db:                   db,
-		xrConverter:          xrConverter,
-		transactionsProducer: transactionsProducer,
-		metrics:              newMetrics(),
+		db:             db,
+		xrConverter:    xrConverter,
+		tokenGenerator: tokenGenerator,
+		passwordHasher: passwordHasher,
+		metrics:        newMetrics(),
+		cfg:            cfg,
 	}
 }
 
 type xrConverter interface {
-	Convert(ctx context.Context, currencyFrom, currencyTo converter.Currency) (float64, error)
-}
-
-//go:generate mockery --name transactionsProducer --exported
-type transactionsProducer interface {
-	ProduceTransaction(ctx context.Context, transactions models.Transaction) error
+	Convert(ctx context.Context, currencyFrom, currencyTo converter.Currency) (*models.Conversion, error)
+	ConvertAt(ctx context.Context, currencyFrom, currencyTo converter.Currency, date time.Time) (*models.Conversion, error)
 }
 
 type db interface {
 	CreateWallet(ctx context.Context, wallet models.Wallet) (*models.Wallet, error)
 	GetWalletByID(ctx context.Context, id, ownerID uuid.UUID) (*models.Wallet, error)
-	UpdateWallet(ctx context.Context, id, ownerID uuid.UUID, name, currency *string, balance float64) (*models.Wallet, error)
+	GetWallets(ctx context.Context, ownerID uuid.UUID, params models.Params) ([]*models.Wallet, error)
+	UpdateWallet(
+		ctx context.Context,
+		id, ownerID uuid.UUID,
+		name, currency *string,
+		balance models.Decimal,
+		multiCurrency, savings bool,
+	) (*models.Wallet, error)
+	GetPockets(ctx context.Context, walletID uuid.UUID) ([]models.Pocket, error)
 	DeleteWallet(ctx context.Context, id, ownerID uuid.UUID) error
-	Withdraw(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error
-	Deposit(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error
-	Transfer(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error
+	GetDeletedWallets(ctx context.Context, ownerID uuid.UUID, deletedAfter time.Time) ([]*models.Wallet, error)
+	RestoreWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	PurgeWallets(ctx context.Context, deletedBefore time.Time) (int64, error)
+	Withdraw(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error)
+	Deposit(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error)
+	Transfer(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error)
+	Exchange(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID, toPocket string) (*models.Transaction, error)
 	GetTransactions(ctx context.Context, ID uuid.UUID, params models.Params) ([]*models.Transaction, error)
+	GetTransactionByID(ctx context.Context, id, ownerID uuid.UUID) (*models.Transaction, error)
+	GetAnyTransactionByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error)
+	GetFeeTransaction(ctx context.Context, id uuid.UUID) (*models.Transaction, error)
+	GetIdempotencyKey(ctx context.Context, ownerID uuid.UUID, key string, createdAfter time.Time) (*models.IdempotencyKey, error)
+	DeleteExpiredIdempotencyKey(ctx context.Context, ownerID uuid.UUID, key string, createdBefore time.Time) error
+	CleanIdempotencyKeys(ctx context.Context, createdBefore time.Time) error
+	CleanOutbox(ctx context.Context, publishedBefore time.Time) (int64, error)
+	GetTransferTargetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	GetRecipientWallet(ctx context.Context, recipient, currency string) (*models.Wallet, error)
+	LockWallets(ctx context.Context, ids ...uuid.UUID) error
+	CreateHold(ctx context.Context, hold models.Hold) (*models.Hold, error)
+	GetHoldByID(ctx context.Context, id, ownerID uuid.UUID) (*models.Hold, error)
+	CaptureHold(ctx context.Context, hold models.Hold, transaction models.Transaction) (*models.Transaction, error)
+	VoidHold(ctx context.Context, hold models.Hold) (*models.Hold, error)
+	ExpireHolds(ctx context.Context, before time.Time) (int64, error)
+	CreateSchedule(ctx context.Context, schedule models.Schedule) (*models.Schedule, error)
+	GetSchedules(ctx context.Context, ownerID uuid.UUID) ([]*models.Schedule, error)
+	GetScheduleByID(ctx context.Context, id, ownerID uuid.UUID) (*models.Schedule, error)
+	UpdateSchedule(ctx context.Context, schedule models.Schedule) (*models.Schedule, error)
+	DeleteSchedule(ctx context.Context, id, ownerID uuid.UUID) error
+	GetDueSchedules(ctx context.Context, now time.Time, limit int) ([]*models.Schedule, error)
+	SetScheduleNextRun(ctx context.Context, id uuid.UUID, nextRunAt *time.Time) error
+	SaveScheduleRun(ctx context.Context, run models.ScheduleRun) error
+	GetScheduleRuns(ctx context.Context, scheduleID uuid.UUID, params models.Params) ([]*models.ScheduleRun, error)
+	Reverse(ctx context.Context, reversal, original models.Transaction) (*models.Transaction, error)
+	GetReversedAmounts(ctx context.Context, id uuid.UUID) (models.Decimal, models.Decimal, error)
+	SaveTransaction(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error)
+	SavePostings(ctx context.Context, postings []models.Posting) error
+	GetLedgerReport(ctx context.Context) (*models.LedgerReport, error)
+	GetAccountBalance(ctx context.Context, accountID uuid.UUID, currency string) (models.Decimal, error)
+	SaveOutboxMessage(ctx context.Context, message models.OutboxMessage) error
+	GetUserByLogin(ctx context.Context, login string) (*models.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	UpdateUserPassword(ctx context.Context, id uuid.UUID, oldPassword, newPassword string) error
+	GetUserPasswords(ctx context.Context, afterID uuid.UUID, limit int) ([]*models.User, error)
+	FindUsers(ctx context.Context, params models.Params) ([]*models.User, error)
+	SetUserRoles(ctx context.Context, id uuid.UUID, roles []string) error
+	SetUserTier(ctx context.Context, id uuid.UUID, tier string) error
+	GetAnyWalletByID(ctx context.Context, id uuid.UUID) (*models.Wallet, error)
+	SetWalletStatus(ctx context.Context, id uuid.UUID, status string) (*models.Wallet, error)
+	SaveWalletStatusChange(ctx context.Context, change models.WalletStatusChange) error
+	GetWalletStatusChanges(ctx context.Context, walletID uuid.UUID) ([]*models.WalletStatusChange, error)
+	SaveRefreshToken(ctx context.Context, token models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, id uuid.UUID, rotatedAt time.Time) error
+	RevokeSession(ctx context.Context, sessionID uuid.UUID) error
+	IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error)
+	CleanRefreshTokens(ctx context.Context, expiredBefore time.Time) error
+	SaveQuote(ctx context.Context, quote models.Quote) (*models.Quote, error)
+	GetQuoteByID(ctx context.Context, id, ownerID uuid.UUID) (*models.Quote, error)
+	UseQuote(ctx context.Context, id, transactionID uuid.UUID) error
+	CleanQuotes(ctx context.Context, expiredBefore time.Time) error
+	GetCurrencies(ctx context.Context) ([]*models.Currency, error)
+	GetCurrency(ctx context.Context, code string) (*models.Currency, error)
+	CreateCurrency(ctx context.Context, currency models.Currency) (*models.Currency, error)
+	UpdateCurrency(ctx context.Context, currency models.Currency) (*models.Currency, error)
+	GetWalletsToWarn(ctx context.Context, inactiveBefore time.Time, limit int) ([]*models.Wallet, error)
+	GetWalletsToMakeDormant(ctx context.Context, inactiveBefore, warnedBefore time.Time, limit int) ([]*models.Wallet, error)
+	MarkDormancyWarned(ctx context.Context, id uuid.UUID, warnedAt time.Time) error
+	SaveDormancyRun(ctx context.Context, run models.DormancyRun) error
+	GetDormancyRuns(ctx context.Context, params models.Params) ([]*models.DormancyRun, error)
 	DoWithTx(ctx context.Context, fn func(ctx context.Context) error) error
-	Clean(ctx context.Context) error
 }
 
 func (s *Service) CreateWallet(ctx context.Context, wallet models.Wallet) (*models.Wallet, error) {
@@ -67,9 +164,47 @@ func (s *Service) GetWalletByID(ctx context.Context, id, ownerID uuid.UUID) (*mo
 		return nil, fmt.Errorf("s.db.GetWalletByID(id) err: %w", err)
 	}
 
+	if err = s.attachPockets(ctx, wallet); err != nil {
+		return nil, err
+	}
+
 	return wallet, nil
 }
 
+func (s *Service) GetWallets(ctx context.Context, ownerID uuid.UUID, params models.Params) ([]*models.Wallet, error) {
+	wallets, err := s.db.GetWallets(ctx, ownerID, params)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetWallets(ownerID) err: %w", err)
+	}
+
+	if err = s.attachPockets(ctx, wallets...); err != nil {
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
+// attachPockets loads the pockets of the multi-currency wallets among the wallets.
+func (s *Service) attachPockets(ctx context.Context, wallets ...*models.Wallet) error {
+	for _, wallet := range wallets {
+		if !wallet.MultiCurrency {
+			continue
+		}
+
+		pockets, err := s.db.GetPockets(ctx, wallet.ID)
+		if err != nil {
+			return fmt.Errorf("s.db.GetPockets(walletID) err: %w", err)
+		}
+
+		wallet.Pockets = pockets
+	}
+
+	return nil
+}
+
+// UpdateWallet renames the wallet, converts its balance into a new currency, switches it to and from
+// multi-currency or marks it as a savings wallet, which never becomes dormant. The currency of a
+// multi-currency wallet is fixed, and it can stop being multi-currency only once its pockets are empty.
 func (s *Service) UpdateWallet(ctx context.Context, id, ownerID uuid.UUID, walletDTO models.WalletDTO) (*models.Wallet, error) {
 	var updatedWallet *models.Wallet
 
@@ -82,11 +217,21 @@ func (s *Service) UpdateWallet(ctx context.Context, id, ownerID uuid.UUID, walle
 		newBalance := wallet.Balance
 		newCurrency := &wallet.Currency
 
+		var conversion *models.Conversion
+
 		if walletDTO.Currency != nil {
 			newCurrency = walletDTO.Currency
 
 			if wallet.Currency != *walletDTO.Currency {
-				convertedAmount, err := s.xrConverter.Convert(
+				if wallet.MultiCurrency {
+					return models.ErrMultiCurrencyWallet
+				}
+
+				if !wallet.AvailableBalance.Equal(wallet.Balance) {
+					return models.ErrWalletHasHolds
+				}
+
+				conversion, err = s.xrConverter.Convert(
 					ctx,
 					converter.Currency{Amount: wallet.Balance, Name: wallet.Currency},
 					converter.Currency{Amount: wallet.Balance, Name: *walletDTO.Currency},
@@ -95,7 +240,7 @@ func (s *Service) UpdateWallet(ctx context.Context, id, ownerID uuid.UUID, walle
 					return fmt.Errorf("s.xrConverter.Convert(...) err: %w", err)
 				}
 
-				newBalance = convertedAmount
+				newBalance = conversion.Amount
 
 				s.metrics.IncrXRRequests(wallet.Currency, *walletDTO.Currency)
 			}
@@ -106,11 +251,35 @@ func (s *Service) UpdateWallet(ctx context.Context, id, ownerID uuid.UUID, walle
 			newName = walletDTO.Name
 		}
 
-		updatedWallet, err = s.db.UpdateWallet(ctx, id, ownerID, newName, newCurrency, newBalance)
+		newMultiCurrency := wallet.MultiCurrency
+		if walletDTO.MultiCurrency != nil {
+			newMultiCurrency = *walletDTO.MultiCurrency
+		}
+
+		newSavings := wallet.Savings
+		if walletDTO.Savings != nil {
+			newSavings = *walletDTO.Savings
+		}
+
+		if wallet.MultiCurrency && !newMultiCurrency {
+			if err = s.checkPocketsEmpty(ctx, wallet.ID); err != nil {
+				return err
+			}
+		}
+
+		updatedWallet, err = s.db.UpdateWallet(ctx, id, ownerID, newName, newCurrency, newBalance, newMultiCurrency, newSavings)
 		if err != nil {
 			return fmt.Errorf("s.db.UpdateWallet(ctx, id, walletDTO) err: %w", err)
 		}
 
+		if err = s.attachPockets(ctx, updatedWallet); err != nil {
+			return err
+		}
+
+		if wallet.Currency != updatedWallet.Currency && !wallet.Balance.IsZero() {
+			return s.saveConversion(ctx, *wallet, *updatedWallet, *conversion, ownerID)
+		}
+
 		return nil
 	}); err != nil {
 		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
@@ -119,6 +288,55 @@ func (s *Service) UpdateWallet(ctx context.Context, id, ownerID uuid.UUID, walle
 	return updatedWallet, nil
 }
 
+func (s *Service) checkPocketsEmpty(ctx context.Context, walletID uuid.UUID) error {
+	pockets, err := s.db.GetPockets(ctx, walletID)
+	if err != nil {
+		return fmt.Errorf("s.db.GetPockets(walletID) err: %w", err)
+	}
+
+	for _, pocket := range pockets {
+		if !pocket.Balance.IsZero() {
+			return fmt.Errorf("%w: %s", models.ErrWalletHasPockets, pocket.Currency)
+		}
+	}
+
+	return nil
+}
+
+// saveConversion records the conversion of the whole wallet balance into a new currency.
+func (s *Service) saveConversion(
+	ctx context.Context,
+	wallet, updatedWallet models.Wallet,
+	conversion models.Conversion,
+	ownerID uuid.UUID,
+) error {
+	transaction := models.Transaction{
+		TransactionID:   uuid.New(),
+		WalletID:        wallet.ID,
+		TargetWalletID:  wallet.ID,
+		Amount:          wallet.Balance,
+		Currency:        wallet.Currency,
+		OperationType:   models.OperationConversion,
+		BalanceCurrency: wallet.Currency,
+	}
+	transaction.ApplyConversion(conversion)
+
+	executedTransaction, err := s.db.SaveTransaction(ctx, transaction, ownerID)
+	if err != nil {
+		return fmt.Errorf("s.db.SaveTransaction() err: %w", err)
+	}
+
+	if err = s.savePostings(ctx, movementPostings(
+		executedTransaction.TransactionID,
+		wallet.ID, wallet.Balance, wallet.Currency,
+		wallet.ID, updatedWallet.Balance, updatedWallet.Currency,
+	)); err != nil {
+		return err
+	}
+
+	return s.saveTransactionEvent(ctx, *executedTransaction)
+}
+
 func (s *Service) DeleteWallet(ctx context.Context, id, ownerID uuid.UUID) error {
 	if err := s.db.DeleteWallet(ctx, id, ownerID); err != nil {
 		return fmt.Errorf("s.db.DeleteWallet(id) err: %w", err)
@@ -127,129 +345,429 @@ func (s *Service) DeleteWallet(ctx context.Context, id, ownerID uuid.UUID) error
 	return nil
 }
 
+func (s *Service) Withdraw(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (
+	*models.Transaction, error,
+) {
+	return s.executeIdempotent(ctx, transaction, ownerID, s.withdraw)
+}
+
 //nolint:dupl
-func (s *Service) Withdraw(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error {
+func (s *Service) withdraw(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (
+	*models.Transaction, error,
+) {
+	var executedTransaction *models.Transaction
+
 	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
 		wallet, err := s.db.GetWalletByID(ctx, transaction.WalletID, ownerID)
 		if err != nil {
 			return fmt.Errorf("s.db.GetWalletByID(walletID) err: %w", err)
 		}
 
-		if wallet.Currency != transaction.Currency {
-			convertedAmount, err := s.xrConverter.Convert(
-				ctx,
-				converter.Currency{Amount: transaction.Amount, Name: transaction.Currency},
-				converter.Currency{Amount: wallet.Balance, Name: wallet.Currency},
-			)
-			if err != nil {
-				return fmt.Errorf("s.xrConverter.Convert(...) err: %w", err)
-			}
+		if err = wallet.CheckDebitable(); err != nil {
+			return err
+		}
 
-			transaction.ConvertedAmount = convertedAmount
+		// A multi-currency wallet pays out of the pocket of the currency instead of converting.
+		transaction.Pocket = wallet.PocketOf(transaction.Currency)
+		balanceCurrency := wallet.BalanceCurrency(transaction.Pocket)
+		transaction.BalanceCurrency = balanceCurrency
+
+		conversion, err := s.convertTransaction(ctx, transaction, ownerID, transaction.Currency, balanceCurrency)
+		if err != nil {
+			return err
 		}
 
-		err = s.db.Withdraw(ctx, transaction, ownerID)
+		transaction.ApplyConversion(*conversion)
+
+		executedTransaction, err = s.db.Withdraw(ctx, transaction, ownerID)
 		if err != nil {
 			return fmt.Errorf("s.db.Withdraw() err: %w", err)
 		}
 
-		if err = s.transactionsProducer.ProduceTransaction(ctx, transaction); err != nil {
-			return fmt.Errorf("s.transactionsProducer.ProduceTransaction() err: %w", err)
+		if err = s.savePostings(ctx, movementPostings(
+			executedTransaction.TransactionID,
+			wallet.ID, transaction.ConvertedAmount, balanceCurrency,
+			models.CashOutAccountID, transaction.Amount, transaction.Currency,
+		)); err != nil {
+			return err
 		}
 
-		return nil
+		if err = s.saveTransactionEvent(ctx, *executedTransaction); err != nil {
+			return err
+		}
+
+		return s.chargeFee(
+			ctx, *executedTransaction, *wallet,
+			transaction.Currency, balanceCurrency, balanceCurrency, transaction.ConvertedAmount,
+		)
 	}); err != nil {
-		return fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
 	}
 
-	return nil
+	return executedTransaction, nil
+}
+
+func (s *Service) Deposit(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (
+	*models.Transaction, error,
+) {
+	return s.executeIdempotent(ctx, transaction, ownerID, s.deposit)
 }
 
 //nolint:dupl
-func (s *Service) Deposit(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error {
+func (s *Service) deposit(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (
+	*models.Transaction, error,
+) {
+	var executedTransaction *models.Transaction
+
 	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
 		wallet, err := s.db.GetWalletByID(ctx, transaction.WalletID, ownerID)
 		if err != nil {
 			return fmt.Errorf("s.db.GetWalletByID(walletID) err: %w", err)
 		}
 
-		if wallet.Currency != transaction.Currency {
-			convertedAmount, err := s.xrConverter.Convert(
-				ctx,
-				converter.Currency{Amount: transaction.Amount, Name: transaction.Currency},
-				converter.Currency{Amount: wallet.Balance, Name: wallet.Currency},
-			)
-			if err != nil {
-				return fmt.Errorf("s.xrConverter.Convert(...) err: %w", err)
-			}
+		if err = wallet.CheckActive(); err != nil {
+			return err
+		}
+
+		// A multi-currency wallet keeps a foreign currency in its pocket instead of converting it.
+		transaction.Pocket = wallet.PocketOf(transaction.Currency)
+		balanceCurrency := wallet.BalanceCurrency(transaction.Pocket)
+		transaction.BalanceCurrency = balanceCurrency
 
-			transaction.ConvertedAmount = convertedAmount
+		conversion, err := s.convertTransaction(ctx, transaction, ownerID, transaction.Currency, balanceCurrency)
+		if err != nil {
+			return err
 		}
 
-		err = s.db.Deposit(ctx, transaction, ownerID)
+		transaction.ApplyConversion(*conversion)
+
+		executedTransaction, err = s.db.Deposit(ctx, transaction, ownerID)
 		if err != nil {
 			return fmt.Errorf("s.db.Deposit() err: %w", err)
 		}
 
-		if err = s.transactionsProducer.ProduceTransaction(ctx, transaction); err != nil {
-			return fmt.Errorf("s.transactionsProducer.ProduceTransaction() err: %w", err)
+		if err = s.savePostings(ctx, movementPostings(
+			executedTransaction.TransactionID,
+			models.CashInAccountID, transaction.Amount, transaction.Currency,
+			wallet.ID, transaction.ConvertedAmount, balanceCurrency,
+		)); err != nil {
+			return err
 		}
 
-		return nil
+		if err = s.saveTransactionEvent(ctx, *executedTransaction); err != nil {
+			return err
+		}
+
+		return s.chargeFee(
+			ctx, *executedTransaction, *wallet,
+			transaction.Currency, balanceCurrency, balanceCurrency, transaction.ConvertedAmount,
+		)
 	}); err != nil {
-		return fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
 	}
 
-	return nil
+	return executedTransaction, nil
 }
 
-func (s *Service) Transfer(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error {
-	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+func (s *Service) Transfer(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (
+	*models.Transaction, error,
+) {
+	return s.executeIdempotent(ctx, transaction, ownerID, s.transfer)
+}
+
+func (s *Service) transfer(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (
+	*models.Transaction, error,
+) {
+	var executedTransaction *models.Transaction
+
+	targetWalletID, err := s.resolveTransferTarget(ctx, transaction, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		if err := s.db.LockWallets(ctx, transaction.WalletID, targetWalletID); err != nil {
+			return fmt.Errorf("s.db.LockWallets(walletID, targetWalletID) err: %w", err)
+		}
+
 		walletFrom, err := s.db.GetWalletByID(ctx, transaction.WalletID, ownerID)
 		if err != nil {
 			return fmt.Errorf("s.db.GetWalletByID(walletID) err: %w", err)
 		}
 
-		walletTo, err := s.db.GetWalletByID(ctx, transaction.TargetWalletID, ownerID)
+		if err = walletFrom.CheckDebitable(); err != nil {
+			return err
+		}
+
+		// A multi-currency wallet sends a currency other than its own from the pocket of the currency.
+		transaction.Pocket = walletFrom.PocketOf(transaction.Currency)
+		currencyFrom := walletFrom.BalanceCurrency(transaction.Pocket)
+
+		walletTo, err := s.db.GetTransferTargetWallet(ctx, targetWalletID)
 		if err != nil {
-			return fmt.Errorf("s.db.GetWalletByID(walletID) err: %w", err)
+			return fmt.Errorf("s.db.GetTransferTargetWallet(targetWalletID) err: %w", err)
 		}
 
-		if walletFrom.Currency != walletTo.Currency {
-			convertedAmount, err := s.xrConverter.Convert(
-				ctx,
-				converter.Currency{Amount: transaction.Amount, Name: walletFrom.Currency},
-				converter.Currency{Amount: walletTo.Balance, Name: walletTo.Currency},
-			)
-			if err != nil {
-				return fmt.Errorf("s.xrConverter.Convert(...) err: %w", err)
-			}
+		if err = walletTo.CheckActive(); err != nil {
+			return err
+		}
 
-			transaction.ConvertedAmount = convertedAmount
+		transaction.TargetWalletID = walletTo.ID
+		transaction.TargetOwnerID = walletTo.Owner
+		transaction.BalanceCurrency = currencyFrom
+		transaction.TargetCurrency = walletTo.Currency
+		conversion, err := s.convertTransaction(ctx, transaction, ownerID, currencyFrom, walletTo.Currency)
+		if err != nil {
+			return err
 		}
 
-		err = s.db.Transfer(ctx, transaction, ownerID)
+		transaction.ApplyConversion(*conversion)
+
+		executedTransaction, err = s.db.Transfer(ctx, transaction, ownerID)
 		if err != nil {
 			return fmt.Errorf("s.db.Transfer() err: %w", err)
 		}
 
-		if err = s.transactionsProducer.ProduceTransaction(ctx, transaction); err != nil {
-			return fmt.Errorf("s.transactionsProducer.ProduceTransaction() err: %w", err)
+		if err = s.savePostings(ctx, movementPostings(
+			executedTransaction.TransactionID,
+			walletFrom.ID, transaction.Amount, currencyFrom,
+			walletTo.ID, transaction.ConvertedAmount, walletTo.Currency,
+		)); err != nil {
+			return err
 		}
 
-		return nil
+		if err = s.saveTransferEvents(ctx, *executedTransaction); err != nil {
+			return err
+		}
+
+		return s.chargeFee(
+			ctx, *executedTransaction, *walletFrom, currencyFrom, walletTo.Currency, currencyFrom, transaction.Amount,
+		)
 	}); err != nil {
-		return fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return executedTransaction, nil
+}
+
+func (s *Service) Exchange(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (
+	*models.Transaction, error,
+) {
+	return s.executeIdempotent(ctx, transaction, ownerID, s.exchange)
+}
+
+// exchange converts the amount between two balances of a multi-currency wallet: from the balance in
+// the transaction currency to the balance in the target currency, either of which may be the main one.
+func (s *Service) exchange(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (
+	*models.Transaction, error,
+) {
+	var executedTransaction *models.Transaction
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		wallet, err := s.db.GetWalletByID(ctx, transaction.WalletID, ownerID)
+		if err != nil {
+			return fmt.Errorf("s.db.GetWalletByID(walletID) err: %w", err)
+		}
+
+		if err = wallet.CheckActive(); err != nil {
+			return err
+		}
+
+		if !wallet.MultiCurrency {
+			return models.ErrNotMultiCurrency
+		}
+
+		transaction.TargetWalletID = wallet.ID
+		transaction.TargetOwnerID = wallet.Owner
+		transaction.Pocket = wallet.PocketOf(transaction.Currency)
+		transaction.BalanceCurrency = wallet.BalanceCurrency(transaction.Pocket)
+
+		conversion, err := s.convertTransaction(ctx, transaction, ownerID, transaction.Currency, transaction.TargetCurrency)
+		if err != nil {
+			return err
+		}
+
+		transaction.ApplyConversion(*conversion)
+
+		executedTransaction, err = s.db.Exchange(ctx, transaction, ownerID, wallet.PocketOf(transaction.TargetCurrency))
+		if err != nil {
+			return fmt.Errorf("s.db.Exchange() err: %w", err)
+		}
+
+		if err = s.savePostings(ctx, movementPostings(
+			executedTransaction.TransactionID,
+			wallet.ID, transaction.Amount, transaction.Currency,
+			wallet.ID, transaction.ConvertedAmount, transaction.TargetCurrency,
+		)); err != nil {
+			return err
+		}
+
+		if err = s.saveTransactionEvent(ctx, *executedTransaction); err != nil {
+			return err
+		}
+
+		return s.chargeFee(
+			ctx, *executedTransaction, *wallet,
+			transaction.Currency, transaction.TargetCurrency, transaction.Currency, transaction.Amount,
+		)
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return executedTransaction, nil
+}
+
+// resolveTransferTarget returns the ID of the receiving wallet without locking any wallet, so that
+// the transfer can lock both wallets in the order of their IDs and opposing transfers can't deadlock.
+func (s *Service) resolveTransferTarget(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (
+	uuid.UUID, error,
+) {
+	walletFrom, err := s.db.GetWalletByID(ctx, transaction.WalletID, ownerID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("s.db.GetWalletByID(walletID) err: %w", err)
+	}
+
+	walletTo, err := s.getTransferTarget(ctx, transaction, walletFrom.BalanceCurrency(walletFrom.PocketOf(transaction.Currency)))
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return walletTo.ID, nil
+}
+
+// getTransferTarget resolves the receiving wallet, which may belong to another user. A recipient
+// given by user ID, email or phone takes precedence over the target wallet ID.
+func (s *Service) getTransferTarget(ctx context.Context, transaction models.Transaction, currency string) (
+	*models.Wallet, error,
+) {
+	if transaction.Recipient != "" {
+		wallet, err := s.db.GetRecipientWallet(ctx, transaction.Recipient, currency)
+		if err != nil {
+			return nil, fmt.Errorf("s.db.GetRecipientWallet(recipient) err: %w", err)
+		}
+
+		return wallet, nil
+	}
+
+	wallet, err := s.db.GetTransferTargetWallet(ctx, transaction.TargetWalletID)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetTransferTargetWallet(targetWalletID) err: %w", err)
+	}
+
+	return wallet, nil
+}
+
+// saveTransactionEvent stores the transaction event in the outbox within the current
+// DB transaction; the broker relay publishes it after commit.
+func (s *Service) saveTransactionEvent(ctx context.Context, transaction models.Transaction) error {
+	payload, err := json.Marshal(transaction)
+	if err != nil {
+		return fmt.Errorf("json.Marshal(transaction) err: %w", err)
+	}
+
+	if err = s.db.SaveOutboxMessage(ctx, models.OutboxMessage{
+		Topic:   models.TransactionsTopic,
+		Key:     transaction.TransactionID.String(),
+		Payload: payload,
+	}); err != nil {
+		return fmt.Errorf("s.db.SaveOutboxMessage() err: %w", err)
+	}
+
+	return nil
+}
+
+// saveTransferEvents stores an event for each side of a transfer, so that both the sender and
+// the recipient are notified.
+func (s *Service) saveTransferEvents(ctx context.Context, transaction models.Transaction) error {
+	for _, direction := range []string{models.DirectionOutgoing, models.DirectionIncoming} {
+		event := transaction
+		event.Direction = direction
+
+		if err := s.saveTransactionEvent(ctx, event); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (s *Service) GetTransactions(ctx context.Context, id uuid.UUID, params models.Params) (
+// executeIdempotent runs the operation once per idempotency key: a repeated request with the
+// same key returns the originally executed transaction instead of being executed again.
+func (s *Service) executeIdempotent(
+	ctx context.Context,
+	transaction models.Transaction,
+	ownerID uuid.UUID,
+	execute func(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error),
+) (*models.Transaction, error) {
+	if transaction.TransactionID == uuid.Nil {
+		transaction.TransactionID = uuid.New()
+	}
+
+	if transaction.IdempotencyKey == "" {
+		return execute(ctx, transaction, ownerID)
+	}
+
+	// Keys created before expiredBefore are ignored by the lookup and released for the insert alike.
+	expiredBefore := time.Now().Add(-s.cfg.IdempotencyKeyTTL)
+
+	previousTransaction, err := s.getIdempotentResult(ctx, transaction, ownerID, expiredBefore)
+
+	switch {
+	case err == nil:
+		return previousTransaction, nil
+	case !errors.Is(err, models.ErrIdempotencyKeyNotFound):
+		return nil, err
+	}
+
+	if err = s.db.DeleteExpiredIdempotencyKey(ctx, ownerID, transaction.IdempotencyKey, expiredBefore); err != nil {
+		return nil, fmt.Errorf("s.db.DeleteExpiredIdempotencyKey(key) err: %w", err)
+	}
+
+	executedTransaction, err := execute(ctx, transaction, ownerID)
+	if errors.Is(err, models.ErrDuplicateIdempotencyKey) {
+		return s.getIdempotentResult(ctx, transaction, ownerID, expiredBefore)
+	}
+
+	return executedTransaction, err
+}
+
+func (s *Service) getIdempotentResult(
+	ctx context.Context,
+	transaction models.Transaction,
+	ownerID uuid.UUID,
+	expiredBefore time.Time,
+) (*models.Transaction, error) {
+	idempotencyKey, err := s.db.GetIdempotencyKey(ctx, ownerID, transaction.IdempotencyKey, expiredBefore)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetIdempotencyKey(key) err: %w", err)
+	}
+
+	if idempotencyKey.RequestHash != transaction.RequestHash() {
+		return nil, models.ErrIdempotencyKeyConflict
+	}
+
+	previousTransaction, err := s.db.GetTransactionByID(ctx, idempotencyKey.TransactionID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetTransactionByID(transactionID) err: %w", err)
+	}
+
+	return previousTransaction, nil
+}
+
+// GetTransactions returns the history of the wallet, which must belong to the owner.
+func (s *Service) GetTransactions(ctx context.Context, id, ownerID uuid.UUID, params models.Params) (
 	[]*models.Transaction, error,
 ) {
-	var transactions []*models.Transaction
+	if _, err := s.db.GetWalletByID(ctx, id, ownerID); err != nil {
+		return nil, fmt.Errorf("s.db.GetWalletByID(id) err: %w", err)
+	}
+
+	return s.getTransactions(ctx, id, params)
+}
 
+func (s *Service) getTransactions(ctx context.Context, id uuid.UUID, params models.Params) (
+	[]*models.Transaction, error,
+) {
 	transactions, err := s.db.GetTransactions(ctx, id, params)
 
 	switch {
@@ -264,13 +782,46 @@ func (s *Service) GetTransactions(ctx context.Context, id uuid.UUID, params mode
 }
 
 func (s *Service) StartCleaner(ctx context.Context) error {
-	ticker := time.NewTicker(cleaningEvery)
+	ticker := time.NewTicker(s.cfg.CleanerInterval)
 	defer ticker.Stop()
 
 	for {
-		if err := s.db.Clean(ctx); err != nil {
-			log.Errorf("base cleaner failed: %v", err)
+		if s.cfg.DormancyPeriod > 0 {
+			if _, err := s.RunDormancyPolicy(ctx); err != nil {
+				log.Errorf("dormancy policy failed: %v", err)
+			}
 		}
+
+		if err := s.db.CleanIdempotencyKeys(ctx, time.Now().Add(-s.cfg.IdempotencyKeyTTL)); err != nil {
+			log.Errorf("idempotency keys cleaner failed: %v", err)
+		}
+
+		if err := s.db.CleanRefreshTokens(ctx, time.Now()); err != nil {
+			log.Errorf("refresh tokens cleaner failed: %v", err)
+		}
+
+		if err := s.db.CleanQuotes(ctx, time.Now()); err != nil {
+			log.Errorf("quotes cleaner failed: %v", err)
+		}
+
+		cleaned, err := s.db.CleanOutbox(ctx, time.Now().Add(-s.cfg.OutboxRetention))
+
+		switch {
+		case err != nil:
+			log.Errorf("outbox cleaner failed: %v", err)
+		case cleaned > 0:
+			log.Infof("%d published outbox messages cleaned", cleaned)
+		}
+
+		purged, err := s.db.PurgeWallets(ctx, time.Now().Add(-s.cfg.WalletRestorePeriod))
+
+		switch {
+		case err != nil:
+			log.Errorf("wallets purger failed: %v", err)
+		case purged > 0:
+			log.Infof("%d deleted wallets purged", purged)
+		}
+
 		select {
 		case <-ticker.C:
 		case <-ctx.Done():
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+// GetDeletedWallets returns the deleted wallets of the owner that can still be restored, with the
+// time their grace period ends.
+func (s *Service) GetDeletedWallets(ctx context.Context, ownerID uuid.UUID) ([]*models.Wallet, error) {
+	wallets, err := s.db.GetDeletedWallets(ctx, ownerID, time.Now().Add(-s.cfg.WalletRestorePeriod))
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetDeletedWallets(ownerID) err: %w", err)
+	}
+
+	for _, wallet := range wallets {
+		wallet.RestorableUntil = s.restorableUntil(*wallet)
+	}
+
+	if err = s.attachPockets(ctx, wallets...); err != nil {
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
+// RestoreWallet undeletes a wallet of the owner within the grace period after its deletion. The
+// wallet comes back with its balance, pockets and status as they were when it was deleted.
+func (s *Service) RestoreWallet(ctx context.Context, id, ownerID uuid.UUID) (*models.Wallet, error) {
+	var restoredWallet *models.Wallet
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		wallet, err := s.db.GetAnyWalletByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("s.db.GetAnyWalletByID(id) err: %w", err)
+		}
+
+		if wallet.Owner != ownerID || !wallet.Deleted {
+			return models.ErrWalletNotFound
+		}
+
+		restorableUntil := s.restorableUntil(*wallet)
+		if wallet.PurgedAt != nil || restorableUntil == nil || time.Now().After(*restorableUntil) {
+			return models.ErrRestorePeriodExpired
+		}
+
+		restoredWallet, err = s.db.RestoreWallet(ctx, id)
+		if err != nil {
+			return fmt.Errorf("s.db.RestoreWallet(id) err: %w", err)
+		}
+
+		return s.attachPockets(ctx, restoredWallet)
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return restoredWallet, nil
+}
+
+func (s *Service) restorableUntil(wallet models.Wallet) *time.Time {
+	if wallet.DeletedAt == nil {
+		return nil
+	}
+
+	restorableUntil := wallet.DeletedAt.Add(s.cfg.WalletRestorePeriod)
+
+	return &restorableUntil
+}
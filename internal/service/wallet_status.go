@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+// SetWalletStatus freezes or unfreezes a wallet of any owner on behalf of staff. Operations in
+// progress finish first, since they hold a lock on the wallet.
+func (s *Service) SetWalletStatus(ctx context.Context, id, actorID uuid.UUID, status, reason string) (*models.Wallet, error) {
+	var updatedWallet *models.Wallet
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		wallet, err := s.db.GetAnyWalletByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("s.db.GetAnyWalletByID(id) err: %w", err)
+		}
+
+		updatedWallet, err = s.changeWalletStatus(ctx, *wallet, status, actorID, reason)
+
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return updatedWallet, nil
+}
+
+// FreezeWallet lets the owner block the operations of their wallet, for example when a card is lost.
+func (s *Service) FreezeWallet(ctx context.Context, id, ownerID uuid.UUID, reason string) (*models.Wallet, error) {
+	var updatedWallet *models.Wallet
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		wallet, err := s.db.GetWalletByID(ctx, id, ownerID)
+		if err != nil {
+			return fmt.Errorf("s.db.GetWalletByID(id) err: %w", err)
+		}
+
+		updatedWallet, err = s.changeWalletStatus(ctx, *wallet, models.WalletStatusFrozen, ownerID, reason)
+
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return updatedWallet, nil
+}
+
+// UnfreezeWallet lets the owner unblock their wallet, unless it was frozen by staff.
+func (s *Service) UnfreezeWallet(ctx context.Context, id, ownerID uuid.UUID, reason string) (*models.Wallet, error) {
+	var updatedWallet *models.Wallet
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		wallet, err := s.db.GetWalletByID(ctx, id, ownerID)
+		if err != nil {
+			return fmt.Errorf("s.db.GetWalletByID(id) err: %w", err)
+		}
+
+		if wallet.Status == models.WalletStatusFrozen {
+			changes, err := s.db.GetWalletStatusChanges(ctx, wallet.ID)
+			if err != nil {
+				return fmt.Errorf("s.db.GetWalletStatusChanges(id) err: %w", err)
+			}
+
+			if len(changes) == 0 || changes[0].ChangedBy != ownerID {
+				return models.ErrFrozenByStaff
+			}
+		}
+
+		updatedWallet, err = s.changeWalletStatus(ctx, *wallet, models.WalletStatusActive, ownerID, reason)
+
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return updatedWallet, nil
+}
+
+// ReactivateWallet lets the owner bring their dormant wallet back into use.
+func (s *Service) ReactivateWallet(ctx context.Context, id, ownerID uuid.UUID, reason string) (*models.Wallet, error) {
+	var updatedWallet *models.Wallet
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		wallet, err := s.db.GetWalletByID(ctx, id, ownerID)
+		if err != nil {
+			return fmt.Errorf("s.db.GetWalletByID(id) err: %w", err)
+		}
+
+		if wallet.Status != models.WalletStatusDormant && wallet.Status != models.WalletStatusActive {
+			return fmt.Errorf("%w: from %s to %s", models.ErrInvalidStatusTransition, wallet.Status, models.WalletStatusActive)
+		}
+
+		updatedWallet, err = s.changeWalletStatus(ctx, *wallet, models.WalletStatusActive, ownerID, reason)
+
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return updatedWallet, nil
+}
+
+// CloseWallet closes the wallet of the owner, see closeWallet.
+func (s *Service) CloseWallet(ctx context.Context, id, ownerID uuid.UUID, request models.WalletStatusRequest) (
+	*models.Wallet, error,
+) {
+	var updatedWallet *models.Wallet
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		if err := s.lockSweptWallets(ctx, id, request); err != nil {
+			return err
+		}
+
+		wallet, err := s.db.GetWalletByID(ctx, id, ownerID)
+		if err != nil {
+			return fmt.Errorf("s.db.GetWalletByID(id) err: %w", err)
+		}
+
+		// A dormant wallet is empty, so it can be closed without being reactivated first.
+		if wallet.Status != models.WalletStatusDormant {
+			if err = wallet.CheckDebitable(); err != nil {
+				return err
+			}
+		}
+
+		updatedWallet, err = s.closeWallet(ctx, *wallet, ownerID, request)
+
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return updatedWallet, nil
+}
+
+// CloseAnyWallet closes a wallet of any owner on behalf of staff, frozen wallets included.
+func (s *Service) CloseAnyWallet(ctx context.Context, id, actorID uuid.UUID, request models.WalletStatusRequest) (
+	*models.Wallet, error,
+) {
+	var updatedWallet *models.Wallet
+
+	if err := s.db.DoWithTx(ctx, func(ctx context.Context) error {
+		if err := s.lockSweptWallets(ctx, id, request); err != nil {
+			return err
+		}
+
+		wallet, err := s.db.GetAnyWalletByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("s.db.GetAnyWalletByID(id) err: %w", err)
+		}
+
+		if wallet.Deleted {
+			return models.ErrWalletNotFound
+		}
+
+		updatedWallet, err = s.closeWallet(ctx, *wallet, actorID, request)
+
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.DoWithTx(ctx, func(ctx context.Context) err: %w", err)
+	}
+
+	return updatedWallet, nil
+}
+
+// lockSweptWallets locks the wallet and the one its balance is swept to in the order of their IDs,
+// so that a sweep can't deadlock with a transfer between them. Must be called within a DB transaction.
+func (s *Service) lockSweptWallets(ctx context.Context, id uuid.UUID, request models.WalletStatusRequest) error {
+	if request.SweepTo == nil {
+		return nil
+	}
+
+	if err := s.db.LockWallets(ctx, id, *request.SweepTo); err != nil {
+		return fmt.Errorf("s.db.LockWallets(id, sweepTo) err: %w", err)
+	}
+
+	return nil
+}
+
+// closeWallet closes an empty wallet, or sweeps its balance and pockets to the wallet given by the
+// request first. A wallet with a balance left and nowhere to sweep it is closing instead: money can
+// only leave it until it is empty and closed by another request. Must be called within a DB transaction.
+func (s *Service) closeWallet(
+	ctx context.Context,
+	wallet models.Wallet,
+	actorID uuid.UUID,
+	request models.WalletStatusRequest,
+) (*models.Wallet, error) {
+	if wallet.Status == models.WalletStatusClosed {
+		return &wallet, nil
+	}
+
+	if !wallet.AvailableBalance.Equal(wallet.Balance) {
+		return nil, models.ErrWalletHasHolds
+	}
+
+	pockets, err := s.db.GetPockets(ctx, wallet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetPockets(walletID) err: %w", err)
+	}
+
+	status := models.WalletStatusClosed
+
+	switch {
+	case request.SweepTo != nil:
+		if err = s.sweepWallet(ctx, wallet, pockets, *request.SweepTo); err != nil {
+			return nil, err
+		}
+	case !walletEmpty(wallet, pockets) && wallet.Status == models.WalletStatusClosing:
+		return nil, models.ErrWalletNotEmpty
+	case !walletEmpty(wallet, pockets):
+		status = models.WalletStatusClosing
+	}
+
+	return s.changeWalletStatus(ctx, wallet, status, actorID, request.Reason)
+}
+
+func walletEmpty(wallet models.Wallet, pockets []models.Pocket) bool {
+	for _, pocket := range pockets {
+		if !pocket.Balance.IsZero() {
+			return false
+		}
+	}
+
+	return wallet.Balance.IsZero()
+}
+
+// sweepWallet moves the main balance and every pocket of the wallet to the target wallet, converting
+// them into its currency. Sweeps bear no FX fee, so that nothing is left behind.
+func (s *Service) sweepWallet(ctx context.Context, wallet models.Wallet, pockets []models.Pocket, targetID uuid.UUID) error {
+	if targetID == wallet.ID {
+		return models.ErrInvalidSweepTarget
+	}
+
+	target, err := s.db.GetTransferTargetWallet(ctx, targetID)
+	if err != nil {
+		return fmt.Errorf("s.db.GetTransferTargetWallet(targetID) err: %w", err)
+	}
+
+	if err = target.CheckActive(); err != nil {
+		return err
+	}
+
+	balances := append([]models.Pocket{{Currency: wallet.Currency, Balance: wallet.Balance}}, pockets...)
+
+	for _, balance := range balances {
+		if balance.Balance.Sign() <= 0 {
+			continue
+		}
+
+		transaction := models.Transaction{
+			TransactionID:   uuid.New(),
+			WalletID:        wallet.ID,
+			TargetWalletID:  target.ID,
+			TargetOwnerID:   target.Owner,
+			Amount:          balance.Balance,
+			Currency:        balance.Currency,
+			OperationType:   models.OperationSweep,
+			Pocket:          wallet.PocketOf(balance.Currency),
+			TargetCurrency:  target.Currency,
+			BalanceCurrency: balance.Currency,
+		}
+
+		conversion, err := s.convertTransaction(ctx, transaction, wallet.Owner, balance.Currency, target.Currency)
+		if err != nil {
+			return err
+		}
+
+		transaction.ApplyConversion(*conversion)
+
+		executedTransaction, err := s.db.Transfer(ctx, transaction, wallet.Owner)
+		if err != nil {
+			return fmt.Errorf("s.db.Transfer(sweep) err: %w", err)
+		}
+
+		if err = s.savePostings(ctx, movementPostings(
+			executedTransaction.TransactionID,
+			wallet.ID, transaction.Amount, balance.Currency,
+			target.ID, transaction.ConvertedAmount, target.Currency,
+		)); err != nil {
+			return err
+		}
+
+		if err = s.saveTransferEvents(ctx, *executedTransaction); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// changeWalletStatus moves the wallet to the status and records who did it and why. Setting the
+// status the wallet already has changes nothing. Must be called within a DB transaction.
+func (s *Service) changeWalletStatus(
+	ctx context.Context,
+	wallet models.Wallet,
+	status string,
+	actorID uuid.UUID,
+	reason string,
+) (*models.Wallet, error) {
+	if wallet.Status == status {
+		return &wallet, nil
+	}
+
+	if !wallet.CanChangeStatus(status) {
+		return nil, fmt.Errorf("%w: from %s to %s", models.ErrInvalidStatusTransition, wallet.Status, status)
+	}
+
+	updatedWallet, err := s.db.SetWalletStatus(ctx, wallet.ID, status)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.SetWalletStatus(id) err: %w", err)
+	}
+
+	if err = s.db.SaveWalletStatusChange(ctx, models.WalletStatusChange{
+		ID:         uuid.New(),
+		WalletID:   wallet.ID,
+		StatusFrom: wallet.Status,
+		StatusTo:   status,
+		ChangedBy:  actorID,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}); err != nil {
+		return nil, fmt.Errorf("s.db.SaveWalletStatusChange() err: %w", err)
+	}
+
+	return updatedWallet, nil
+}
+
+// GetWalletStatusChanges returns the status history of the wallet, which must belong to the owner.
+func (s *Service) GetWalletStatusChanges(ctx context.Context, id, ownerID uuid.UUID) ([]*models.WalletStatusChange, error) {
+	if _, err := s.db.GetWalletByID(ctx, id, ownerID); err != nil {
+		return nil, fmt.Errorf("s.db.GetWalletByID(id) err: %w", err)
+	}
+
+	return s.getWalletStatusChanges(ctx, id)
+}
+
+// GetAnyWalletStatusChanges returns the status history of a wallet of any owner.
+func (s *Service) GetAnyWalletStatusChanges(ctx context.Context, id uuid.UUID) ([]*models.WalletStatusChange, error) {
+	if _, err := s.db.GetAnyWalletByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("s.db.GetAnyWalletByID(id) err: %w", err)
+	}
+
+	return s.getWalletStatusChanges(ctx, id)
+}
+
+func (s *Service) getWalletStatusChanges(ctx context.Context, id uuid.UUID) ([]*models.WalletStatusChange, error) {
+	changes, err := s.db.GetWalletStatusChanges(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("s.db.GetWalletStatusChanges(id) err: %w", err)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetUserByLogin finds a user by email or phone, preferring the email. Usernames aren't unique,
+// so they can't be used to log in.
+func (p *Postgres) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+	var user models.User
+
+	query := `	SELECT id, name, email, phone, password, roles, created_at, deleted
+				FROM users
+				WHERE (email = $1 or phone = $1) and deleted = false
+				ORDER BY email = $1 DESC
+				LIMIT 1`
+
+	err := p.conn(ctx).QueryRow(ctx, query, login).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Phone,
+		&user.Password,
+		&user.Roles,
+		&user.CreatedAt,
+		&user.Deleted,
+	)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrUserNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting user by login error: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (p *Postgres) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+
+	query := `	SELECT id, name, email, phone, password, roles, tier, created_at, deleted
+				FROM users
+				WHERE id = $1 and deleted = false`
+
+	err := p.conn(ctx).QueryRow(ctx, query, id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Phone,
+		&user.Password,
+		&user.Roles,
+		&user.Tier,
+		&user.CreatedAt,
+		&user.Deleted,
+	)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrUserNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting user by id error: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (p *Postgres) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	query := `INSERT INTO refresh_tokens (id, session_id, user_id, token_hash, expires_at, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := p.conn(ctx).Exec(
+		ctx,
+		query,
+		token.ID,
+		token.SessionID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt,
+		token.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("saving refresh token error: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+
+	query := `	SELECT id, session_id, user_id, token_hash, expires_at, created_at, rotated_at, revoked_at
+				FROM refresh_tokens
+				WHERE token_hash = $1`
+
+	if p.getTxFromCtx(ctx) != nil {
+		query += ` FOR UPDATE`
+	}
+
+	err := p.conn(ctx).QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.SessionID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.RotatedAt,
+		&token.RevokedAt,
+	)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrInvalidRefreshToken
+	case err != nil:
+		return nil, fmt.Errorf("getting refresh token error: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (p *Postgres) RotateRefreshToken(ctx context.Context, id uuid.UUID, rotatedAt time.Time) error {
+	query := `UPDATE refresh_tokens SET rotated_at = $2 WHERE id = $1`
+
+	if _, err := p.conn(ctx).Exec(ctx, query, id, rotatedAt); err != nil {
+		return fmt.Errorf("rotating refresh token error: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeSession revokes all refresh tokens of the session.
+func (p *Postgres) RevokeSession(ctx context.Context, sessionID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE session_id = $1 and revoked_at is null`
+
+	if _, err := p.conn(ctx).Exec(ctx, query, sessionID, time.Now()); err != nil {
+		return fmt.Errorf("revoking session error: %w", err)
+	}
+
+	return nil
+}
+
+// IsSessionActive reports whether the session has a refresh token that is neither revoked nor expired.
+func (p *Postgres) IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	var active bool
+
+	query := `	SELECT exists(
+					SELECT 1 FROM refresh_tokens
+					WHERE session_id = $1 and revoked_at is null and expires_at > $2
+				)`
+
+	if err := p.conn(ctx).QueryRow(ctx, query, sessionID, time.Now()).Scan(&active); err != nil {
+		return false, fmt.Errorf("checking session error: %w", err)
+	}
+
+	return active, nil
+}
+
+func (p *Postgres) CleanRefreshTokens(ctx context.Context, expiredBefore time.Time) error {
+	query := `DELETE FROM refresh_tokens WHERE expires_at < $1`
+
+	_, err := p.db.Exec(ctx, query, expiredBefore)
+	if err != nil {
+		return fmt.Errorf("cleanRefreshTokens(): p.db.Exec(ctx, query, time) err: %w", err)
+	}
+
+	return nil
+}
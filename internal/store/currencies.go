@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const currencyColumns = `code, iso_code, numeric_code, minor_units, provider_ids, enabled, created_at, updated_at`
+
+func (p *Postgres) GetCurrencies(ctx context.Context) ([]*models.Currency, error) {
+	query := `SELECT ` + currencyColumns + ` FROM currencies ORDER BY code`
+
+	rows, err := p.conn(ctx).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("getting currencies error: %w", err)
+	}
+
+	defer rows.Close()
+
+	currencies := make([]*models.Currency, 0)
+
+	for rows.Next() {
+		currency, err := scanCurrency(rows)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		currencies = append(currencies, currency)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return currencies, nil
+}
+
+func (p *Postgres) GetCurrency(ctx context.Context, code string) (*models.Currency, error) {
+	query := `SELECT ` + currencyColumns + ` FROM currencies WHERE code = $1`
+
+	if p.getTxFromCtx(ctx) != nil {
+		query += ` FOR UPDATE`
+	}
+
+	currency, err := scanCurrency(p.conn(ctx).QueryRow(ctx, query, code))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrCurrencyNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting currency error: %w", err)
+	}
+
+	return currency, nil
+}
+
+func (p *Postgres) CreateCurrency(ctx context.Context, currency models.Currency) (*models.Currency, error) {
+	query := `INSERT INTO currencies (code, iso_code, numeric_code, minor_units, provider_ids, enabled, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+				RETURNING ` + currencyColumns
+
+	createdCurrency, err := scanCurrency(p.conn(ctx).QueryRow(
+		ctx,
+		query,
+		currency.Code,
+		currency.ISOCode,
+		currency.NumericCode,
+		currency.MinorUnits,
+		providerIDs(currency),
+		currency.Enabled,
+		time.Now(),
+	))
+
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation:
+		return nil, models.ErrCurrencyExists
+	case err != nil:
+		return nil, fmt.Errorf("creating currency error: %w", err)
+	}
+
+	return createdCurrency, nil
+}
+
+// UpdateCurrency saves the changes of the currency; its minor units never change.
+func (p *Postgres) UpdateCurrency(ctx context.Context, currency models.Currency) (*models.Currency, error) {
+	query := `UPDATE currencies SET iso_code = $2, numeric_code = $3, provider_ids = $4, enabled = $5, updated_at = $6
+				WHERE code = $1
+				RETURNING ` + currencyColumns
+
+	updatedCurrency, err := scanCurrency(p.conn(ctx).QueryRow(
+		ctx,
+		query,
+		currency.Code,
+		currency.ISOCode,
+		currency.NumericCode,
+		providerIDs(currency),
+		currency.Enabled,
+		time.Now(),
+	))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrCurrencyNotFound
+	case err != nil:
+		return nil, fmt.Errorf("updating currency error: %w", err)
+	}
+
+	return updatedCurrency, nil
+}
+
+// providerIDs keeps the column an object when the currency has no provider ids.
+func providerIDs(currency models.Currency) map[string]string {
+	if currency.ProviderIDs == nil {
+		return map[string]string{}
+	}
+
+	return currency.ProviderIDs
+}
+
+func scanCurrency(row pgx.Row) (*models.Currency, error) {
+	var currency models.Currency
+
+	err := row.Scan(
+		&currency.Code,
+		&currency.ISOCode,
+		&currency.NumericCode,
+		&currency.MinorUnits,
+		&currency.ProviderIDs,
+		&currency.Enabled,
+		&currency.CreatedAt,
+		&currency.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return &currency, nil
+}
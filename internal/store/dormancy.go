@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+// dormancyCandidates matches active wallets inactive since $1 that are empty and not savings
+// wallets, so that no money is ever locked away by the dormancy policy.
+const dormancyCandidates = `w.deleted = false and w.status = $2 and w.savings = false
+					and w.balance = 0 and w.held = 0 and w.updated_at < $1
+					and NOT EXISTS (SELECT 1 FROM wallet_pockets wp WHERE wp.wallet_id = w.id and wp.balance <> 0)`
+
+// GetWalletsToWarn returns up to limit wallets inactive since inactiveBefore whose owners were not
+// warned about dormancy after their last activity, the longest inactive first.
+func (p *Postgres) GetWalletsToWarn(ctx context.Context, inactiveBefore time.Time, limit int) ([]*models.Wallet, error) {
+	query := `	SELECT ` + walletColumns + `
+				FROM wallets w
+				WHERE ` + dormancyCandidates + `
+					and (w.dormancy_warned_at IS NULL or w.dormancy_warned_at < w.updated_at)
+				ORDER BY w.updated_at
+				LIMIT $3`
+
+	return p.getDormancyCandidates(ctx, query, inactiveBefore, models.WalletStatusActive, limit)
+}
+
+// GetWalletsToMakeDormant returns up to limit wallets inactive since inactiveBefore whose owners
+// were warned about dormancy after their last activity and before warnedBefore.
+func (p *Postgres) GetWalletsToMakeDormant(
+	ctx context.Context,
+	inactiveBefore, warnedBefore time.Time,
+	limit int,
+) ([]*models.Wallet, error) {
+	query := `	SELECT ` + walletColumns + `
+				FROM wallets w
+				WHERE ` + dormancyCandidates + `
+					and w.dormancy_warned_at >= w.updated_at and w.dormancy_warned_at < $3
+				ORDER BY w.updated_at
+				LIMIT $4`
+
+	return p.getDormancyCandidates(ctx, query, inactiveBefore, models.WalletStatusActive, warnedBefore, limit)
+}
+
+// getDormancyCandidates locks the wallets it returns when called within a transaction, skipping the
+// ones locked by operations or by another instance.
+func (p *Postgres) getDormancyCandidates(ctx context.Context, query string, args ...any) ([]*models.Wallet, error) {
+	if p.getTxFromCtx(ctx) != nil {
+		query += ` FOR UPDATE SKIP LOCKED`
+	}
+
+	rows, err := p.conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("getting dormancy candidates error: %w", err)
+	}
+
+	defer rows.Close()
+
+	wallets := make([]*models.Wallet, 0)
+
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		wallets = append(wallets, wallet)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return wallets, nil
+}
+
+// MarkDormancyWarned records that the owner of the wallet was warned about its dormancy. It leaves
+// updated_at alone, since a warning is not an activity of the wallet.
+func (p *Postgres) MarkDormancyWarned(ctx context.Context, id uuid.UUID, warnedAt time.Time) error {
+	query := `UPDATE wallets SET dormancy_warned_at = $2 WHERE id = $1`
+
+	result, err := p.conn(ctx).Exec(ctx, query, id, warnedAt)
+
+	switch {
+	case err != nil:
+		return fmt.Errorf("marking dormancy warning error: %w", err)
+	case result.RowsAffected() == 0:
+		return models.ErrWalletNotFound
+	}
+
+	return nil
+}
+
+func (p *Postgres) SaveDormancyRun(ctx context.Context, run models.DormancyRun) error {
+	query := `INSERT INTO dormancy_runs (id, started_at) VALUES ($1, $2)`
+
+	if _, err := p.conn(ctx).Exec(ctx, query, run.ID, run.StartedAt); err != nil {
+		return fmt.Errorf("saving dormancy run err: %w", err)
+	}
+
+	query = `INSERT INTO dormancy_run_wallets (run_id, wallet_id, owner_id, action, last_activity_at)
+				VALUES ($1, $2, $3, $4, $5)`
+
+	for _, wallet := range run.Wallets {
+		_, err := p.conn(ctx).Exec(ctx, query, run.ID, wallet.WalletID, wallet.OwnerID, wallet.Action, wallet.LastActivityAt)
+		if err != nil {
+			return fmt.Errorf("saving dormancy run wallet err: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetDormancyRuns returns the runs of the dormancy policy with the wallets they affected, the
+// latest first.
+func (p *Postgres) GetDormancyRuns(ctx context.Context, params models.Params) ([]*models.DormancyRun, error) {
+	query := `	SELECT r.id, r.started_at, rw.wallet_id, rw.owner_id, rw.action, rw.last_activity_at
+				FROM (
+					SELECT id, started_at
+					FROM dormancy_runs
+					ORDER BY started_at DESC
+					LIMIT $1 OFFSET $2
+				) r
+				JOIN dormancy_run_wallets rw ON rw.run_id = r.id
+				ORDER BY r.started_at DESC, r.id, rw.action, rw.last_activity_at`
+
+	rows, err := p.conn(ctx).Query(ctx, query, params.Limit, params.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("getting dormancy runs error: %w", err)
+	}
+
+	defer rows.Close()
+
+	runs := make([]*models.DormancyRun, 0)
+
+	for rows.Next() {
+		var (
+			run    models.DormancyRun
+			wallet models.DormancyRunWallet
+		)
+
+		if err = rows.Scan(
+			&run.ID,
+			&run.StartedAt,
+			&wallet.WalletID,
+			&wallet.OwnerID,
+			&wallet.Action,
+			&wallet.LastActivityAt,
+		); err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		if len(runs) == 0 || runs[len(runs)-1].ID != run.ID {
+			runs = append(runs, &run)
+		}
+
+		last := runs[len(runs)-1]
+		last.Wallets = append(last.Wallets, wallet)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return runs, nil
+}
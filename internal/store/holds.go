@@ -0,0 +1,247 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	log "github.com/sirupsen/logrus"
+)
+
+const holdColumns = `id, wallet_id, owner_id, amount, currency, converted_amount, ex_rate, rate_source, rate_date,
+				captured_amount, transaction_id, status, expires_at, created_at, updated_at`
+
+// CreateHold reserves the converted amount of the hold on the wallet.
+func (p *Postgres) CreateHold(ctx context.Context, hold models.Hold) (*models.Hold, error) {
+	tx, err := p.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Warnf("create hold tx.Rollback(ctx) err: %v", err)
+		}
+	}()
+
+	if err = p.updateWalletHeld(ctx, tx, hold.WalletID, hold.OwnerID, hold.ConvertedAmount); err != nil {
+		return nil, err
+	}
+
+	timeNow := time.Now()
+
+	query := `INSERT INTO holds (id, wallet_id, owner_id, amount, currency, converted_amount, ex_rate,
+                   rate_source, rate_date, status, expires_at, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+				RETURNING ` + holdColumns
+
+	createdHold, err := scanHold(tx.QueryRow(
+		ctx,
+		query,
+		hold.ID,
+		hold.WalletID,
+		hold.OwnerID,
+		hold.Amount,
+		hold.Currency,
+		hold.ConvertedAmount,
+		hold.ExRate,
+		hold.RateSource,
+		hold.RateDate,
+		models.HoldStatusActive,
+		hold.ExpiresAt,
+		timeNow,
+		timeNow,
+	))
+
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation:
+		return nil, models.ErrDuplicateHold
+	case err != nil:
+		return nil, fmt.Errorf("creating hold error: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("transaction commit err: %w", err)
+	}
+
+	return createdHold, nil
+}
+
+func (p *Postgres) GetHoldByID(ctx context.Context, id, ownerID uuid.UUID) (*models.Hold, error) {
+	query := `	SELECT ` + holdColumns + `
+				FROM holds
+				WHERE id = $1 and owner_id = $2`
+
+	if p.getTxFromCtx(ctx) != nil {
+		query += ` FOR UPDATE`
+	}
+
+	hold, err := scanHold(p.conn(ctx).QueryRow(ctx, query, id, ownerID))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrHoldNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting hold by id error: %w", err)
+	}
+
+	return hold, nil
+}
+
+// CaptureHold withdraws the captured part of the hold and releases the whole reservation.
+func (p *Postgres) CaptureHold(ctx context.Context, hold models.Hold, transaction models.Transaction) (*models.Transaction, error) {
+	tx, err := p.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Warnf("capture hold tx.Rollback(ctx) err: %v", err)
+		}
+	}()
+
+	if err = p.updateWalletHeld(ctx, tx, hold.WalletID, hold.OwnerID, hold.ConvertedAmount.Neg()); err != nil {
+		return nil, err
+	}
+
+	err = p.updateWalletBalance(ctx, tx, hold.WalletID, hold.OwnerID, "", transaction.ConvertedAmount.Neg())
+
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound):
+		return nil, models.ErrWalletNotFound
+	case errors.Is(err, models.ErrBalanceBelowZero):
+		return nil, models.ErrBalanceBelowZero
+	case err != nil:
+		return nil, fmt.Errorf("p.updateWalletBalance(ctx) err: %w", err)
+	}
+
+	query := `UPDATE holds SET status = $2, captured_amount = $3, transaction_id = $4, updated_at = $5 WHERE id = $1`
+
+	if _, err = tx.Exec(ctx, query, hold.ID, models.HoldStatusCaptured, transaction.Amount, transaction.TransactionID, time.Now()); err != nil {
+		return nil, fmt.Errorf("capturing hold error: %w", err)
+	}
+
+	executedTransaction, err := saveTransaction(ctx, tx, transaction, hold.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("transaction commit err: %w", err)
+	}
+
+	return executedTransaction, nil
+}
+
+// VoidHold releases the reservation without moving money.
+func (p *Postgres) VoidHold(ctx context.Context, hold models.Hold) (*models.Hold, error) {
+	tx, err := p.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Warnf("void hold tx.Rollback(ctx) err: %v", err)
+		}
+	}()
+
+	if err = p.updateWalletHeld(ctx, tx, hold.WalletID, hold.OwnerID, hold.ConvertedAmount.Neg()); err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE holds SET status = $2, updated_at = $3 WHERE id = $1 RETURNING ` + holdColumns
+
+	voidedHold, err := scanHold(tx.QueryRow(ctx, query, hold.ID, models.HoldStatusVoided, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("voiding hold error: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("transaction commit err: %w", err)
+	}
+
+	return voidedHold, nil
+}
+
+// ExpireHolds releases active holds that expired before the given time and returns how many were expired.
+func (p *Postgres) ExpireHolds(ctx context.Context, before time.Time) (int64, error) {
+	query := `	WITH expired AS (
+					UPDATE holds SET status = $2, updated_at = $1
+					WHERE status = $3 and expires_at <= $1
+					RETURNING wallet_id, converted_amount
+				), released AS (
+					UPDATE wallets w SET held = w.held - e.amount
+					FROM (SELECT wallet_id, sum(converted_amount) AS amount FROM expired GROUP BY wallet_id) e
+					WHERE w.id = e.wallet_id
+				)
+				SELECT count(*) FROM expired`
+
+	var expired int64
+
+	err := p.db.QueryRow(ctx, query, before, models.HoldStatusExpired, models.HoldStatusActive).Scan(&expired)
+	if err != nil {
+		return 0, fmt.Errorf("expiring holds error: %w", err)
+	}
+
+	return expired, nil
+}
+
+func (p *Postgres) updateWalletHeld(ctx context.Context, tx pgx.Tx, walletID, ownerID uuid.UUID, amount models.Decimal) error {
+	query := `	UPDATE wallets SET held = held + $3
+                WHERE id = $1 and owner = $2 and deleted = false`
+
+	result, err := tx.Exec(ctx, query, walletID, ownerID, amount)
+
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.CheckViolation:
+		return models.ErrBalanceBelowZero
+	case err != nil:
+		return fmt.Errorf("updating wallet held amount error: %w", err)
+	case result.RowsAffected() == 0:
+		return models.ErrWalletNotFound
+	}
+
+	return nil
+}
+
+func scanHold(row pgx.Row) (*models.Hold, error) {
+	var hold models.Hold
+
+	err := row.Scan(
+		&hold.ID,
+		&hold.WalletID,
+		&hold.OwnerID,
+		&hold.Amount,
+		&hold.Currency,
+		&hold.ConvertedAmount,
+		&hold.ExRate,
+		&hold.RateSource,
+		&hold.RateDate,
+		&hold.CapturedAmount,
+		&hold.TransactionID,
+		&hold.Status,
+		&hold.ExpiresAt,
+		&hold.CreatedAt,
+		&hold.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return &hold, nil
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func saveIdempotencyKey(ctx context.Context, tx pgx.Tx, transaction models.Transaction, ownerID uuid.UUID) error {
+	if transaction.IdempotencyKey == "" {
+		return nil
+	}
+
+	query := `INSERT INTO idempotency_keys (owner_id, key, request_hash, transaction_id, created_at)
+				VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		ownerID,
+		transaction.IdempotencyKey,
+		transaction.RequestHash(),
+		transaction.TransactionID,
+		time.Now(),
+	)
+
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation:
+		return models.ErrDuplicateIdempotencyKey
+	case err != nil:
+		return fmt.Errorf("saving idempotency key err: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) GetIdempotencyKey(ctx context.Context, ownerID uuid.UUID, key string, createdAfter time.Time) (
+	*models.IdempotencyKey, error,
+) {
+	var idempotencyKey models.IdempotencyKey
+
+	query := `	SELECT owner_id, key, request_hash, transaction_id, created_at
+				FROM idempotency_keys
+				WHERE owner_id = $1 and key = $2 and created_at > $3`
+
+	err := p.conn(ctx).QueryRow(ctx, query, ownerID, key, createdAfter).Scan(
+		&idempotencyKey.OwnerID,
+		&idempotencyKey.Key,
+		&idempotencyKey.RequestHash,
+		&idempotencyKey.TransactionID,
+		&idempotencyKey.CreatedAt,
+	)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrIdempotencyKeyNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting idempotency key err: %w", err)
+	}
+
+	return &idempotencyKey, nil
+}
+
+// DeleteExpiredIdempotencyKey deletes the key of the owner if it was created before the given time,
+// so that it can be used again before the cleaner gets to it.
+func (p *Postgres) DeleteExpiredIdempotencyKey(ctx context.Context, ownerID uuid.UUID, key string, createdBefore time.Time) error {
+	query := `DELETE FROM idempotency_keys WHERE owner_id = $1 and key = $2 and created_at <= $3`
+
+	_, err := p.conn(ctx).Exec(ctx, query, ownerID, key, createdBefore)
+	if err != nil {
+		return fmt.Errorf("deleting expired idempotency key err: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) CleanIdempotencyKeys(ctx context.Context, createdBefore time.Time) error {
+	query := `DELETE FROM idempotency_keys WHERE created_at < $1`
+
+	_, err := p.db.Exec(ctx, query, createdBefore)
+	if err != nil {
+		return fmt.Errorf("cleanIdempotencyKeys(): p.db.Exec(ctx, query, time) err: %w", err)
+	}
+
+	return nil
+}
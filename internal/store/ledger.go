@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+func (p *Postgres) SavePostings(ctx context.Context, postings []models.Posting) error {
+	query := `INSERT INTO ledger_postings (transaction_id, account_id, amount, currency, created_at)
+				VALUES ($1, $2, $3, $4, $5)`
+
+	timeNow := time.Now()
+
+	for _, posting := range postings {
+		_, err := p.conn(ctx).Exec(
+			ctx,
+			query,
+			posting.TransactionID,
+			posting.AccountID,
+			posting.Amount,
+			posting.Currency,
+			timeNow,
+		)
+		if err != nil {
+			return fmt.Errorf("saving posting err: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetAccountBalance returns the sum of the postings of the ledger account in the currency.
+func (p *Postgres) GetAccountBalance(ctx context.Context, accountID uuid.UUID, currency string) (models.Decimal, error) {
+	var balance models.Decimal
+
+	query := `	SELECT coalesce(sum(amount), 0)
+				FROM ledger_postings
+				WHERE account_id = $1 and currency = $2`
+
+	if err := p.conn(ctx).QueryRow(ctx, query, accountID, currency).Scan(&balance); err != nil {
+		return models.Decimal{}, fmt.Errorf("getting account balance error: %w", err)
+	}
+
+	return balance, nil
+}
+
+// GetLedgerReport returns currencies whose postings don't sum to zero and wallets whose
+// balance in a currency differs from the sum of their postings.
+func (p *Postgres) GetLedgerReport(ctx context.Context) (*models.LedgerReport, error) {
+	report := models.LedgerReport{
+		CurrencyImbalances:  make([]models.CurrencyImbalance, 0),
+		WalletDiscrepancies: make([]models.WalletDiscrepancy, 0),
+	}
+
+	query := `	SELECT currency, sum(amount)
+				FROM ledger_postings
+				GROUP BY currency
+				HAVING sum(amount) <> 0`
+
+	rows, err := p.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("p.db.Query err: %w", err)
+	}
+
+	for rows.Next() {
+		var imbalance models.CurrencyImbalance
+
+		if err = rows.Scan(&imbalance.Currency, &imbalance.Sum); err != nil {
+			rows.Close()
+
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		report.CurrencyImbalances = append(report.CurrencyImbalances, imbalance)
+	}
+
+	rows.Close()
+
+	// The pockets of multi-currency wallets are reconciled like the main balances.
+	query = `	SELECT b.wallet_id, b.currency, b.balance, coalesce(sum(lp.amount), 0)
+				FROM (
+					SELECT id AS wallet_id, currency, balance FROM wallets
+					UNION ALL
+					SELECT wallet_id, currency, balance FROM wallet_pockets
+				) b
+				LEFT JOIN ledger_postings lp ON lp.account_id = b.wallet_id and lp.currency = b.currency
+				GROUP BY b.wallet_id, b.currency, b.balance
+				HAVING b.balance <> coalesce(sum(lp.amount), 0)`
+
+	rows, err = p.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("p.db.Query err: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var discrepancy models.WalletDiscrepancy
+
+		err = rows.Scan(
+			&discrepancy.WalletID,
+			&discrepancy.Currency,
+			&discrepancy.Balance,
+			&discrepancy.LedgerBalance,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		report.WalletDiscrepancies = append(report.WalletDiscrepancies, discrepancy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return &report, nil
+}
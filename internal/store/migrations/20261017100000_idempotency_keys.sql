@@ -0,0 +1,15 @@
+-- +migrate Up
+
+CREATE TABLE idempotency_keys (
+    owner_id uuid not null references users (id),
+    key varchar not null,
+    request_hash varchar not null,
+    transaction_id uuid not null,
+    created_at timestamp not null,
+    primary key (owner_id, key)
+);
+
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys (created_at);
+-- +migrate Down
+
+DROP TABLE idempotency_keys;
@@ -0,0 +1,18 @@
+-- +migrate Up
+
+CREATE TABLE outbox (
+    id bigserial primary key,
+    topic varchar not null,
+    key varchar not null,
+    payload jsonb not null,
+    created_at timestamp not null,
+    attempts int not null default 0,
+    last_error varchar,
+    next_attempt_at timestamp not null,
+    published_at timestamp
+);
+
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at) WHERE published_at IS NULL;
+-- +migrate Down
+
+DROP TABLE outbox;
@@ -0,0 +1,66 @@
+-- +migrate Up
+
+CREATE TABLE ledger_accounts (
+    id uuid not null primary key,
+    wallet_id uuid unique,
+    code varchar unique,
+    created_at timestamp not null,
+    check ( (wallet_id IS NULL) <> (code IS NULL) )
+);
+
+CREATE TABLE ledger_postings (
+    id bigserial primary key,
+    transaction_id uuid not null,
+    account_id uuid not null references ledger_accounts (id),
+    amount numeric not null,
+    currency varchar not null,
+    created_at timestamp not null
+);
+
+CREATE INDEX ledger_postings_transaction_id_idx ON ledger_postings (transaction_id);
+CREATE INDEX ledger_postings_account_id_idx ON ledger_postings (account_id, currency);
+
+INSERT INTO ledger_accounts (id, code, created_at) VALUES
+    ('00000000-0000-0000-0000-000000000001', 'cash_in', now()),
+    ('00000000-0000-0000-0000-000000000002', 'cash_out', now()),
+    ('00000000-0000-0000-0000-000000000003', 'fx_clearing', now()),
+    ('00000000-0000-0000-0000-000000000004', 'opening_balance', now());
+
+INSERT INTO ledger_accounts (id, wallet_id, created_at)
+SELECT id, id, created_at FROM wallets;
+
+INSERT INTO ledger_postings (transaction_id, account_id, amount, currency, created_at)
+SELECT opening.transaction_id, posting.account_id, posting.amount, opening.currency, now()
+FROM (SELECT gen_random_uuid() AS transaction_id, id, balance, currency FROM wallets WHERE balance <> 0) AS opening
+CROSS JOIN LATERAL (VALUES
+    (opening.id, opening.balance),
+    ('00000000-0000-0000-0000-000000000004'::uuid, -opening.balance)
+) AS posting (account_id, amount);
+
+-- +migrate StatementBegin
+CREATE FUNCTION check_ledger_transaction_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM ledger_postings
+        WHERE transaction_id = NEW.transaction_id
+        GROUP BY currency
+        HAVING sum(amount) <> 0
+    ) THEN
+        RAISE EXCEPTION 'ledger transaction % is not balanced', NEW.transaction_id
+            USING ERRCODE = 'check_violation';
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced
+    AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_ledger_transaction_balanced();
+-- +migrate Down
+
+DROP TRIGGER ledger_postings_balanced ON ledger_postings;
+DROP FUNCTION check_ledger_transaction_balanced();
+DROP TABLE ledger_postings, ledger_accounts;
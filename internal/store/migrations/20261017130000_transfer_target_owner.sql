@@ -0,0 +1,15 @@
+-- +migrate Up
+
+ALTER TABLE transactions_history
+    ADD COLUMN target_owner_id uuid not null DEFAULT '00000000-0000-0000-0000-000000000000';
+
+UPDATE transactions_history th
+SET target_owner_id = w.owner
+FROM wallets w
+WHERE w.id = th.target_wallet_id;
+
+CREATE INDEX transactions_history_target_wallet_id_idx ON transactions_history (target_wallet_id);
+-- +migrate Down
+
+DROP INDEX transactions_history_target_wallet_id_idx;
+ALTER TABLE transactions_history DROP COLUMN target_owner_id;
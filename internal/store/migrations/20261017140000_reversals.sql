@@ -0,0 +1,16 @@
+-- +migrate Up
+
+ALTER TABLE transactions_history
+    ADD COLUMN ex_rate numeric,
+    ADD COLUMN original_transaction_id uuid not null DEFAULT '00000000-0000-0000-0000-000000000000';
+
+UPDATE transactions_history
+SET ex_rate = converted_amount / amount
+WHERE converted_amount IS NOT NULL and amount <> 0;
+
+CREATE INDEX transactions_history_original_transaction_id_idx ON transactions_history (original_transaction_id)
+    WHERE original_transaction_id <> '00000000-0000-0000-0000-000000000000';
+-- +migrate Down
+
+DROP INDEX transactions_history_original_transaction_id_idx;
+ALTER TABLE transactions_history DROP COLUMN ex_rate, DROP COLUMN original_transaction_id;
@@ -0,0 +1,27 @@
+-- +migrate Up
+
+ALTER TABLE wallets
+    ADD COLUMN held numeric not null DEFAULT 0,
+    ADD CONSTRAINT wallets_held_check check ( held >= 0 and held <= balance );
+
+CREATE TABLE holds (
+    id uuid not null primary key,
+    wallet_id uuid not null references wallets (id),
+    owner_id uuid not null references users (id),
+    amount numeric not null,
+    currency varchar not null,
+    converted_amount numeric not null,
+    ex_rate numeric not null,
+    captured_amount numeric not null DEFAULT 0,
+    transaction_id uuid not null DEFAULT '00000000-0000-0000-0000-000000000000',
+    status varchar not null,
+    expires_at timestamp not null,
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+CREATE INDEX holds_active_expires_at_idx ON holds (expires_at) WHERE status = 'active';
+-- +migrate Down
+
+DROP TABLE holds;
+ALTER TABLE wallets DROP CONSTRAINT wallets_held_check, DROP COLUMN held;
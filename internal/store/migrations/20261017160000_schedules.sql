@@ -0,0 +1,41 @@
+-- +migrate Up
+
+CREATE TABLE schedules (
+    id uuid not null primary key,
+    owner_id uuid not null references users (id),
+    name varchar not null,
+    operation_type varchar not null,
+    wallet_id uuid not null references wallets (id),
+    target_wallet_id uuid not null,
+    recipient varchar not null,
+    amount numeric,
+    percent numeric,
+    currency varchar not null,
+    recurrence varchar not null,
+    run_at timestamp not null,
+    cron varchar not null,
+    weekday int,
+    day_of_month int,
+    next_run_at timestamp,
+    active bool not null,
+    deleted bool not null,
+    created_at timestamp not null,
+    updated_at timestamp not null
+);
+
+CREATE INDEX schedules_due_idx ON schedules (next_run_at) WHERE active and not deleted;
+
+CREATE TABLE schedule_runs (
+    id bigserial primary key,
+    schedule_id uuid not null references schedules (id),
+    scheduled_at timestamp not null,
+    executed_at timestamp not null,
+    status varchar not null,
+    transaction_id uuid not null,
+    error varchar not null
+);
+
+CREATE INDEX schedule_runs_schedule_id_idx ON schedule_runs (schedule_id, scheduled_at);
+-- +migrate Down
+
+DROP TABLE schedule_runs, schedules;
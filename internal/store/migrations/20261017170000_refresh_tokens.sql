@@ -0,0 +1,18 @@
+-- +migrate Up
+
+CREATE TABLE refresh_tokens (
+    id uuid not null primary key,
+    session_id uuid not null,
+    user_id uuid not null references users (id),
+    token_hash varchar not null unique,
+    expires_at timestamp not null,
+    created_at timestamp not null,
+    rotated_at timestamp,
+    revoked_at timestamp
+);
+
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens (session_id);
+CREATE INDEX refresh_tokens_expires_at_idx ON refresh_tokens (expires_at);
+-- +migrate Down
+
+DROP TABLE refresh_tokens;
@@ -0,0 +1,8 @@
+-- +migrate Up
+
+ALTER TABLE users ADD COLUMN roles varchar[] not null default '{owner}';
+ALTER TABLE wallets ADD COLUMN status varchar not null default 'active';
+-- +migrate Down
+
+ALTER TABLE wallets DROP COLUMN status;
+ALTER TABLE users DROP COLUMN roles;
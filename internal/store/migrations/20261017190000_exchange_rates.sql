@@ -0,0 +1,15 @@
+-- +migrate Up
+
+CREATE TABLE exchange_rates (
+    source varchar not null,
+    currency varchar not null,
+    rate_date date not null,
+    rate numeric not null check ( rate > 0 ),
+    fetched_at timestamp not null,
+    primary key (source, currency, rate_date)
+);
+
+CREATE INDEX exchange_rates_currency_idx ON exchange_rates (currency, rate_date DESC, fetched_at DESC);
+-- +migrate Down
+
+DROP TABLE exchange_rates;
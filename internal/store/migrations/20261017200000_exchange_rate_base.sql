@@ -0,0 +1,10 @@
+-- +migrate Up
+
+ALTER TABLE exchange_rates ADD COLUMN base varchar not null default 'RUR';
+DROP INDEX exchange_rates_currency_idx;
+CREATE INDEX exchange_rates_source_currency_idx ON exchange_rates (source, currency, rate_date DESC);
+-- +migrate Down
+
+DROP INDEX exchange_rates_source_currency_idx;
+CREATE INDEX exchange_rates_currency_idx ON exchange_rates (currency, rate_date DESC, fetched_at DESC);
+ALTER TABLE exchange_rates DROP COLUMN base;
@@ -0,0 +1,23 @@
+-- +migrate Up
+
+CREATE TABLE quotes (
+    id uuid primary key,
+    owner_id uuid not null references users(id),
+    currency_from varchar not null,
+    currency_to varchar not null,
+    amount numeric not null check ( amount > 0 ),
+    converted_amount numeric not null,
+    rate numeric not null,
+    expires_at timestamp not null,
+    created_at timestamp not null,
+    transaction_id uuid
+);
+
+CREATE INDEX quotes_expires_at_idx ON quotes (expires_at);
+
+ALTER TABLE transactions_history ADD COLUMN quote_id uuid references quotes(id);
+-- +migrate Down
+
+ALTER TABLE transactions_history DROP COLUMN quote_id;
+
+DROP TABLE quotes;
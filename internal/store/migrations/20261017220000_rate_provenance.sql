@@ -0,0 +1,20 @@
+-- +migrate Up
+
+ALTER TABLE transactions_history ADD COLUMN rate_source varchar not null default '';
+ALTER TABLE transactions_history ADD COLUMN rate_date date;
+
+ALTER TABLE holds ADD COLUMN rate_source varchar not null default '';
+ALTER TABLE holds ADD COLUMN rate_date date;
+
+ALTER TABLE quotes ADD COLUMN rate_source varchar not null default '';
+ALTER TABLE quotes ADD COLUMN rate_date date not null default current_date;
+-- +migrate Down
+
+ALTER TABLE quotes DROP COLUMN rate_date;
+ALTER TABLE quotes DROP COLUMN rate_source;
+
+ALTER TABLE holds DROP COLUMN rate_date;
+ALTER TABLE holds DROP COLUMN rate_source;
+
+ALTER TABLE transactions_history DROP COLUMN rate_date;
+ALTER TABLE transactions_history DROP COLUMN rate_source;
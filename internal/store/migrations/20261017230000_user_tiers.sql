@@ -0,0 +1,6 @@
+-- +migrate Up
+
+ALTER TABLE users ADD COLUMN tier varchar not null default 'standard';
+-- +migrate Down
+
+ALTER TABLE users DROP COLUMN tier;
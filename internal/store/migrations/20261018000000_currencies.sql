@@ -0,0 +1,23 @@
+-- +migrate Up
+
+CREATE TABLE currencies (
+    code varchar primary key,
+    iso_code varchar not null,
+    numeric_code int not null,
+    minor_units int not null,
+    provider_ids jsonb not null default '{}',
+    enabled boolean not null default true,
+    created_at timestamp not null default now(),
+    updated_at timestamp not null default now()
+);
+
+INSERT INTO currencies (code, iso_code, numeric_code, minor_units, provider_ids) VALUES
+    ('RUR', 'RUB', 643, 2, '{}'),
+    ('CHY', 'CNY', 156, 2, '{"cbr": "R01375"}'),
+    ('AED', 'AED', 784, 2, '{"cbr": "R01230"}'),
+    ('INR', 'INR', 356, 2, '{"cbr": "R01270"}'),
+    ('EUR', 'EUR', 978, 2, '{"cbr": "R01239"}'),
+    ('USD', 'USD', 840, 2, '{"cbr": "R01235"}');
+-- +migrate Down
+
+DROP TABLE currencies;
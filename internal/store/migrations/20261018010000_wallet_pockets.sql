@@ -0,0 +1,23 @@
+-- +migrate Up
+
+ALTER TABLE wallets ADD COLUMN multi_currency boolean not null default false;
+
+CREATE TABLE wallet_pockets (
+    wallet_id uuid not null references wallets(id),
+    currency varchar not null,
+    balance numeric not null check ( balance >= 0 ),
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    primary key (wallet_id, currency)
+);
+
+ALTER TABLE transactions_history ADD COLUMN pocket varchar not null default '';
+ALTER TABLE transactions_history ADD COLUMN target_currency varchar not null default '';
+-- +migrate Down
+
+ALTER TABLE transactions_history DROP COLUMN target_currency;
+ALTER TABLE transactions_history DROP COLUMN pocket;
+
+DROP TABLE wallet_pockets;
+
+ALTER TABLE wallets DROP COLUMN multi_currency;
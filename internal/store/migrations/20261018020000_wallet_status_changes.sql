@@ -0,0 +1,16 @@
+-- +migrate Up
+
+CREATE TABLE wallet_status_changes (
+    id uuid primary key,
+    wallet_id uuid not null references wallets(id),
+    status_from varchar not null,
+    status_to varchar not null,
+    changed_by uuid not null,
+    reason varchar not null default '',
+    created_at timestamp not null
+);
+
+CREATE INDEX wallet_status_changes_wallet_id_idx ON wallet_status_changes (wallet_id, created_at);
+-- +migrate Down
+
+DROP TABLE wallet_status_changes;
@@ -0,0 +1,10 @@
+-- +migrate Up
+
+ALTER TABLE wallets ADD COLUMN deleted_at timestamp, ADD COLUMN purged_at timestamp;
+
+UPDATE wallets SET deleted_at = updated_at WHERE deleted;
+
+CREATE INDEX wallets_deleted_at_idx ON wallets (deleted_at) WHERE deleted and purged_at IS NULL;
+-- +migrate Down
+
+ALTER TABLE wallets DROP COLUMN deleted_at, DROP COLUMN purged_at;
@@ -0,0 +1,24 @@
+-- +migrate Up
+
+ALTER TABLE wallets ADD COLUMN savings bool not null default false, ADD COLUMN dormancy_warned_at timestamp;
+
+CREATE TABLE dormancy_runs (
+    id uuid primary key,
+    started_at timestamp not null
+);
+
+CREATE INDEX dormancy_runs_started_at_idx ON dormancy_runs (started_at);
+
+CREATE TABLE dormancy_run_wallets (
+    run_id uuid not null references dormancy_runs(id),
+    wallet_id uuid not null references wallets(id),
+    owner_id uuid not null,
+    action varchar not null,
+    last_activity_at timestamp not null,
+    primary key (run_id, wallet_id)
+);
+-- +migrate Down
+
+DROP TABLE dormancy_run_wallets;
+DROP TABLE dormancy_runs;
+ALTER TABLE wallets DROP COLUMN savings, DROP COLUMN dormancy_warned_at;
@@ -0,0 +1,12 @@
+-- +migrate Up
+
+ALTER TABLE outbox ADD COLUMN failed_at timestamp;
+
+DROP INDEX outbox_pending_idx;
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at) WHERE published_at IS NULL and failed_at IS NULL;
+-- +migrate Down
+
+DROP INDEX outbox_pending_idx;
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at) WHERE published_at IS NULL;
+
+ALTER TABLE outbox DROP COLUMN failed_at;
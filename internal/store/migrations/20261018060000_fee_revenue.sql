@@ -0,0 +1,7 @@
+-- +migrate Up
+
+INSERT INTO ledger_accounts (id, code, created_at) VALUES
+    ('00000000-0000-0000-0000-000000000005', 'fee_revenue', now());
+-- +migrate Down
+
+DELETE FROM ledger_accounts WHERE id = '00000000-0000-0000-0000-000000000005';
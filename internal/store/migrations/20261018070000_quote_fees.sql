@@ -0,0 +1,9 @@
+-- +migrate Up
+
+ALTER TABLE quotes
+    ADD COLUMN operation_type varchar not null DEFAULT '',
+    ADD COLUMN fee numeric not null DEFAULT 0,
+    ADD COLUMN fee_currency varchar not null DEFAULT '';
+-- +migrate Down
+
+ALTER TABLE quotes DROP COLUMN operation_type, DROP COLUMN fee, DROP COLUMN fee_currency;
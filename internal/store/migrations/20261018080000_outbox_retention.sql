@@ -0,0 +1,6 @@
+-- +migrate Up
+
+CREATE INDEX outbox_published_idx ON outbox (published_at) WHERE published_at IS NOT NULL;
+-- +migrate Down
+
+DROP INDEX outbox_published_idx;
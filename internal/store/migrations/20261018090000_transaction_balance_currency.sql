@@ -0,0 +1,6 @@
+-- +migrate Up
+
+ALTER TABLE transactions_history ADD COLUMN balance_currency varchar not null default '';
+-- +migrate Down
+
+ALTER TABLE transactions_history DROP COLUMN balance_currency;
@@ -9,14 +9,16 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	log "github.com/sirupsen/logrus"
 )
 
-func (p *Postgres) Deposit(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error {
-	tx, err := p.db.Begin(ctx)
+func (p *Postgres) Deposit(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error) {
+	tx, err := p.begin(ctx)
 	if err != nil {
-		return fmt.Errorf("p.db.Begin(ctx) err: %w", err)
+		return nil, err
 	}
 
 	defer func() {
@@ -26,27 +28,31 @@ func (p *Postgres) Deposit(ctx context.Context, transaction models.Transaction,
 		}
 	}()
 
-	err = p.updateWalletBalance(ctx, tx, transaction.WalletID, ownerID, transaction.Amount)
+	if err = saveIdempotencyKey(ctx, tx, transaction, ownerID); err != nil {
+		return nil, err
+	}
+
+	err = p.updateWalletBalance(ctx, tx, transaction.WalletID, ownerID, transaction.Pocket, transaction.ConvertedAmount)
 	if err != nil {
-		return models.ErrChangeBalanceData
+		return nil, models.ErrChangeBalanceData
 	}
 
-	err = saveTransaction(ctx, tx, transaction, ownerID)
+	executedTransaction, err := saveTransaction(ctx, tx, transaction, ownerID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("transaction commit err: %w", err)
+		return nil, fmt.Errorf("transaction commit err: %w", err)
 	}
 
-	return nil
+	return executedTransaction, nil
 }
 
-func (p *Postgres) Transfer(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error {
-	tx, err := p.db.Begin(ctx)
+func (p *Postgres) Transfer(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error) {
+	tx, err := p.begin(ctx)
 	if err != nil {
-		return fmt.Errorf("p.db.Begin(ctx) err: %w", err)
+		return nil, err
 	}
 
 	defer func() {
@@ -56,42 +62,46 @@ func (p *Postgres) Transfer(ctx context.Context, transaction models.Transaction,
 		}
 	}()
 
-	err = p.updateWalletBalance(ctx, tx, transaction.WalletID, ownerID, -transaction.Amount)
+	if err = saveIdempotencyKey(ctx, tx, transaction, ownerID); err != nil {
+		return nil, err
+	}
+
+	err = p.updateWalletBalance(ctx, tx, transaction.WalletID, ownerID, transaction.Pocket, transaction.Amount.Neg())
 
 	switch {
 	case errors.Is(err, models.ErrWalletNotFound):
-		return models.ErrWalletNotFound
+		return nil, models.ErrWalletNotFound
 	case errors.Is(err, models.ErrBalanceBelowZero):
-		return models.ErrBalanceBelowZero
+		return nil, models.ErrBalanceBelowZero
 	case err != nil:
-		return fmt.Errorf("owner walletp.db.UpdateWallet(ctx) err: %w", err)
+		return nil, fmt.Errorf("owner walletp.db.UpdateWallet(ctx) err: %w", err)
 	}
 
-	err = p.updateWalletBalance(ctx, tx, transaction.TargetWalletID, ownerID, transaction.ConvertedAmount)
+	err = p.updateWalletBalance(ctx, tx, transaction.TargetWalletID, transaction.TargetOwnerID, "", transaction.ConvertedAmount)
 
 	switch {
 	case errors.Is(err, models.ErrWalletNotFound):
-		return models.ErrWalletNotFound
+		return nil, models.ErrWalletNotFound
 	case err != nil:
-		return fmt.Errorf("target wallet p.db.UpdateWallet(ctx) err: %w", err)
+		return nil, fmt.Errorf("target wallet p.db.UpdateWallet(ctx) err: %w", err)
 	}
 
-	err = saveTransaction(ctx, tx, transaction, ownerID)
+	executedTransaction, err := saveTransaction(ctx, tx, transaction, ownerID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("transaction commit err: %w", err)
+		return nil, fmt.Errorf("transaction commit err: %w", err)
 	}
 
-	return nil
+	return executedTransaction, nil
 }
 
-func (p *Postgres) Withdraw(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) error {
-	tx, err := p.db.Begin(ctx)
+func (p *Postgres) Withdraw(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (*models.Transaction, error) {
+	tx, err := p.begin(ctx)
 	if err != nil {
-		return fmt.Errorf("p.db.Begin(ctx) err: %w", err)
+		return nil, err
 	}
 
 	defer func() {
@@ -101,73 +111,318 @@ func (p *Postgres) Withdraw(ctx context.Context, transaction models.Transaction,
 		}
 	}()
 
-	err = p.updateWalletBalance(ctx, tx, transaction.WalletID, ownerID, -transaction.Amount)
+	if err = saveIdempotencyKey(ctx, tx, transaction, ownerID); err != nil {
+		return nil, err
+	}
+
+	err = p.updateWalletBalance(ctx, tx, transaction.WalletID, ownerID, transaction.Pocket, transaction.ConvertedAmount.Neg())
 
 	switch {
 	case errors.Is(err, models.ErrBalanceBelowZero):
-		return models.ErrBalanceBelowZero
+		return nil, models.ErrBalanceBelowZero
 	case err != nil:
-		return models.ErrChangeBalanceData
+		return nil, models.ErrChangeBalanceData
 	}
 
-	err = saveTransaction(ctx, tx, transaction, ownerID)
+	executedTransaction, err := saveTransaction(ctx, tx, transaction, ownerID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("transaction commit err: %w", err)
+		return nil, fmt.Errorf("transaction commit err: %w", err)
 	}
 
-	return nil
+	return executedTransaction, nil
 }
 
-func saveTransaction(ctx context.Context, tx pgx.Tx, transaction models.Transaction, ownerID uuid.UUID) error {
+// Exchange moves the amount between two balances of a multi-currency wallet: it debits the amount
+// from the pocket of the transaction currency and credits the converted amount to the pocket of
+// the target currency, where an empty pocket is the main balance.
+func (p *Postgres) Exchange(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID, toPocket string) (
+	*models.Transaction, error,
+) {
+	tx, err := p.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Warnf("exchange tx.Rollback(ctx) err: %v", err)
+		}
+	}()
+
+	if err = saveIdempotencyKey(ctx, tx, transaction, ownerID); err != nil {
+		return nil, err
+	}
+
+	err = p.updateWalletBalance(ctx, tx, transaction.WalletID, ownerID, transaction.Pocket, transaction.Amount.Neg())
+	if err == nil {
+		err = p.updateWalletBalance(ctx, tx, transaction.WalletID, ownerID, toPocket, transaction.ConvertedAmount)
+	}
+
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound):
+		return nil, models.ErrWalletNotFound
+	case errors.Is(err, models.ErrBalanceBelowZero):
+		return nil, models.ErrBalanceBelowZero
+	case err != nil:
+		return nil, fmt.Errorf("p.updateWalletBalance(ctx) err: %w", err)
+	}
+
+	executedTransaction, err := saveTransaction(ctx, tx, transaction, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("transaction commit err: %w", err)
+	}
+
+	return executedTransaction, nil
+}
+
+// Reverse applies a reversal of the original transaction to the wallet balances and records it.
+func (p *Postgres) Reverse(ctx context.Context, reversal, original models.Transaction) (*models.Transaction, error) {
+	tx, err := p.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			log.Warnf("reverse tx.Rollback(ctx) err: %v", err)
+		}
+	}()
+
+	switch original.OperationType {
+	case models.OperationDeposit:
+		err = p.updateWalletBalance(ctx, tx, original.WalletID, original.OwnerID, original.Pocket, reversal.ConvertedAmount.Neg())
+	case models.OperationWithdraw, models.OperationFee:
+		err = p.updateWalletBalance(ctx, tx, original.WalletID, original.OwnerID, original.Pocket, reversal.ConvertedAmount)
+	case models.OperationTransfer:
+		err = p.updateWalletBalance(ctx, tx, original.TargetWalletID, original.TargetOwnerID, "", reversal.ConvertedAmount.Neg())
+		if err == nil {
+			err = p.updateWalletBalance(ctx, tx, original.WalletID, original.OwnerID, original.Pocket, reversal.Amount)
+		}
+	default:
+		return nil, models.ErrNotReversible
+	}
+
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound):
+		return nil, models.ErrWalletNotFound
+	case errors.Is(err, models.ErrBalanceBelowZero):
+		return nil, models.ErrBalanceBelowZero
+	case err != nil:
+		return nil, fmt.Errorf("p.updateWalletBalance(ctx) err: %w", err)
+	}
+
+	executedTransaction, err := saveTransaction(ctx, tx, reversal, original.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("transaction commit err: %w", err)
+	}
+
+	return executedTransaction, nil
+}
+
+// GetReversedAmounts returns how much of the transaction has been reversed so far, in its amount
+// and converted amount.
+func (p *Postgres) GetReversedAmounts(ctx context.Context, id uuid.UUID) (models.Decimal, models.Decimal, error) {
+	var amount, convertedAmount models.Decimal
+
+	query := `	SELECT sum(amount), sum(converted_amount)
+				FROM transactions_history 
+				WHERE original_transaction_id = $1 and transaction_type = $2`
+
+	err := p.conn(ctx).QueryRow(ctx, query, id, models.OperationReversal).Scan(&amount, &convertedAmount)
+	if err != nil {
+		return models.Decimal{}, models.Decimal{}, fmt.Errorf("getting reversed amounts error: %w", err)
+	}
+
+	return amount, convertedAmount, nil
+}
+
+// SaveTransaction records an operation that doesn't move money between wallets, such as a
+// currency conversion of a wallet balance.
+func (p *Postgres) SaveTransaction(ctx context.Context, transaction models.Transaction, ownerID uuid.UUID) (
+	*models.Transaction, error,
+) {
+	return saveTransaction(ctx, p.conn(ctx), transaction, ownerID)
+}
+
+func saveTransaction(ctx context.Context, tx querier, transaction models.Transaction, ownerID uuid.UUID) (
+	*models.Transaction, error,
+) {
 	var executedOperation models.Transaction
 
+	if transaction.TransactionID == uuid.Nil {
+		transaction.TransactionID = uuid.New()
+	}
+
 	query := `INSERT INTO transactions_history
-    (id, wallet_id, owner_id, target_wallet_id, amount, converted_amount, currency, transaction_type, executed_at)
-    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-    RETURNING id, wallet_id, owner_id, target_wallet_id, amount, 
-        converted_amount, currency, transaction_type, executed_at`
+    (id, wallet_id, owner_id, target_wallet_id, target_owner_id, amount, converted_amount, 
+     currency, ex_rate, rate_source, rate_date, transaction_type, original_transaction_id, quote_id,
+     pocket, target_currency, balance_currency, executed_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+    RETURNING id, wallet_id, owner_id, target_wallet_id, target_owner_id, amount, converted_amount,
+        currency, ex_rate, rate_source, rate_date, transaction_type, original_transaction_id, quote_id,
+        pocket, target_currency, balance_currency, executed_at`
 
 	err := tx.QueryRow(
 		ctx,
 		query,
-		uuid.New(),
+		transaction.TransactionID,
 		transaction.WalletID,
 		ownerID,
 		transaction.TargetWalletID,
+		transaction.TargetOwnerID,
 		transaction.Amount,
 		transaction.ConvertedAmount,
 		transaction.Currency,
+		transaction.ExRate,
+		transaction.RateSource,
+		transaction.RateDate,
 		transaction.OperationType,
+		transaction.OriginalID,
+		transaction.QuoteID,
+		transaction.Pocket,
+		transaction.TargetCurrency,
+		transaction.BalanceCurrency,
 		time.Now(),
 	).Scan(
 		&executedOperation.TransactionID,
 		&executedOperation.WalletID,
 		&executedOperation.OwnerID,
 		&executedOperation.TargetWalletID,
+		&executedOperation.TargetOwnerID,
 		&executedOperation.Amount,
 		&executedOperation.ConvertedAmount,
 		&executedOperation.Currency,
+		&executedOperation.ExRate,
+		&executedOperation.RateSource,
+		&executedOperation.RateDate,
 		&executedOperation.OperationType,
+		&executedOperation.OriginalID,
+		&executedOperation.QuoteID,
+		&executedOperation.Pocket,
+		&executedOperation.TargetCurrency,
+		&executedOperation.BalanceCurrency,
 		&executedOperation.ExecutedAt,
 	)
-	if err != nil {
-		return fmt.Errorf("transaction writing to base err: %w", err)
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation:
+		return nil, models.ErrDuplicateTransaction
+	case err != nil:
+		return nil, fmt.Errorf("transaction writing to base err: %w", err)
+	}
+
+	return &executedOperation, nil
+}
+
+func (p *Postgres) GetTransactionByID(ctx context.Context, id, ownerID uuid.UUID) (*models.Transaction, error) {
+	return p.getTransaction(ctx, `id = $1 and owner_id = $2`, id, ownerID)
+}
+
+// GetAnyTransactionByID returns a transaction of any owner, for staff operations.
+func (p *Postgres) GetAnyTransactionByID(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	return p.getTransaction(ctx, `id = $1`, id)
+}
+
+// GetFeeTransaction returns the FX fee charged for the transaction.
+func (p *Postgres) GetFeeTransaction(ctx context.Context, id uuid.UUID) (*models.Transaction, error) {
+	return p.getTransaction(ctx, `original_transaction_id = $1 and transaction_type = $2`, id, models.OperationFee)
+}
+
+// getTransaction returns the transaction matching the condition, locked when called within a DB transaction.
+func (p *Postgres) getTransaction(ctx context.Context, condition string, args ...any) (*models.Transaction, error) {
+	var transaction models.Transaction
+
+	query := `	SELECT id, wallet_id, owner_id, target_wallet_id, target_owner_id, amount, 
+	       				converted_amount, currency, ex_rate, rate_source, rate_date, transaction_type,
+	       				original_transaction_id, quote_id, pocket, target_currency, balance_currency, executed_at
+				FROM transactions_history 
+				WHERE ` + condition
+
+	if p.getTxFromCtx(ctx) != nil {
+		query += ` FOR UPDATE`
 	}
 
-	return nil
+	err := p.conn(ctx).QueryRow(ctx, query, args...).Scan(
+		&transaction.TransactionID,
+		&transaction.WalletID,
+		&transaction.OwnerID,
+		&transaction.TargetWalletID,
+		&transaction.TargetOwnerID,
+		&transaction.Amount,
+		&transaction.ConvertedAmount,
+		&transaction.Currency,
+		&transaction.ExRate,
+		&transaction.RateSource,
+		&transaction.RateDate,
+		&transaction.OperationType,
+		&transaction.OriginalID,
+		&transaction.QuoteID,
+		&transaction.Pocket,
+		&transaction.TargetCurrency,
+		&transaction.BalanceCurrency,
+		&transaction.ExecutedAt,
+	)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrTransactionsNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting transaction error: %w", err)
+	}
+
+	return &transaction, nil
 }
 
+//nolint:gochecknoglobals
+var transactionsSortingColumns = map[string]struct{}{
+	"amount":           {},
+	"converted_amount": {},
+	"currency":         {},
+	"transaction_type": {},
+	"executed_at":      {},
+}
+
+// transactionDirection works out whether money entered or left the wallet $1 from the operation type:
+// a reversal moves money the opposite way to the transaction it reverses.
+const transactionDirection = `
+	CASE h.transaction_type
+		WHEN 'deposit' THEN 'incoming'
+		WHEN 'fee_settlement' THEN 'incoming'
+		WHEN 'withdraw' THEN 'outgoing'
+		WHEN 'reversal' THEN
+			CASE (SELECT o.transaction_type FROM transactions_history o WHERE o.id = h.original_transaction_id)
+				WHEN 'deposit' THEN 'outgoing'
+				WHEN 'withdraw' THEN 'incoming'
+				WHEN 'fee' THEN 'incoming'
+				ELSE CASE WHEN h.wallet_id = $1 THEN 'incoming' ELSE 'outgoing' END
+			END
+		ELSE CASE WHEN h.wallet_id = $1 THEN 'outgoing' ELSE 'incoming' END
+	END`
+
 func (p *Postgres) GetTransactions(ctx context.Context, id uuid.UUID, params models.Params) ([]*models.Transaction, error) {
 	var transactions []*models.Transaction
 
-	query := `	SELECT id, wallet_id, owner_id, target_wallet_id, amount, 
-	       				converted_amount, currency, transaction_type, executed_at
-				FROM transactions_history 
-				WHERE wallet_id = $1
+	query := `	SELECT id, wallet_id, owner_id, target_wallet_id, target_owner_id, amount, 
+	       				converted_amount, currency, ex_rate, rate_source, rate_date, transaction_type,
+	       				original_transaction_id, quote_id, pocket, target_currency, balance_currency, executed_at,
+	       				` + transactionDirection + `
+				FROM transactions_history h
+				WHERE (wallet_id = $1 or target_wallet_id = $1)
 			`
 	queryParams := []interface{}{id}
 	i := 2
@@ -187,6 +442,10 @@ func (p *Postgres) GetTransactions(ctx context.Context, id uuid.UUID, params mod
 	}
 
 	if params.Sorting != "" {
+		if _, ok := transactionsSortingColumns[params.Sorting]; !ok {
+			return nil, models.ErrSortingNotAllowed
+		}
+
 		query += " ORDER BY " + params.Sorting
 		if params.Descending {
 			query += " DESC "
@@ -210,11 +469,21 @@ func (p *Postgres) GetTransactions(ctx context.Context, id uuid.UUID, params mod
 			&transaction.WalletID,
 			&transaction.OwnerID,
 			&transaction.TargetWalletID,
+			&transaction.TargetOwnerID,
 			&transaction.Amount,
 			&transaction.ConvertedAmount,
 			&transaction.Currency,
+			&transaction.ExRate,
+			&transaction.RateSource,
+			&transaction.RateDate,
 			&transaction.OperationType,
+			&transaction.OriginalID,
+			&transaction.QuoteID,
+			&transaction.Pocket,
+			&transaction.TargetCurrency,
+			&transaction.BalanceCurrency,
 			&transaction.ExecutedAt,
+			&transaction.Direction,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("rows.Scan err: %w", err)
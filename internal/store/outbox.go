@@ -0,0 +1,160 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+// SaveOutboxMessage stores the message in the transaction from ctx, so it is committed
+// together with the change it describes.
+func (p *Postgres) SaveOutboxMessage(ctx context.Context, message models.OutboxMessage) error {
+	timeNow := time.Now()
+
+	query := `INSERT INTO outbox (topic, key, payload, created_at, next_attempt_at)
+				VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := p.conn(ctx).Exec(ctx, query, message.Topic, message.Key, message.Payload, timeNow, timeNow)
+	if err != nil {
+		return fmt.Errorf("saving outbox message err: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimOutboxMessages claims up to limit unpublished messages that are due for delivery by
+// moving their next attempt to claimedUntil, so that no other relay picks them up while they are
+// published outside of any transaction. A message whose relay dies is claimed again after that.
+// Rows locked by other relays are skipped.
+func (p *Postgres) ClaimOutboxMessages(ctx context.Context, limit, maxAttempts int, claimedUntil time.Time) (
+	[]*models.OutboxMessage, error,
+) {
+	messages := make([]*models.OutboxMessage, 0)
+
+	query := `	UPDATE outbox SET next_attempt_at = $4
+				WHERE id IN (
+					SELECT id
+					FROM outbox
+					WHERE published_at IS NULL and failed_at IS NULL and next_attempt_at <= $1 and attempts < $2
+					ORDER BY id
+					LIMIT $3
+					FOR UPDATE SKIP LOCKED
+				)
+				RETURNING id, topic, key, payload, created_at, attempts`
+
+	rows, err := p.conn(ctx).Query(ctx, query, time.Now(), maxAttempts, limit, claimedUntil)
+	if err != nil {
+		return nil, fmt.Errorf("p.db.Query err: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var message models.OutboxMessage
+
+		err = rows.Scan(
+			&message.ID,
+			&message.Topic,
+			&message.Key,
+			&message.Payload,
+			&message.CreatedAt,
+			&message.Attempts,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		messages = append(messages, &message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	slices.SortFunc(messages, func(a, b *models.OutboxMessage) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return messages, nil
+}
+
+func (p *Postgres) MarkOutboxMessagePublished(ctx context.Context, id int64) error {
+	query := `UPDATE outbox SET published_at = $2, attempts = attempts + 1, last_error = NULL WHERE id = $1`
+
+	_, err := p.conn(ctx).Exec(ctx, query, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("marking outbox message published err: %w", err)
+	}
+
+	return nil
+}
+
+// CleanOutbox deletes the messages published before the given time. Dead-lettered messages are
+// kept for investigation.
+func (p *Postgres) CleanOutbox(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	query := `DELETE FROM outbox WHERE published_at < $1`
+
+	result, err := p.db.Exec(ctx, query, publishedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("cleanOutbox(): p.db.Exec(ctx, query, time) err: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func (p *Postgres) MarkOutboxMessageFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	query := `UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1`
+
+	_, err := p.conn(ctx).Exec(ctx, query, id, lastError, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("marking outbox message failed err: %w", err)
+	}
+
+	return nil
+}
+
+// DeadLetterOutboxMessages gives up on the unpublished messages that used up maxAttempts, marking
+// them as failed and returning them, so that they are reported instead of being left behind.
+func (p *Postgres) DeadLetterOutboxMessages(ctx context.Context, maxAttempts int) ([]*models.OutboxMessage, error) {
+	messages := make([]*models.OutboxMessage, 0)
+
+	query := `	UPDATE outbox SET failed_at = $2
+				WHERE published_at IS NULL and failed_at IS NULL and attempts >= $1
+				RETURNING id, topic, key, payload, created_at, attempts, coalesce(last_error, '')`
+
+	rows, err := p.conn(ctx).Query(ctx, query, maxAttempts, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("p.db.Query err: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var message models.OutboxMessage
+
+		err = rows.Scan(
+			&message.ID,
+			&message.Topic,
+			&message.Key,
+			&message.Payload,
+			&message.CreatedAt,
+			&message.Attempts,
+			&message.LastError,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		messages = append(messages, &message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const quoteColumns = `id, owner_id, currency_from, currency_to, amount, converted_amount, rate,
+				rate_source, rate_date, operation_type, fee, fee_currency, expires_at, created_at, transaction_id`
+
+func (p *Postgres) SaveQuote(ctx context.Context, quote models.Quote) (*models.Quote, error) {
+	query := `INSERT INTO quotes (id, owner_id, currency_from, currency_to, amount, converted_amount, rate,
+                   rate_source, rate_date, operation_type, fee, fee_currency, expires_at, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+				RETURNING ` + quoteColumns
+
+	savedQuote, err := scanQuote(p.conn(ctx).QueryRow(
+		ctx,
+		query,
+		quote.ID,
+		quote.OwnerID,
+		quote.CurrencyFrom,
+		quote.CurrencyTo,
+		quote.Amount,
+		quote.ConvertedAmount,
+		quote.Rate,
+		quote.RateSource,
+		quote.RateDate,
+		quote.OperationType,
+		quote.Fee,
+		quote.FeeCurrency,
+		quote.ExpiresAt,
+		quote.CreatedAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("saving quote error: %w", err)
+	}
+
+	return savedQuote, nil
+}
+
+func (p *Postgres) GetQuoteByID(ctx context.Context, id, ownerID uuid.UUID) (*models.Quote, error) {
+	query := `	SELECT ` + quoteColumns + `
+				FROM quotes
+				WHERE id = $1 and owner_id = $2`
+
+	if p.getTxFromCtx(ctx) != nil {
+		query += ` FOR UPDATE`
+	}
+
+	quote, err := scanQuote(p.conn(ctx).QueryRow(ctx, query, id, ownerID))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrQuoteNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting quote by id error: %w", err)
+	}
+
+	return quote, nil
+}
+
+// UseQuote binds the quote to the transaction that locked its rate, unless it is already used.
+func (p *Postgres) UseQuote(ctx context.Context, id, transactionID uuid.UUID) error {
+	query := `UPDATE quotes SET transaction_id = $2 WHERE id = $1 and transaction_id IS NULL`
+
+	tag, err := p.conn(ctx).Exec(ctx, query, id, transactionID)
+	if err != nil {
+		return fmt.Errorf("using quote error: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return models.ErrQuoteUsed
+	}
+
+	return nil
+}
+
+// CleanQuotes deletes quotes that expired unused before the given time.
+func (p *Postgres) CleanQuotes(ctx context.Context, expiredBefore time.Time) error {
+	query := `DELETE FROM quotes WHERE expires_at < $1 and transaction_id IS NULL`
+
+	_, err := p.db.Exec(ctx, query, expiredBefore)
+	if err != nil {
+		return fmt.Errorf("cleanQuotes(): p.db.Exec(ctx, query, time) err: %w", err)
+	}
+
+	return nil
+}
+
+func scanQuote(row pgx.Row) (*models.Quote, error) {
+	var quote models.Quote
+
+	err := row.Scan(
+		&quote.ID,
+		&quote.OwnerID,
+		&quote.CurrencyFrom,
+		&quote.CurrencyTo,
+		&quote.Amount,
+		&quote.ConvertedAmount,
+		&quote.Rate,
+		&quote.RateSource,
+		&quote.RateDate,
+		&quote.OperationType,
+		&quote.Fee,
+		&quote.FeeCurrency,
+		&quote.ExpiresAt,
+		&quote.CreatedAt,
+		&quote.TransactionID,
+	)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return &quote, nil
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// SaveExchangeRate stores a fetched rate; a rate fetched again for the same date replaces the previous one.
+func (p *Postgres) SaveExchangeRate(ctx context.Context, rate models.ExchangeRate) error {
+	query := `INSERT INTO exchange_rates (source, currency, base, rate_date, rate, fetched_at)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (source, currency, rate_date) DO UPDATE
+				SET base = $3, rate = $5, fetched_at = $6`
+
+	_, err := p.conn(ctx).Exec(
+		ctx,
+		query,
+		rate.Source,
+		rate.Currency,
+		rate.Base,
+		rate.Date,
+		rate.Rate,
+		rate.FetchedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("saving exchange rate error: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestExchangeRate returns the rate of the currency from the source for the latest date it was fetched for.
+func (p *Postgres) GetLatestExchangeRate(ctx context.Context, source, currency string) (*models.ExchangeRate, error) {
+	rate, err := scanExchangeRate(p.conn(ctx).QueryRow(
+		ctx,
+		`	SELECT source, currency, base, rate_date, rate, fetched_at
+			FROM exchange_rates
+			WHERE source = $1 and currency = $2
+			ORDER BY rate_date DESC
+			LIMIT 1`,
+		source,
+		currency,
+	))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrExchangeRateNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting latest exchange rate error: %w", err)
+	}
+
+	return rate, nil
+}
+
+// GetExchangeRateAt returns the rate of the currency from the source for the latest date on or before the date.
+func (p *Postgres) GetExchangeRateAt(ctx context.Context, source, currency string, date time.Time) (
+	*models.ExchangeRate, error,
+) {
+	rate, err := scanExchangeRate(p.conn(ctx).QueryRow(
+		ctx,
+		`	SELECT source, currency, base, rate_date, rate, fetched_at
+			FROM exchange_rates
+			WHERE source = $1 and currency = $2 and rate_date <= $3
+			ORDER BY rate_date DESC
+			LIMIT 1`,
+		source,
+		currency,
+		date,
+	))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrExchangeRateNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting exchange rate at date error: %w", err)
+	}
+
+	return rate, nil
+}
+
+func scanExchangeRate(row pgx.Row) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+
+	err := row.Scan(
+		&rate.Source,
+		&rate.Currency,
+		&rate.Base,
+		&rate.Date,
+		&rate.Rate,
+		&rate.FetchedAt,
+	)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return &rate, nil
+}
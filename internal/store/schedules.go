@@ -0,0 +1,300 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const scheduleColumns = `id, owner_id, name, operation_type, wallet_id, target_wallet_id, recipient, amount, percent,
+				currency, recurrence, run_at, cron, weekday, day_of_month, next_run_at, active, created_at, updated_at`
+
+func (p *Postgres) CreateSchedule(ctx context.Context, schedule models.Schedule) (*models.Schedule, error) {
+	timeNow := time.Now()
+
+	query := `INSERT INTO schedules (id, owner_id, name, operation_type, wallet_id, target_wallet_id, recipient,
+                       amount, percent, currency, recurrence, run_at, cron, weekday, day_of_month, next_run_at,
+                       active, deleted, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, false, $18, $18)
+				RETURNING ` + scheduleColumns
+
+	createdSchedule, err := scanSchedule(p.conn(ctx).QueryRow(
+		ctx,
+		query,
+		schedule.ID,
+		schedule.OwnerID,
+		schedule.Name,
+		schedule.OperationType,
+		schedule.WalletID,
+		schedule.TargetWalletID,
+		schedule.Recipient,
+		schedule.Amount,
+		schedule.Percent,
+		schedule.Currency,
+		schedule.Recurrence,
+		schedule.RunAt,
+		schedule.Cron,
+		schedule.Weekday,
+		schedule.DayOfMonth,
+		schedule.NextRunAt,
+		schedule.Active,
+		timeNow,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("creating schedule error: %w", err)
+	}
+
+	return createdSchedule, nil
+}
+
+func (p *Postgres) GetSchedules(ctx context.Context, ownerID uuid.UUID) ([]*models.Schedule, error) {
+	schedules := make([]*models.Schedule, 0)
+
+	query := `	SELECT ` + scheduleColumns + `
+				FROM schedules
+				WHERE owner_id = $1 and deleted = false
+				ORDER BY created_at`
+
+	rows, err := p.db.Query(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("p.db.Query err: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return schedules, nil
+}
+
+func (p *Postgres) GetScheduleByID(ctx context.Context, id, ownerID uuid.UUID) (*models.Schedule, error) {
+	query := `	SELECT ` + scheduleColumns + `
+				FROM schedules
+				WHERE id = $1 and owner_id = $2 and deleted = false`
+
+	if p.getTxFromCtx(ctx) != nil {
+		query += ` FOR UPDATE`
+	}
+
+	schedule, err := scanSchedule(p.conn(ctx).QueryRow(ctx, query, id, ownerID))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrScheduleNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting schedule by id error: %w", err)
+	}
+
+	return schedule, nil
+}
+
+func (p *Postgres) UpdateSchedule(ctx context.Context, schedule models.Schedule) (*models.Schedule, error) {
+	query := `UPDATE schedules SET name = $3, operation_type = $4, wallet_id = $5, target_wallet_id = $6, recipient = $7,
+                     amount = $8, percent = $9, currency = $10, recurrence = $11, run_at = $12, cron = $13, weekday = $14,
+                     day_of_month = $15, next_run_at = $16, active = $17, updated_at = $18
+				WHERE id = $1 and owner_id = $2 and deleted = false
+				RETURNING ` + scheduleColumns
+
+	updatedSchedule, err := scanSchedule(p.conn(ctx).QueryRow(
+		ctx,
+		query,
+		schedule.ID,
+		schedule.OwnerID,
+		schedule.Name,
+		schedule.OperationType,
+		schedule.WalletID,
+		schedule.TargetWalletID,
+		schedule.Recipient,
+		schedule.Amount,
+		schedule.Percent,
+		schedule.Currency,
+		schedule.Recurrence,
+		schedule.RunAt,
+		schedule.Cron,
+		schedule.Weekday,
+		schedule.DayOfMonth,
+		schedule.NextRunAt,
+		schedule.Active,
+		time.Now(),
+	))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrScheduleNotFound
+	case err != nil:
+		return nil, fmt.Errorf("updating schedule error: %w", err)
+	}
+
+	return updatedSchedule, nil
+}
+
+func (p *Postgres) DeleteSchedule(ctx context.Context, id, ownerID uuid.UUID) error {
+	query := `UPDATE schedules SET deleted = true, active = false, updated_at = $3 WHERE id = $1 and owner_id = $2 and deleted = false`
+
+	result, err := p.db.Exec(ctx, query, id, ownerID, time.Now())
+
+	switch {
+	case err != nil:
+		return fmt.Errorf("deleting schedule error: %w", err)
+	case result.RowsAffected() == 0:
+		return models.ErrScheduleNotFound
+	}
+
+	return nil
+}
+
+// GetDueSchedules locks schedules due by now; schedules locked by another replica are skipped.
+// It must be called within a transaction, which holds the locks until the runs are recorded.
+func (p *Postgres) GetDueSchedules(ctx context.Context, now time.Time, limit int) ([]*models.Schedule, error) {
+	schedules := make([]*models.Schedule, 0)
+
+	query := `	SELECT ` + scheduleColumns + `
+				FROM schedules
+				WHERE active and not deleted and next_run_at <= $1
+				ORDER BY next_run_at
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED`
+
+	rows, err := p.conn(ctx).Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("p.conn(ctx).Query err: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// SetScheduleNextRun moves the schedule to its next run, deactivating it when there is none.
+func (p *Postgres) SetScheduleNextRun(ctx context.Context, id uuid.UUID, nextRunAt *time.Time) error {
+	query := `UPDATE schedules SET next_run_at = $2, active = $3, updated_at = $4 WHERE id = $1`
+
+	if _, err := p.conn(ctx).Exec(ctx, query, id, nextRunAt, nextRunAt != nil, time.Now()); err != nil {
+		return fmt.Errorf("setting schedule next run error: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) SaveScheduleRun(ctx context.Context, run models.ScheduleRun) error {
+	query := `INSERT INTO schedule_runs (schedule_id, scheduled_at, executed_at, status, transaction_id, error)
+				VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := p.conn(ctx).Exec(
+		ctx,
+		query,
+		run.ScheduleID,
+		run.ScheduledAt,
+		run.ExecutedAt,
+		run.Status,
+		run.TransactionID,
+		run.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("saving schedule run error: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) GetScheduleRuns(ctx context.Context, scheduleID uuid.UUID, params models.Params) ([]*models.ScheduleRun, error) {
+	runs := make([]*models.ScheduleRun, 0)
+
+	query := `	SELECT id, schedule_id, scheduled_at, executed_at, status, transaction_id, error
+				FROM schedule_runs
+				WHERE schedule_id = $1
+				ORDER BY scheduled_at DESC
+				LIMIT $2 OFFSET $3`
+
+	rows, err := p.db.Query(ctx, query, scheduleID, params.Limit, params.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("p.db.Query err: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var run models.ScheduleRun
+
+		err = rows.Scan(
+			&run.ID,
+			&run.ScheduleID,
+			&run.ScheduledAt,
+			&run.ExecutedAt,
+			&run.Status,
+			&run.TransactionID,
+			&run.Error,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		runs = append(runs, &run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return runs, nil
+}
+
+func scanSchedule(row pgx.Row) (*models.Schedule, error) {
+	var schedule models.Schedule
+
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.OwnerID,
+		&schedule.Name,
+		&schedule.OperationType,
+		&schedule.WalletID,
+		&schedule.TargetWalletID,
+		&schedule.Recipient,
+		&schedule.Amount,
+		&schedule.Percent,
+		&schedule.Currency,
+		&schedule.Recurrence,
+		&schedule.RunAt,
+		&schedule.Cron,
+		&schedule.Weekday,
+		&schedule.DayOfMonth,
+		&schedule.NextRunAt,
+		&schedule.Active,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return &schedule, nil
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	migrate "github.com/rubenv/sql-migrate"
 	log "github.com/sirupsen/logrus"
@@ -130,3 +131,38 @@ func (p *Postgres) getTxFromCtx(ctx context.Context) pgx.Tx {
 
 	return tx
 }
+
+type querier interface {
+	QueryRow(ctx context.Context, query string, args ...any) pgx.Row
+	Query(ctx context.Context, query string, args ...any) (pgx.Rows, error)
+	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
+}
+
+// conn returns the transaction stored in ctx by DoWithTx, or the pool if there is none.
+func (p *Postgres) conn(ctx context.Context) querier {
+	if tx := p.getTxFromCtx(ctx); tx != nil {
+		return tx
+	}
+
+	return p.db
+}
+
+// begin starts a transaction, or a savepoint inside the transaction stored in ctx by DoWithTx,
+// so that the caller's changes commit atomically with the rest of the outer transaction.
+func (p *Postgres) begin(ctx context.Context) (pgx.Tx, error) {
+	if tx := p.getTxFromCtx(ctx); tx != nil {
+		nestedTx, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("tx.Begin(ctx) err: %w", err)
+		}
+
+		return nestedTx, nil
+	}
+
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("p.db.Begin(ctx) err: %w", err)
+	}
+
+	return tx, nil
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/iurikman/cashFlowManager/internal/models"
 )
 
+// UpsertUser saves the user as is; the password must already be hashed.
 func (p *Postgres) UpsertUser(ctx context.Context, user models.User) error {
 	query := `	INSERT INTO users (id, name, email, phone, password, created_at, updated_at, deleted) 
 	VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
@@ -33,3 +35,128 @@ func (p *Postgres) UpsertUser(ctx context.Context, user models.User) error {
 
 	return nil
 }
+
+// UpdateUserPassword replaces the stored password unless it has been changed concurrently.
+func (p *Postgres) UpdateUserPassword(ctx context.Context, id uuid.UUID, oldPassword, newPassword string) error {
+	query := `UPDATE users SET password = $3, updated_at = $4 WHERE id = $1 and password = $2`
+
+	if _, err := p.conn(ctx).Exec(ctx, query, id, oldPassword, newPassword, time.Now()); err != nil {
+		return fmt.Errorf("updating user password error: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserPasswords returns up to limit users with IDs after afterID, ordered by ID, with only their
+// IDs and passwords read.
+func (p *Postgres) GetUserPasswords(ctx context.Context, afterID uuid.UUID, limit int) ([]*models.User, error) {
+	users := make([]*models.User, 0)
+
+	query := `	SELECT id, password
+				FROM users
+				WHERE id > $1
+				ORDER BY id
+				LIMIT $2`
+
+	rows, err := p.conn(ctx).Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("p.conn(ctx).Query err: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+
+		if err = rows.Scan(&user.ID, &user.Password); err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		users = append(users, &user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return users, nil
+}
+
+// FindUsers returns users whose name, email or phone contains params.FilterName. Passwords are not read.
+func (p *Postgres) FindUsers(ctx context.Context, params models.Params) ([]*models.User, error) {
+	users := make([]*models.User, 0)
+
+	query := `	SELECT id, name, email, phone, roles, tier, created_at, deleted
+				FROM users
+				WHERE deleted = false`
+	queryParams := []interface{}{}
+
+	if params.FilterName != "" {
+		queryParams = append(queryParams, "%"+params.FilterName+"%")
+		query += " and (name ILIKE $1 or email ILIKE $1 or phone ILIKE $1)"
+	}
+
+	query += fmt.Sprintf(" ORDER BY created_at, id LIMIT %d OFFSET %d", params.Limit, params.Offset)
+
+	rows, err := p.db.Query(ctx, query, queryParams...)
+	if err != nil {
+		return nil, fmt.Errorf("p.db.Query err: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var user models.User
+
+		if err = rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.Phone,
+			&user.Roles,
+			&user.Tier,
+			&user.CreatedAt,
+			&user.Deleted,
+		); err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		users = append(users, &user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return users, nil
+}
+
+func (p *Postgres) SetUserRoles(ctx context.Context, id uuid.UUID, roles []string) error {
+	query := `UPDATE users SET roles = $2, updated_at = $3 WHERE id = $1 and deleted = false`
+
+	result, err := p.conn(ctx).Exec(ctx, query, id, roles, time.Now())
+
+	switch {
+	case err != nil:
+		return fmt.Errorf("setting user roles error: %w", err)
+	case result.RowsAffected() == 0:
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (p *Postgres) SetUserTier(ctx context.Context, id uuid.UUID, tier string) error {
+	query := `UPDATE users SET tier = $2, updated_at = $3 WHERE id = $1 and deleted = false`
+
+	result, err := p.conn(ctx).Exec(ctx, query, id, tier, time.Now())
+
+	switch {
+	case err != nil:
+		return fmt.Errorf("setting user tier error: %w", err)
+	case result.RowsAffected() == 0:
+		return models.ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+func (p *Postgres) SaveWalletStatusChange(ctx context.Context, change models.WalletStatusChange) error {
+	query := `INSERT INTO wallet_status_changes (id, wallet_id, status_from, status_to, changed_by, reason, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := p.conn(ctx).Exec(
+		ctx,
+		query,
+		change.ID,
+		change.WalletID,
+		change.StatusFrom,
+		change.StatusTo,
+		change.ChangedBy,
+		change.Reason,
+		change.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("saving wallet status change err: %w", err)
+	}
+
+	return nil
+}
+
+// GetWalletStatusChanges returns the status changes of the wallet, the latest first.
+func (p *Postgres) GetWalletStatusChanges(ctx context.Context, walletID uuid.UUID) ([]*models.WalletStatusChange, error) {
+	query := `	SELECT id, wallet_id, status_from, status_to, changed_by, reason, created_at
+				FROM wallet_status_changes
+				WHERE wallet_id = $1
+				ORDER BY created_at DESC`
+
+	rows, err := p.conn(ctx).Query(ctx, query, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("getting wallet status changes error: %w", err)
+	}
+
+	defer rows.Close()
+
+	changes := make([]*models.WalletStatusChange, 0)
+
+	for rows.Next() {
+		var change models.WalletStatusChange
+
+		err = rows.Scan(
+			&change.ID,
+			&change.WalletID,
+			&change.StatusFrom,
+			&change.StatusTo,
+			&change.ChangedBy,
+			&change.Reason,
+			&change.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		changes = append(changes, &change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return changes, nil
+}
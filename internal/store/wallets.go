@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,37 +15,37 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func (p *Postgres) CreateWallet(ctx context.Context, wallet models.Wallet) (*models.Wallet, error) {
-	createdWallet := new(models.Wallet)
+const walletColumns = `w.id, w.owner, w.name, w.currency, w.balance, w.balance - w.held, w.status, w.multi_currency, w.savings, ` +
+	`w.created_at, w.updated_at, w.deleted, w.deleted_at, w.purged_at`
 
+func (p *Postgres) CreateWallet(ctx context.Context, wallet models.Wallet) (*models.Wallet, error) {
 	timeNow := time.Now()
 
-	query := `INSERT INTO wallets (id, owner, name, currency, balance, created_at, updated_at, deleted) 
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-				RETURNING id, owner, name, currency, balance, created_at, updated_at, deleted
+	query := `WITH created AS (
+					INSERT INTO wallets AS w (id, owner, name, currency, balance, multi_currency, savings, created_at, updated_at, deleted) 
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+					RETURNING ` + walletColumns + `
+				), account AS (
+					INSERT INTO ledger_accounts (id, wallet_id, created_at)
+					SELECT id, id, created_at FROM created
+				)
+				SELECT * FROM created
 				`
 
-	err := p.db.QueryRow(
+	createdWallet, err := scanWallet(p.db.QueryRow(
 		ctx,
 		query,
 		uuid.New(),
 		wallet.Owner,
 		wallet.Name,
 		wallet.Currency,
-		0,
+		models.Decimal{},
+		wallet.MultiCurrency,
+		wallet.Savings,
 		timeNow,
 		timeNow,
 		wallet.Deleted,
-	).Scan(
-		&createdWallet.ID,
-		&createdWallet.Owner,
-		&createdWallet.Name,
-		&createdWallet.Currency,
-		&createdWallet.Balance,
-		&createdWallet.CreatedAt,
-		&createdWallet.UpdatedAt,
-		&createdWallet.Deleted,
-	)
+	))
 	if err != nil {
 		var pgErr *pgconn.PgError
 
@@ -61,42 +62,21 @@ func (p *Postgres) CreateWallet(ctx context.Context, wallet models.Wallet) (*mod
 	return createdWallet, nil
 }
 
-type querier interface {
-	QueryRow(ctx context.Context, query string, args ...any) pgx.Row
-}
-
-//nolint:ineffassign
 func (p *Postgres) GetWalletByID(ctx context.Context, id, ownerID uuid.UUID) (*models.Wallet, error) {
-	var wallet models.Wallet
-
-	query := `	SELECT id, owner, name, currency, balance, created_at, updated_at, deleted 
-				FROM wallets 
-				WHERE id = $1 and owner = $2 and deleted = false`
+	query := `	SELECT ` + walletColumns + ` 
+				FROM wallets w
+				WHERE w.id = $1 and w.owner = $2 and w.deleted = false`
 
-	var db querier
-
-	db = p.getTxFromCtx(ctx)
-	if db == nil {
-		db = p.db
-	} else {
+	if p.getTxFromCtx(ctx) != nil {
 		query += ` FOR UPDATE`
 	}
 
-	err := p.db.QueryRow(
+	wallet, err := scanWallet(p.conn(ctx).QueryRow(
 		ctx,
 		query,
 		id,
 		ownerID,
-	).Scan(
-		&wallet.ID,
-		&wallet.Owner,
-		&wallet.Name,
-		&wallet.Currency,
-		&wallet.Balance,
-		&wallet.CreatedAt,
-		&wallet.UpdatedAt,
-		&wallet.Deleted,
-	)
+	))
 
 	switch {
 	case errors.Is(err, pgx.ErrNoRows):
@@ -105,16 +85,197 @@ func (p *Postgres) GetWalletByID(ctx context.Context, id, ownerID uuid.UUID) (*m
 		return nil, fmt.Errorf("getting wallet by id error: %w", err)
 	}
 
-	return &wallet, nil
+	return wallet, nil
 }
 
-func (p *Postgres) updateWalletBalance(ctx context.Context, tx pgx.Tx, walletID, ownerID uuid.UUID, amount float64) error {
+// GetAnyWalletByID returns a wallet of any owner, including a deleted one, for staff lookups.
+func (p *Postgres) GetAnyWalletByID(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	query := `	SELECT ` + walletColumns + ` 
+				FROM wallets w
+				WHERE w.id = $1`
+
+	if p.getTxFromCtx(ctx) != nil {
+		query += ` FOR UPDATE`
+	}
+
+	wallet, err := scanWallet(p.conn(ctx).QueryRow(ctx, query, id))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrWalletNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting any wallet by id error: %w", err)
+	}
+
+	return wallet, nil
+}
+
+// GetTransferTargetWallet returns an active wallet of any owner, so that it can receive a transfer.
+func (p *Postgres) GetTransferTargetWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	query := `	SELECT ` + walletColumns + ` 
+				FROM wallets w
+				JOIN users u ON u.id = w.owner
+				WHERE w.id = $1 and w.deleted = false and u.deleted = false`
+
+	if p.getTxFromCtx(ctx) != nil {
+		query += ` FOR UPDATE OF w`
+	}
+
+	wallet, err := scanWallet(p.conn(ctx).QueryRow(ctx, query, id))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrWalletNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting transfer target wallet error: %w", err)
+	}
+
+	return wallet, nil
+}
+
+// GetRecipientWallet returns the wallet that receives transfers addressed to a user by ID, email
+// or phone: the oldest active wallet in the given currency, or the oldest active wallet otherwise.
+// A user without active wallets is reported the same way as an unknown one.
+func (p *Postgres) GetRecipientWallet(ctx context.Context, recipient, currency string) (*models.Wallet, error) {
+	query := `	SELECT ` + walletColumns + ` 
+				FROM wallets w
+				JOIN users u ON u.id = w.owner
+				WHERE (u.id::text = $1 or u.email = $1 or u.phone = $1) 
+					and w.deleted = false and u.deleted = false and w.status = $3
+				ORDER BY w.currency = $2 DESC, w.created_at
+				LIMIT 1`
+
+	if p.getTxFromCtx(ctx) != nil {
+		query += ` FOR UPDATE OF w`
+	}
+
+	wallet, err := scanWallet(p.conn(ctx).QueryRow(ctx, query, recipient, currency, models.WalletStatusActive))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrWalletNotFound
+	case err != nil:
+		return nil, fmt.Errorf("getting recipient wallet error: %w", err)
+	}
+
+	return wallet, nil
+}
+
+// LockWallets locks the wallets in the order of their IDs, so that operations locking the same
+// wallets can't deadlock. Must be called within a DB transaction.
+func (p *Postgres) LockWallets(ctx context.Context, ids ...uuid.UUID) error {
+	query := `SELECT id FROM wallets WHERE id = ANY($1) ORDER BY id FOR UPDATE`
+
+	rows, err := p.conn(ctx).Query(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("locking wallets error: %w", err)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return nil
+}
+
+//nolint:gochecknoglobals
+var walletsSortingColumns = map[string]struct{}{
+	"name":       {},
+	"currency":   {},
+	"balance":    {},
+	"created_at": {},
+	"updated_at": {},
+}
+
+func (p *Postgres) GetWallets(ctx context.Context, ownerID uuid.UUID, params models.Params) ([]*models.Wallet, error) {
+	wallets := make([]*models.Wallet, 0)
+
+	query := `	SELECT ` + walletColumns + ` 
+				FROM wallets w
+				WHERE w.owner = $1`
+	queryParams := []interface{}{ownerID}
+
+	if !params.IncludeDeleted {
+		query += " and w.deleted = false"
+	}
+
+	if params.FilterType != "" {
+		queryParams = append(queryParams, params.FilterType)
+		query += " and w.currency = $" + strconv.Itoa(len(queryParams))
+	}
+
+	if params.FilterName != "" {
+		queryParams = append(queryParams, "%"+params.FilterName+"%")
+		query += " and w.name ILIKE $" + strconv.Itoa(len(queryParams))
+	}
+
+	if params.BalanceFrom != nil {
+		queryParams = append(queryParams, *params.BalanceFrom)
+		query += " and w.balance >= $" + strconv.Itoa(len(queryParams))
+	}
+
+	if params.BalanceTo != nil {
+		queryParams = append(queryParams, *params.BalanceTo)
+		query += " and w.balance <= $" + strconv.Itoa(len(queryParams))
+	}
+
+	if params.Sorting != "" {
+		if _, ok := walletsSortingColumns[params.Sorting]; !ok {
+			return nil, models.ErrSortingNotAllowed
+		}
+
+		query += " ORDER BY w." + params.Sorting
+		if params.Descending {
+			query += " DESC "
+		}
+	}
+
+	query += fmt.Sprintf(" LIMIT %d OFFSET %d", params.Limit, params.Offset)
+
+	rows, err := p.db.Query(ctx, query, queryParams...)
+	if err != nil {
+		return nil, fmt.Errorf("p.db.Query err: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		wallets = append(wallets, wallet)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return wallets, nil
+}
+
+// updateWalletBalance changes the main balance of the wallet, or the balance of its pocket in
+// the currency when pocket is set, opening the pocket on the first credit.
+func (p *Postgres) updateWalletBalance(
+	ctx context.Context,
+	tx pgx.Tx,
+	walletID, ownerID uuid.UUID,
+	pocket string,
+	amount models.Decimal,
+) error {
+	if pocket != "" {
+		return p.updatePocketBalance(ctx, tx, walletID, ownerID, pocket, amount)
+	}
+
 	query := `	UPDATE wallets SET balance = balance + $3, updated_at = $4
                 WHERE id = $1 and owner = $2 and deleted = false 
 				RETURNING id, balance
 				`
 
-	_, err := tx.Exec(
+	result, err := tx.Exec(
 		ctx,
 		query,
 		walletID,
@@ -126,26 +287,105 @@ func (p *Postgres) updateWalletBalance(ctx context.Context, tx pgx.Tx, walletID,
 	var pgErr *pgconn.PgError
 
 	switch {
-	case errors.Is(err, pgx.ErrNoRows):
-		return models.ErrWalletNotFound
 	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.CheckViolation:
 		return models.ErrBalanceBelowZero
 	case err != nil:
 		return fmt.Errorf("updating wallet error: %w", err)
+	case result.RowsAffected() == 0:
+		return models.ErrWalletNotFound
 	}
 
 	return nil
 }
 
-func (p *Postgres) UpdateWallet(ctx context.Context, id, ownerID uuid.UUID, name, currency *string, balance float64) (*models.Wallet, error) {
-	var updatedWallet models.Wallet
+func (p *Postgres) updatePocketBalance(
+	ctx context.Context,
+	tx pgx.Tx,
+	walletID, ownerID uuid.UUID,
+	currency string,
+	amount models.Decimal,
+) error {
+	timeNow := time.Now()
+
+	query := `UPDATE wallets SET updated_at = $3 WHERE id = $1 and owner = $2 and deleted = false and multi_currency = true`
+
+	result, err := tx.Exec(ctx, query, walletID, ownerID, timeNow)
+
+	switch {
+	case err != nil:
+		return fmt.Errorf("updating wallet error: %w", err)
+	case result.RowsAffected() == 0:
+		return models.ErrWalletNotFound
+	}
+
+	query = `INSERT INTO wallet_pockets AS wp (wallet_id, currency, balance, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $4)
+				ON CONFLICT (wallet_id, currency) DO UPDATE SET balance = wp.balance + $3, updated_at = $4`
+
+	_, err = tx.Exec(ctx, query, walletID, currency, amount, timeNow)
+
+	var pgErr *pgconn.PgError
+
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == pgerrcode.CheckViolation:
+		return models.ErrBalanceBelowZero
+	case err != nil:
+		return fmt.Errorf("updating pocket error: %w", err)
+	}
 
-	query := `UPDATE wallets SET name = $3, currency = $4, balance = $5, updated_at = $6 
-               WHERE id = $1 AND owner = $2 AND deleted = false
-				RETURNING id, owner, name, currency, balance, created_at, updated_at, deleted
+	return nil
+}
+
+// GetPockets returns the pockets of the wallet in the order of their currencies.
+func (p *Postgres) GetPockets(ctx context.Context, walletID uuid.UUID) ([]models.Pocket, error) {
+	query := `	SELECT currency, balance, updated_at
+				FROM wallet_pockets
+				WHERE wallet_id = $1
+				ORDER BY currency`
+
+	if p.getTxFromCtx(ctx) != nil {
+		query += ` FOR UPDATE`
+	}
+
+	rows, err := p.conn(ctx).Query(ctx, query, walletID)
+	if err != nil {
+		return nil, fmt.Errorf("getting pockets error: %w", err)
+	}
+
+	defer rows.Close()
+
+	pockets := make([]models.Pocket, 0)
+
+	for rows.Next() {
+		var pocket models.Pocket
+
+		if err = rows.Scan(&pocket.Currency, &pocket.Balance, &pocket.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		pockets = append(pockets, pocket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return pockets, nil
+}
+
+func (p *Postgres) UpdateWallet(
+	ctx context.Context,
+	id, ownerID uuid.UUID,
+	name, currency *string,
+	balance models.Decimal,
+	multiCurrency, savings bool,
+) (*models.Wallet, error) {
+	query := `UPDATE wallets w SET name = $3, currency = $4, balance = $5, multi_currency = $6, savings = $7, updated_at = $8 
+               WHERE w.id = $1 AND w.owner = $2 AND w.deleted = false
+				RETURNING ` + walletColumns + `
                `
 
-	err := p.db.QueryRow(
+	updatedWallet, err := scanWallet(p.conn(ctx).QueryRow(
 		ctx,
 		query,
 		id,
@@ -153,17 +393,10 @@ func (p *Postgres) UpdateWallet(ctx context.Context, id, ownerID uuid.UUID, name
 		name,
 		currency,
 		balance,
+		multiCurrency,
+		savings,
 		time.Now(),
-	).Scan(
-		&updatedWallet.ID,
-		&updatedWallet.Owner,
-		&updatedWallet.Name,
-		&updatedWallet.Currency,
-		&updatedWallet.Balance,
-		&updatedWallet.CreatedAt,
-		&updatedWallet.UpdatedAt,
-		&updatedWallet.Deleted,
-	)
+	))
 
 	switch {
 	case errors.Is(err, pgx.ErrNoRows):
@@ -172,13 +405,30 @@ func (p *Postgres) UpdateWallet(ctx context.Context, id, ownerID uuid.UUID, name
 		return nil, fmt.Errorf("updating wallet error: %w", err)
 	}
 
-	return &updatedWallet, nil
+	return updatedWallet, nil
+}
+
+func (p *Postgres) SetWalletStatus(ctx context.Context, id uuid.UUID, status string) (*models.Wallet, error) {
+	query := `UPDATE wallets w SET status = $2, updated_at = $3 
+				WHERE w.id = $1 AND w.deleted = false
+				RETURNING ` + walletColumns
+
+	wallet, err := scanWallet(p.conn(ctx).QueryRow(ctx, query, id, status, time.Now()))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrWalletNotFound
+	case err != nil:
+		return nil, fmt.Errorf("setting wallet status error: %w", err)
+	}
+
+	return wallet, nil
 }
 
 func (p *Postgres) DeleteWallet(ctx context.Context, id, ownerID uuid.UUID) error {
-	query := `UPDATE wallets SET deleted = true WHERE id = $1 and owner = $2 and deleted = false`
+	query := `UPDATE wallets SET deleted = true, deleted_at = $3 WHERE id = $1 and owner = $2 and deleted = false`
 
-	result, err := p.db.Exec(ctx, query, id, ownerID)
+	result, err := p.db.Exec(ctx, query, id, ownerID, time.Now())
 
 	switch {
 	case result.RowsAffected() == 0:
@@ -190,6 +440,80 @@ func (p *Postgres) DeleteWallet(ctx context.Context, id, ownerID uuid.UUID) erro
 	return nil
 }
 
+// GetDeletedWallets returns the deleted wallets of the owner that were deleted after the given time
+// and are not purged yet, the latest deleted first.
+func (p *Postgres) GetDeletedWallets(ctx context.Context, ownerID uuid.UUID, deletedAfter time.Time) ([]*models.Wallet, error) {
+	wallets := make([]*models.Wallet, 0)
+
+	query := `	SELECT ` + walletColumns + ` 
+				FROM wallets w
+				WHERE w.owner = $1 and w.deleted = true and w.purged_at IS NULL and w.deleted_at >= $2
+				ORDER BY w.deleted_at DESC`
+
+	rows, err := p.db.Query(ctx, query, ownerID, deletedAfter)
+	if err != nil {
+		return nil, fmt.Errorf("p.db.Query err: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		wallet, err := scanWallet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("rows.Scan err: %w", err)
+		}
+
+		wallets = append(wallets, wallet)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return wallets, nil
+}
+
+// RestoreWallet undeletes a deleted wallet that is not purged yet.
+func (p *Postgres) RestoreWallet(ctx context.Context, id uuid.UUID) (*models.Wallet, error) {
+	query := `UPDATE wallets w SET deleted = false, deleted_at = NULL, updated_at = $2 
+				WHERE w.id = $1 and w.deleted = true and w.purged_at IS NULL
+				RETURNING ` + walletColumns
+
+	wallet, err := scanWallet(p.conn(ctx).QueryRow(ctx, query, id, time.Now()))
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, models.ErrWalletNotFound
+	case err != nil:
+		return nil, fmt.Errorf("restoring wallet error: %w", err)
+	}
+
+	return wallet, nil
+}
+
+// PurgeWallets purges the wallets deleted before the given time, so that they can't be restored
+// anymore, and deletes their schedules. The wallet rows themselves are kept: the ledger and the
+// transaction history still reference them.
+func (p *Postgres) PurgeWallets(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := `WITH purged AS (
+					UPDATE wallets SET purged_at = $2
+					WHERE deleted = true and purged_at IS NULL and deleted_at < $1
+					RETURNING id
+				), deleted_schedules AS (
+					UPDATE schedules SET deleted = true, active = false, updated_at = $2
+					WHERE wallet_id IN (SELECT id FROM purged) and deleted = false
+				)
+				SELECT count(*) FROM purged`
+
+	var purged int64
+
+	if err := p.db.QueryRow(ctx, query, deletedBefore, time.Now()).Scan(&purged); err != nil {
+		return 0, fmt.Errorf("purging wallets error: %w", err)
+	}
+
+	return purged, nil
+}
+
 func (p *Postgres) DoWithTx(ctx context.Context, fn func(ctx context.Context) error) error {
 	tx, err := p.db.Begin(ctx)
 	if err != nil {
@@ -215,14 +539,28 @@ func (p *Postgres) DoWithTx(ctx context.Context, fn func(ctx context.Context) er
 	return nil
 }
 
-func (p *Postgres) Clean(ctx context.Context) error {
-	startingFromDate := time.Now().AddDate(-1, 0, 0)
-	query := `UPDATE wallets SET deleted = true WHERE deleted = false and updated_at < $1`
+func scanWallet(row pgx.Row) (*models.Wallet, error) {
+	var wallet models.Wallet
 
-	_, err := p.db.Exec(ctx, query, startingFromDate)
+	err := row.Scan(
+		&wallet.ID,
+		&wallet.Owner,
+		&wallet.Name,
+		&wallet.Currency,
+		&wallet.Balance,
+		&wallet.AvailableBalance,
+		&wallet.Status,
+		&wallet.MultiCurrency,
+		&wallet.Savings,
+		&wallet.CreatedAt,
+		&wallet.UpdatedAt,
+		&wallet.Deleted,
+		&wallet.DeletedAt,
+		&wallet.PurgedAt,
+	)
 	if err != nil {
-		return fmt.Errorf("clean(): p.db.Exec(ctx, query, time) err: %w", err)
+		return nil, err //nolint:wrapcheck
 	}
 
-	return nil
+	return &wallet, nil
 }
@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+)
+
+func (s *IntegrationTestSuite) TestAdmin() {
+	newUser := func(name, phone string, roles ...string) (models.User, string) {
+		user := models.User{
+			ID:       uuid.New(),
+			Username: name,
+			Email:    name + "@mail.com",
+			Phone:    phone,
+			Password: "password" + phone,
+			Roles:    roles,
+		}
+		err := s.store.UpsertUser(context.Background(), user)
+		s.Require().NoError(err)
+		err = s.store.SetUserRoles(context.Background(), user.ID, roles)
+		s.Require().NoError(err)
+
+		authToken, err := s.tokenGenerator.GetNewTokenString(user)
+		s.Require().NoError(err)
+
+		return user, authToken
+	}
+
+	owner, ownerToken := newUser("adminOwner", "14", models.RoleOwner)
+	_, auditorToken := newUser("adminAuditor", "15", models.RoleAuditor)
+	_, supportToken := newUser("adminSupport", "16", models.RoleSupport)
+	_, adminToken := newUser("adminAdmin", "17", models.RoleAdmin)
+
+	s.authToken = ownerToken
+	walletID := s.createWalletForConverter(owner.ID, "RUR", models.MustDecimal("1000"))
+
+	s.Run("owner can't use admin routes", func() {
+		s.authToken = ownerToken
+
+		resp := s.sendAPIRequest(context.Background(), http.MethodGet, "/admin/wallets/"+walletID.String(), nil, nil)
+		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+
+		resp = s.sendAPIRequest(context.Background(), http.MethodGet, "/admin/users?filterName=adminOwner", nil, nil)
+		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+	})
+
+	s.Run("auditor reads any wallet but can't freeze or use own wallets", func() {
+		s.authToken = auditorToken
+
+		wallet := new(models.Wallet)
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodGet,
+			"/admin/wallets/"+walletID.String(),
+			nil,
+			&rest.HTTPResponse{Data: &wallet},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(owner.ID, wallet.Owner)
+
+		wallets := new([]models.Wallet)
+		resp = s.sendAPIRequest(
+			context.Background(),
+			http.MethodGet,
+			"/admin/users/"+owner.ID.String()+"/wallets",
+			nil,
+			&rest.HTTPResponse{Data: &wallets},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(*wallets, 1)
+
+		transactions := new([]models.Transaction)
+		resp = s.sendAPIRequest(
+			context.Background(),
+			http.MethodGet,
+			"/admin/wallets/"+walletID.String()+"/transactions",
+			nil,
+			&rest.HTTPResponse{Data: &transactions},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().NotEmpty(*transactions)
+
+		users := new([]models.User)
+		resp = s.sendAPIRequest(
+			context.Background(),
+			http.MethodGet,
+			"/admin/users?filterName=adminOwner",
+			nil,
+			&rest.HTTPResponse{Data: &users},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(*users, 1)
+		s.Require().Equal(owner.ID, (*users)[0].ID)
+		s.Require().Empty((*users)[0].Password)
+
+		resp = s.sendAPIRequest(context.Background(), http.MethodPost, "/admin/wallets/"+walletID.String()+"/freeze", nil, nil)
+		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+
+		resp = s.sendRequest(context.Background(), http.MethodGet, "/", nil, nil)
+		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+	})
+
+	s.Run("owner can't read another user's transactions", func() {
+		_, otherToken := newUser("adminOther", "18", models.RoleOwner)
+		s.authToken = otherToken
+
+		resp := s.sendRequest(context.Background(), http.MethodGet, "/"+walletID.String()+"/transactions", nil, nil)
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+
+	s.Run("support freezes a wallet", func() {
+		s.authToken = supportToken
+
+		wallet := new(models.Wallet)
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/admin/wallets/"+walletID.String()+"/freeze",
+			nil,
+			&rest.HTTPResponse{Data: &wallet},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.WalletStatusFrozen, wallet.Status)
+
+		s.authToken = ownerToken
+
+		resp = s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/withdraw",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal("100"),
+				Currency:      "RUR",
+				OperationType: "withdraw",
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+
+		s.authToken = supportToken
+
+		resp = s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/admin/wallets/"+walletID.String()+"/unfreeze",
+			nil,
+			&rest.HTTPResponse{Data: &wallet},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.WalletStatusActive, wallet.Status)
+	})
+
+	s.Run("only admin manages roles", func() {
+		s.authToken = supportToken
+
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPut,
+			"/admin/users/"+owner.ID.String()+"/roles",
+			models.RolesUpdate{Roles: []string{models.RoleOwner, models.RoleAuditor}},
+			nil,
+		)
+		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+
+		s.authToken = adminToken
+
+		resp = s.sendAPIRequest(
+			context.Background(),
+			http.MethodPut,
+			"/admin/users/"+owner.ID.String()+"/roles",
+			models.RolesUpdate{Roles: []string{"superuser"}},
+			nil,
+		)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+
+		user := new(models.User)
+		resp = s.sendAPIRequest(
+			context.Background(),
+			http.MethodPut,
+			"/admin/users/"+owner.ID.String()+"/roles",
+			models.RolesUpdate{Roles: []string{models.RoleOwner, models.RoleAuditor}},
+			&rest.HTTPResponse{Data: &user},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().ElementsMatch([]string{models.RoleOwner, models.RoleAuditor}, user.Roles)
+	})
+}
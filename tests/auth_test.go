@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/password"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+)
+
+func (s *IntegrationTestSuite) TestAuth() {
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "authUser",
+		Email:    "authUser@mail.com",
+		Phone:    "12",
+		Password: "password12",
+	}
+	err := s.store.UpsertUser(context.Background(), testUser)
+	s.Require().NoError(err)
+
+	login := func(credentials models.Credentials) (*models.TokenPair, int) {
+		tokenPair := new(models.TokenPair)
+		resp := s.sendAPIRequest(context.Background(), http.MethodPost, "/auth/login", credentials, &rest.HTTPResponse{Data: &tokenPair})
+
+		return tokenPair, resp.StatusCode
+	}
+
+	refresh := func(refreshToken string) (*models.TokenPair, int) {
+		tokenPair := new(models.TokenPair)
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/auth/refresh",
+			models.RefreshRequest{RefreshToken: refreshToken},
+			&rest.HTTPResponse{Data: &tokenPair},
+		)
+
+		return tokenPair, resp.StatusCode
+	}
+
+	introspect := func(token string) *models.TokenIntrospection {
+		introspection := new(models.TokenIntrospection)
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/auth/introspect",
+			models.IntrospectionRequest{Token: token},
+			&rest.HTTPResponse{Data: &introspection},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		return introspection
+	}
+
+	s.Run("invalid credentials", func() {
+		_, status := login(models.Credentials{Login: testUser.Email, Password: "wrong"})
+		s.Require().Equal(http.StatusUnauthorized, status)
+
+		_, status = login(models.Credentials{Login: "nobody@mail.com", Password: testUser.Password})
+		s.Require().Equal(http.StatusUnauthorized, status)
+
+		_, status = login(models.Credentials{Login: testUser.Email})
+		s.Require().Equal(http.StatusBadRequest, status)
+	})
+
+	s.Run("username is not a login", func() {
+		_, status := login(models.Credentials{Login: testUser.Username, Password: testUser.Password})
+		s.Require().Equal(http.StatusUnauthorized, status)
+	})
+
+	s.Run("login, refresh and logout", func() {
+		tokenPair, status := login(models.Credentials{Login: testUser.Email, Password: testUser.Password})
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().Equal(models.TokenTypeBearer, tokenPair.TokenType)
+		s.Require().NotEmpty(tokenPair.RefreshToken)
+
+		s.authToken = tokenPair.AccessToken
+		resp := s.sendRequest(context.Background(), http.MethodGet, "", nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		introspection := introspect(tokenPair.AccessToken)
+		s.Require().True(introspection.Active)
+		s.Require().Equal(models.TokenTypeAccess, introspection.TokenType)
+		s.Require().Equal(testUser.ID, *introspection.Subject)
+
+		refreshed, status := refresh(tokenPair.RefreshToken)
+		s.Require().Equal(http.StatusOK, status)
+		s.Require().NotEqual(tokenPair.RefreshToken, refreshed.RefreshToken)
+		s.Require().False(introspect(tokenPair.RefreshToken).Active)
+		s.Require().True(introspect(refreshed.RefreshToken).Active)
+
+		resp = s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/auth/logout",
+			models.RefreshRequest{RefreshToken: refreshed.RefreshToken},
+			nil,
+		)
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+
+		_, status = refresh(refreshed.RefreshToken)
+		s.Require().Equal(http.StatusUnauthorized, status)
+		s.Require().False(introspect(refreshed.AccessToken).Active)
+	})
+
+	s.Run("reused refresh token revokes the session", func() {
+		tokenPair, status := login(models.Credentials{Login: testUser.Phone, Password: testUser.Password})
+		s.Require().Equal(http.StatusOK, status)
+
+		refreshed, status := refresh(tokenPair.RefreshToken)
+		s.Require().Equal(http.StatusOK, status)
+
+		_, status = refresh(tokenPair.RefreshToken)
+		s.Require().Equal(http.StatusUnauthorized, status)
+
+		_, status = refresh(refreshed.RefreshToken)
+		s.Require().Equal(http.StatusUnauthorized, status)
+	})
+
+	s.Run("plaintext password is hashed on login", func() {
+		user, err := s.store.GetUserByID(context.Background(), testUser.ID)
+		s.Require().NoError(err)
+		s.Require().True(password.IsHash(user.Password))
+
+		_, status := login(models.Credentials{Login: testUser.Email, Password: testUser.Password})
+		s.Require().Equal(http.StatusOK, status)
+	})
+
+	s.Run("unknown token is inactive", func() {
+		s.Require().False(introspect("unknown").Active)
+	})
+}
+
+func (s *IntegrationTestSuite) TestMigratePlaintextPasswords() {
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "plaintextUser",
+		Email:    "plaintextUser@mail.com",
+		Phone:    "13",
+		Password: "password13",
+	}
+	err := s.store.UpsertUser(context.Background(), testUser)
+	s.Require().NoError(err)
+
+	hashLikeUser := models.User{
+		ID:       uuid.New(),
+		Username: "hashLikeUser",
+		Email:    "hashLikeUser@mail.com",
+		Phone:    "34",
+		Password: "$2a$password34",
+	}
+	err = s.store.UpsertUser(context.Background(), hashLikeUser)
+	s.Require().NoError(err)
+
+	err = s.service.MigratePlaintextPasswords(context.Background())
+	s.Require().NoError(err)
+
+	for _, migratedUser := range []models.User{testUser, hashLikeUser} {
+		user, err := s.store.GetUserByID(context.Background(), migratedUser.ID)
+		s.Require().NoError(err)
+		s.Require().True(password.IsHash(user.Password))
+		s.Require().NotEqual(migratedUser.Password, user.Password)
+
+		_, err = s.service.Login(context.Background(), models.Credentials{Login: migratedUser.Email, Password: migratedUser.Password})
+		s.Require().NoError(err)
+	}
+}
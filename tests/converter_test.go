@@ -0,0 +1,424 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/converter"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+const cbrResponse = `<?xml version="1.0" encoding="windows-1251"?>
+<ValCurs ID="%[1]s" DateRange1="09.10.2026" DateRange2="16.10.2026" name="Foreign Currency Market Dynamic">
+<Record Date="15.10.2026" Id="%[1]s"><Nominal>1</Nominal><Value>11,0000</Value><VunitRate>11</VunitRate></Record>
+<Record Date="16.10.2026" Id="%[1]s"><Nominal>10</Nominal><Value>120,0000</Value><VunitRate>12</VunitRate></Record>
+</ValCurs>`
+
+const ecbResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-10-16">
+			<Cube currency="USD" rate="1.25"/>
+			<Cube currency="CNY" rate="8"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+const ecbHistoryResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-10-16">
+			<Cube currency="USD" rate="1.25"/>
+		</Cube>
+		<Cube time="2026-10-15">
+			<Cube currency="USD" rate="1.2"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+type memoryRateStore struct {
+	mu    sync.Mutex
+	rates map[string]models.ExchangeRate
+}
+
+func newMemoryRateStore() *memoryRateStore {
+	return &memoryRateStore{rates: make(map[string]models.ExchangeRate)}
+}
+
+func (m *memoryRateStore) SaveExchangeRate(_ context.Context, rate models.ExchangeRate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rates[rate.Source+":"+rate.Currency+":"+rate.Date.Format(time.DateOnly)] = rate
+
+	return nil
+}
+
+func (m *memoryRateStore) GetLatestExchangeRate(ctx context.Context, source, currency string) (*models.ExchangeRate, error) {
+	return m.GetExchangeRateAt(ctx, source, currency, time.Now())
+}
+
+func (m *memoryRateStore) GetExchangeRateAt(_ context.Context, source, currency string, date time.Time) (
+	*models.ExchangeRate, error,
+) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var latest *models.ExchangeRate
+
+	for _, rate := range m.rates {
+		if rate.Source != source || rate.Currency != currency || rate.Date.After(date) {
+			continue
+		}
+
+		if latest == nil || rate.Date.After(latest.Date) {
+			latest = &rate
+		}
+	}
+
+	if latest == nil {
+		return nil, models.ErrExchangeRateNotFound
+	}
+
+	return latest, nil
+}
+
+// rateServer serves the body until it is switched down, counting requests.
+type rateServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	down     atomic.Bool
+}
+
+func newRateServer(t *testing.T, body func(r *http.Request) string) *rateServer {
+	t.Helper()
+
+	server := &rateServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.requests.Add(1)
+
+		if server.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		_, _ = fmt.Fprint(w, body(r))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func convert(t *testing.T, xrConverter *converter.Converter, amount, from, to string) models.Decimal {
+	t.Helper()
+
+	result, err := xrConverter.Convert(
+		context.Background(),
+		converter.Currency{Amount: models.MustDecimal(amount), Name: from},
+		converter.Currency{Name: to},
+	)
+	require.NoError(t, err)
+
+	return result.Amount
+}
+
+func TestConverter(t *testing.T) {
+	cbr := newRateServer(t, func(r *http.Request) string {
+		return fmt.Sprintf(cbrResponse, r.URL.Query().Get("VAL_NM_RQ"))
+	})
+	ecb := newRateServer(t, func(r *http.Request) string {
+		if r.URL.Path == "/history" {
+			return ecbHistoryResponse
+		}
+
+		return ecbResponse
+	})
+
+	staticFile := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(staticFile, []byte(`{"base": "RUR", "date": "2026-10-10", "rates": {"USD": "100"}}`), 0o600)
+	require.NoError(t, err)
+
+	newConverter := func(t *testing.T, store *memoryRateStore, rateTTL time.Duration, names ...string) *converter.Converter {
+		t.Helper()
+
+		providers, err := converter.NewProviders(names, converter.ProvidersConfig{
+			CBRHost:         cbr.URL + "/",
+			ECBURL:          ecb.URL,
+			ECBHistoryURL:   ecb.URL + "/history",
+			StaticRatesFile: staticFile,
+			RequestTimeout:  time.Second,
+		})
+		require.NoError(t, err)
+
+		return converter.NewConverter(converter.Config{RateTTL: rateTTL}, providers, store)
+	}
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := converter.NewProviders([]string{"cbr", "fixer"}, converter.ProvidersConfig{})
+		require.Error(t, err)
+	})
+
+	t.Run("cbr uses the latest record and persists it", func(t *testing.T) {
+		store := newMemoryRateStore()
+		xrConverter := newConverter(t, store, time.Hour, models.RateSourceCBR)
+
+		require.True(t, models.MustDecimal("120").Equal(convert(t, xrConverter, "10", "CHY", "RUR")))
+
+		stored, err := store.GetLatestExchangeRate(context.Background(), models.RateSourceCBR, "CHY")
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), stored.Date)
+		require.Equal(t, "RUR", stored.Base)
+
+		requests := cbr.requests.Load()
+
+		convert(t, xrConverter, "10", "CHY", "RUR")
+		require.Equal(t, requests, cbr.requests.Load(), "cached rate must not call the provider")
+	})
+
+	t.Run("ecb quotes pairs through the euro", func(t *testing.T) {
+		xrConverter := newConverter(t, newMemoryRateStore(), time.Hour, models.RateSourceECB)
+
+		require.True(t, models.MustDecimal("125").Equal(convert(t, xrConverter, "100", "EUR", "USD")))
+
+		conversion, err := xrConverter.Convert(
+			context.Background(),
+			converter.Currency{Amount: models.MustDecimal("80"), Name: "CHY"},
+			converter.Currency{Name: "USD"},
+		)
+		require.NoError(t, err)
+		require.True(t, models.MustDecimal("0.15625").Equal(conversion.Rate))
+		require.Equal(t, models.RateSourceECB, conversion.RateSource)
+		require.Equal(t, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), conversion.RateDate)
+
+		require.True(t, models.MustDecimal("800").Equal(convert(t, xrConverter, "100", "EUR", "CHY")))
+		require.True(t, models.MustDecimal("12.5").Equal(convert(t, xrConverter, "80", "CHY", "USD")))
+
+		_, err = xrConverter.Convert(
+			context.Background(),
+			converter.Currency{Amount: models.MustDecimal("1"), Name: "AED"},
+			converter.Currency{Name: "EUR"},
+		)
+		require.ErrorIs(t, err, models.ErrExchangeRateNotFound)
+	})
+
+	t.Run("static file", func(t *testing.T) {
+		xrConverter := newConverter(t, newMemoryRateStore(), time.Hour, models.RateSourceStatic)
+
+		require.True(t, models.MustDecimal("1000").Equal(convert(t, xrConverter, "10", "USD", "RUR")))
+	})
+
+	t.Run("next provider is used while the first is down", func(t *testing.T) {
+		cbr.down.Store(true)
+		defer cbr.down.Store(false)
+
+		xrConverter := newConverter(t, newMemoryRateStore(), time.Hour, models.RateSourceCBR, models.RateSourceStatic)
+
+		require.True(t, models.MustDecimal("1000").Equal(convert(t, xrConverter, "10", "USD", "RUR")))
+
+		requests := cbr.requests.Load()
+
+		convert(t, xrConverter, "10", "USD", "RUR")
+		require.Equal(t, requests, cbr.requests.Load(), "failed provider must be skipped for a while")
+	})
+
+	t.Run("stale stored rate is used while all providers are down", func(t *testing.T) {
+		store := newMemoryRateStore()
+		convert(t, newConverter(t, store, time.Hour, models.RateSourceECB), "1", "EUR", "USD")
+
+		ecb.down.Store(true)
+		defer ecb.down.Store(false)
+
+		xrConverter := newConverter(t, store, time.Nanosecond, models.RateSourceECB)
+		require.True(t, models.MustDecimal("125").Equal(convert(t, xrConverter, "100", "EUR", "USD")))
+
+		_, err := xrConverter.Convert(
+			context.Background(),
+			converter.Currency{Amount: models.MustDecimal("1"), Name: "CHY"},
+			converter.Currency{Name: "EUR"},
+		)
+		require.ErrorIs(t, err, models.ErrExchangeRateNotFound)
+	})
+}
+
+func TestConverterAtDate(t *testing.T) {
+	cbr := newRateServer(t, func(r *http.Request) string {
+		return fmt.Sprintf(cbrResponse, r.URL.Query().Get("VAL_NM_RQ"))
+	})
+	ecb := newRateServer(t, func(r *http.Request) string {
+		if r.URL.Path == "/history" {
+			return ecbHistoryResponse
+		}
+
+		return ecbResponse
+	})
+
+	newConverter := func(t *testing.T, store *memoryRateStore, names ...string) *converter.Converter {
+		t.Helper()
+
+		providers, err := converter.NewProviders(names, converter.ProvidersConfig{
+			CBRHost:        cbr.URL + "/",
+			ECBURL:         ecb.URL,
+			ECBHistoryURL:  ecb.URL + "/history",
+			RequestTimeout: time.Second,
+		})
+		require.NoError(t, err)
+
+		return converter.NewConverter(converter.Config{RateTTL: time.Hour}, providers, store)
+	}
+
+	convertAt := func(xrConverter *converter.Converter, from, to string, date time.Time) (*models.Conversion, error) {
+		return xrConverter.ConvertAt(
+			context.Background(),
+			converter.Currency{Amount: models.MustDecimal("100"), Name: from},
+			converter.Currency{Name: to},
+			date,
+		)
+	}
+
+	t.Run("cbr rate published on the date", func(t *testing.T) {
+		xrConverter := newConverter(t, newMemoryRateStore(), models.RateSourceCBR)
+
+		conversion, err := convertAt(xrConverter, "INR", "RUR", time.Date(2026, 10, 15, 13, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.True(t, models.MustDecimal("1100").Equal(conversion.Amount))
+		require.Equal(t, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), conversion.RateDate)
+
+		requests := cbr.requests.Load()
+
+		_, err = convertAt(xrConverter, "INR", "RUR", time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.Equal(t, requests, cbr.requests.Load(), "stored rate of the date must not call the provider")
+	})
+
+	t.Run("weekend uses the rate of the last working day", func(t *testing.T) {
+		conversion, err := convertAt(newConverter(t, newMemoryRateStore(), models.RateSourceCBR),
+			"INR", "RUR", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.True(t, models.MustDecimal("1200").Equal(conversion.Amount))
+		require.Equal(t, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), conversion.RateDate)
+	})
+
+	t.Run("ecb reads past dates from the history feed", func(t *testing.T) {
+		xrConverter := newConverter(t, newMemoryRateStore(), models.RateSourceECB)
+
+		conversion, err := convertAt(xrConverter, "EUR", "USD", time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.True(t, models.MustDecimal("120").Equal(conversion.Amount))
+		require.Equal(t, models.RateSourceECB, conversion.RateSource)
+
+		t.Run("date before the history", func(t *testing.T) {
+			_, err := convertAt(xrConverter, "EUR", "USD", time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC))
+			require.ErrorIs(t, err, models.ErrExchangeRateNotFound)
+
+			requests := ecb.requests.Load()
+
+			_, err = convertAt(xrConverter, "EUR", "USD", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+			require.NoError(t, err)
+			require.Greater(t, ecb.requests.Load(), requests, "provider without the rate must not be skipped")
+		})
+	})
+
+	t.Run("nearest stored rate is used while the provider is down", func(t *testing.T) {
+		store := newMemoryRateStore()
+
+		_, err := convertAt(newConverter(t, store, models.RateSourceECB), "EUR", "USD", time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+
+		ecb.down.Store(true)
+		defer ecb.down.Store(false)
+
+		conversion, err := convertAt(newConverter(t, store, models.RateSourceECB), "EUR", "USD", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		require.True(t, models.MustDecimal("120").Equal(conversion.Amount))
+		require.Equal(t, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), conversion.RateDate)
+	})
+}
+
+func TestParseRateRequest(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+
+	request, err := models.ParseRateRequest("USD/RUR", "2026-10-10", now)
+	require.NoError(t, err)
+	require.Equal(t, models.RateRequest{
+		CurrencyFrom: "USD",
+		CurrencyTo:   "RUR",
+		Date:         time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC),
+	}, *request)
+
+	request, err = models.ParseRateRequest("USD/RUR", "", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), request.Date)
+
+	_, err = models.ParseRateRequest("USDRUR", "", now)
+	require.ErrorIs(t, err, models.ErrInvalidCurrencyPair)
+
+	_, err = models.ParseRateRequest("USD/XXX", "", now)
+	require.ErrorIs(t, err, models.ErrCurrencyNotAllowed)
+
+	_, err = models.ParseRateRequest("USD/RUR", "10.10.2026", now)
+	require.ErrorIs(t, err, models.ErrInvalidRateDate)
+
+	_, err = models.ParseRateRequest("USD/RUR", "2026-10-18", now)
+	require.ErrorIs(t, err, models.ErrInvalidRateDate)
+}
+
+func (s *IntegrationTestSuite) TestExchangeRatesStore() {
+	ctx := context.Background()
+
+	older := models.ExchangeRate{
+		Currency:  "INR",
+		Base:      "RUR",
+		Date:      time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+		Rate:      models.MustDecimal("1.1"),
+		Source:    models.RateSourceCBR,
+		FetchedAt: time.Now(),
+	}
+	s.Require().NoError(s.store.SaveExchangeRate(ctx, older))
+
+	latest := older
+	latest.Date = older.Date.AddDate(0, 0, 1)
+	latest.Rate = models.MustDecimal("1.2")
+	s.Require().NoError(s.store.SaveExchangeRate(ctx, latest))
+
+	latest.Rate = models.MustDecimal("1.25")
+	s.Require().NoError(s.store.SaveExchangeRate(ctx, latest))
+
+	otherSource := latest
+	otherSource.Source = models.RateSourceECB
+	otherSource.Base = "EUR"
+	otherSource.Date = latest.Date.AddDate(0, 0, 1)
+	otherSource.Rate = models.MustDecimal("0.011")
+	s.Require().NoError(s.store.SaveExchangeRate(ctx, otherSource))
+
+	rate, err := s.store.GetLatestExchangeRate(ctx, models.RateSourceCBR, "INR")
+	s.Require().NoError(err)
+	s.Require().Equal(latest.Date, rate.Date.UTC())
+	s.Require().Equal("RUR", rate.Base)
+	s.Require().True(models.MustDecimal("1.25").Equal(rate.Rate))
+
+	_, err = s.store.GetLatestExchangeRate(ctx, models.RateSourceCBR, "XXX")
+	s.Require().ErrorIs(err, models.ErrExchangeRateNotFound)
+
+	rate, err = s.store.GetExchangeRateAt(ctx, models.RateSourceCBR, "INR", older.Date)
+	s.Require().NoError(err)
+	s.Require().True(models.MustDecimal("1.1").Equal(rate.Rate))
+
+	rate, err = s.store.GetExchangeRateAt(ctx, models.RateSourceCBR, "INR", latest.Date.AddDate(0, 0, 3))
+	s.Require().NoError(err)
+	s.Require().Equal(latest.Date, rate.Date.UTC())
+
+	_, err = s.store.GetExchangeRateAt(ctx, models.RateSourceCBR, "INR", older.Date.AddDate(0, 0, -1))
+	s.Require().ErrorIs(err, models.ErrExchangeRateNotFound)
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCron(t *testing.T) {
+	t.Run("parse errors", func(t *testing.T) {
+		for _, expression := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+			_, err := models.ParseCron(expression)
+			require.ErrorIs(t, err, models.ErrInvalidCron, expression)
+		}
+	})
+
+	t.Run("next activation", func(t *testing.T) {
+		cron, err := models.ParseCron("30 9 * * 1-5")
+		require.NoError(t, err)
+
+		// Friday evening is followed by Monday morning.
+		next, ok := cron.Next(time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC))
+		require.True(t, ok)
+		require.Equal(t, time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC), next)
+	})
+
+	t.Run("steps and lists", func(t *testing.T) {
+		cron, err := models.ParseCron("*/20 0 1,15 * *")
+		require.NoError(t, err)
+
+		next, ok := cron.Next(time.Date(2026, 10, 1, 0, 20, 0, 0, time.UTC))
+		require.True(t, ok)
+		require.Equal(t, time.Date(2026, 10, 1, 0, 40, 0, 0, time.UTC), next)
+
+		next, ok = cron.Next(next)
+		require.True(t, ok)
+		require.Equal(t, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("never matching expression", func(t *testing.T) {
+		cron, err := models.ParseCron("0 0 30 2 *")
+		require.NoError(t, err)
+
+		_, ok := cron.Next(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+		require.False(t, ok)
+	})
+}
+
+func TestScheduleNextRun(t *testing.T) {
+	runAt := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("once", func(t *testing.T) {
+		schedule := models.Schedule{Recurrence: models.RecurrenceOnce, RunAt: runAt}
+
+		next, ok := schedule.NextRun(runAt.Add(-time.Hour))
+		require.True(t, ok)
+		require.Equal(t, runAt, next)
+
+		_, ok = schedule.NextRun(runAt)
+		require.False(t, ok)
+	})
+
+	t.Run("weekly", func(t *testing.T) {
+		wednesday := int(time.Wednesday)
+		schedule := models.Schedule{Recurrence: models.RecurrenceWeekly, RunAt: runAt, Weekday: &wednesday}
+
+		next, ok := schedule.NextRun(time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC))
+		require.True(t, ok)
+		require.Equal(t, time.Date(2026, 10, 21, 10, 0, 0, 0, time.UTC), next)
+
+		next, ok = schedule.NextRun(time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC))
+		require.True(t, ok)
+		require.Equal(t, time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC), next)
+	})
+
+	t.Run("monthly clamps to the last day of the month", func(t *testing.T) {
+		day := 31
+		schedule := models.Schedule{Recurrence: models.RecurrenceMonthly, RunAt: runAt, DayOfMonth: &day}
+
+		next, ok := schedule.NextRun(time.Date(2027, 1, 31, 12, 0, 0, 0, time.UTC))
+		require.True(t, ok)
+		require.Equal(t, time.Date(2027, 2, 28, 10, 0, 0, 0, time.UTC), next)
+
+		next, ok = schedule.NextRun(next)
+		require.True(t, ok)
+		require.Equal(t, time.Date(2027, 3, 31, 10, 0, 0, 0, time.UTC), next)
+	})
+}
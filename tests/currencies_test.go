@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+	"github.com/stretchr/testify/require"
+)
+
+// resetCurrencies registers the built-in currencies only, dropping those added by previous runs.
+func (s *IntegrationTestSuite) resetCurrencies(ctx context.Context) {
+	s.Require().NoError(s.store.Truncate(ctx, "currencies"))
+
+	for _, code := range models.GetCurrencies() {
+		currency, _ := models.LookupCurrency(code)
+
+		_, err := s.store.CreateCurrency(ctx, currency)
+		s.Require().NoError(err)
+	}
+}
+
+func TestCurrencyRegistry(t *testing.T) {
+	t.Run("invalid currencies", func(t *testing.T) {
+		for _, invalid := range []models.Currency{
+			{Code: "gbp", ISOCode: "GBP", NumericCode: 826, MinorUnits: 2},
+			{Code: "GBP", ISOCode: "", NumericCode: 826, MinorUnits: 2},
+			{Code: "GBP", ISOCode: "GBP", NumericCode: 0, MinorUnits: 2},
+			{Code: "GBP", ISOCode: "GBP", NumericCode: 826, MinorUnits: 9},
+			{Code: "GBP", ISOCode: "GBP", NumericCode: 826, MinorUnits: 2, ProviderIDs: map[string]string{"cbr": ""}},
+		} {
+			require.ErrorIs(t, invalid.Validate(), models.ErrInvalidCurrency)
+		}
+	})
+
+	t.Run("validation reads the registry", func(t *testing.T) {
+		registered := make([]*models.Currency, 0)
+
+		for _, code := range models.GetCurrencies() {
+			currency, _ := models.LookupCurrency(code)
+			registered = append(registered, &currency)
+		}
+
+		t.Cleanup(func() { models.SetCurrencies(registered) })
+
+		models.SetCurrencies([]*models.Currency{
+			{Code: "RUR", ISOCode: "RUB", NumericCode: 643, MinorUnits: 2, Enabled: true},
+			{Code: "JPY", ISOCode: "JPY", NumericCode: 392, MinorUnits: 0, Enabled: true},
+			{Code: "AED", ISOCode: "AED", NumericCode: 784, MinorUnits: 2, Enabled: false},
+		})
+
+		wallet := models.Wallet{Owner: uuid.New(), Name: "wallet", Currency: "JPY"}
+		require.NoError(t, wallet.Validate())
+
+		wallet.Currency = "AED"
+		require.ErrorIs(t, wallet.Validate(), models.ErrCurrencyNotAllowed)
+
+		wallet.Currency = "CHY"
+		require.ErrorIs(t, wallet.Validate(), models.ErrCurrencyNotAllowed)
+
+		require.Equal(t, []string{"JPY", "RUR"}, models.GetCurrencies())
+		require.True(t, models.MustDecimal("13").Equal(models.MustDecimal("12.5").RoundForCurrency("JPY")))
+	})
+}
+
+func (s *IntegrationTestSuite) TestCurrencies() {
+	ctx := context.Background()
+
+	admin := models.User{
+		ID:       uuid.New(),
+		Username: "currenciesAdmin",
+		Email:    "currenciesAdmin@mail.com",
+		Phone:    "22",
+		Password: "password22",
+		Roles:    []string{models.RoleAdmin},
+	}
+	s.Require().NoError(s.store.UpsertUser(ctx, admin))
+	s.Require().NoError(s.store.SetUserRoles(ctx, admin.ID, admin.Roles))
+
+	authToken, err := s.tokenGenerator.GetNewTokenString(admin)
+	s.Require().NoError(err)
+
+	s.authToken = authToken
+
+	createWallet := func() *http.Response {
+		return s.sendRequest(ctx, http.MethodPost, "/", models.Wallet{Owner: admin.ID, Name: "GBP", Currency: "GBP"}, nil)
+	}
+
+	s.Run("unknown currency is not allowed", func() {
+		s.Require().Equal(http.StatusBadRequest, createWallet().StatusCode)
+	})
+
+	s.Run("register currency", func() {
+		currency := new(models.Currency)
+		resp := s.sendAPIRequest(
+			ctx,
+			http.MethodPost,
+			"/admin/currencies",
+			models.Currency{Code: "GBP", ISOCode: "GBP", NumericCode: 826, MinorUnits: 2, Enabled: true},
+			&rest.HTTPResponse{Data: &currency},
+		)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal("GBP", currency.Code)
+		s.Require().True(currency.Enabled)
+
+		s.Require().Equal(http.StatusCreated, createWallet().StatusCode)
+
+		s.Run("duplicate", func() {
+			resp := s.sendAPIRequest(
+				ctx,
+				http.MethodPost,
+				"/admin/currencies",
+				models.Currency{Code: "GBP", ISOCode: "GBP", NumericCode: 826, MinorUnits: 2, Enabled: true},
+				nil,
+			)
+			s.Require().Equal(http.StatusConflict, resp.StatusCode)
+		})
+
+		s.Run("invalid", func() {
+			resp := s.sendAPIRequest(
+				ctx,
+				http.MethodPost,
+				"/admin/currencies",
+				models.Currency{Code: "GB", ISOCode: "GBP", NumericCode: 826, MinorUnits: 2},
+				nil,
+			)
+			s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+		})
+	})
+
+	s.Run("disable currency", func() {
+		enabled := false
+		currency := new(models.Currency)
+		resp := s.sendAPIRequest(
+			ctx,
+			http.MethodPut,
+			"/admin/currencies/GBP",
+			models.CurrencyDTO{Enabled: &enabled, ProviderIDs: map[string]string{models.RateSourceECB: "GBP"}},
+			&rest.HTTPResponse{Data: &currency},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().False(currency.Enabled)
+		s.Require().Equal("GBP", currency.ProviderIDs[models.RateSourceECB])
+
+		s.Require().Equal(http.StatusBadRequest, createWallet().StatusCode)
+
+		currencies := new([]models.Currency)
+		resp = s.sendAPIRequest(ctx, http.MethodGet, "/admin/currencies", nil, &rest.HTTPResponse{Data: &currencies})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Contains(*currencies, *currency)
+	})
+
+	s.Run("invalid update", func() {
+		numericCode := 0
+		resp := s.sendAPIRequest(ctx, http.MethodPut, "/admin/currencies/GBP", models.CurrencyDTO{NumericCode: &numericCode}, nil)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("unknown currency", func() {
+		enabled := true
+		resp := s.sendAPIRequest(ctx, http.MethodPut, "/admin/currencies/XXX", models.CurrencyDTO{Enabled: &enabled}, nil)
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimal(t *testing.T) {
+	t.Run("arithmetic is exact", func(t *testing.T) {
+		sum := models.MustDecimal("0.1").Add(models.MustDecimal("0.2"))
+		require.Equal(t, "0.3", sum.String())
+		require.True(t, sum.Equal(models.MustDecimal("0.3")))
+	})
+
+	t.Run("round half away from zero", func(t *testing.T) {
+		require.Equal(t, "2.35", models.MustDecimal("2.345").Round(2).String())
+		require.Equal(t, "-2.35", models.MustDecimal("-2.345").Round(2).String())
+		require.Equal(t, "2.34", models.MustDecimal("2.3449").Round(2).String())
+	})
+
+	t.Run("division keeps precision until rounded", func(t *testing.T) {
+		third, err := models.NewDecimalFromInt(1).Div(models.NewDecimalFromInt(3))
+		require.NoError(t, err)
+		require.Equal(t, "0.333333333333333333", third.String())
+		require.Equal(t, "0.33", third.RoundForCurrency("RUR").String())
+
+		_, err = third.Div(models.Decimal{})
+		require.ErrorIs(t, err, models.ErrDivisionByZero)
+	})
+
+	t.Run("json encodes as string and accepts numbers", func(t *testing.T) {
+		payload, err := json.Marshal(models.Wallet{Balance: models.MustDecimal("10.50")})
+		require.NoError(t, err)
+		require.Contains(t, string(payload), `"balance":"10.5"`)
+
+		var transaction models.Transaction
+		require.NoError(t, json.Unmarshal([]byte(`{"amount": 12.34, "exRate": "0.1234"}`), &transaction))
+		require.Equal(t, "12.34", transaction.Amount.String())
+		require.Equal(t, "0.1234", transaction.ExRate.String())
+
+		require.Error(t, json.Unmarshal([]byte(`{"amount": "abc"}`), &transaction))
+	})
+
+	t.Run("only plain decimals are parsed", func(t *testing.T) {
+		require.Equal(t, "1500", models.MustDecimal("1.5e3").String())
+		require.Equal(t, "0.0123", models.MustDecimal("1.23E-02").String())
+		require.Equal(t, "-0.01", models.MustDecimal(" -0.01 ").String())
+
+		for _, value := range []string{
+			"", "0x10", "0b11", "0o17", "1_000", "0x1p-2", "1/3", "+1", ".5", "1.", "1e", "Inf", "1e100", "1e999999", "1e-999999",
+		} {
+			_, err := models.ParseDecimal(value)
+			require.ErrorIs(t, err, models.ErrInvalidDecimal, value)
+		}
+	})
+
+	t.Run("numeric round trip", func(t *testing.T) {
+		value := models.MustDecimal("-1234.5678")
+
+		numeric, err := value.NumericValue()
+		require.NoError(t, err)
+
+		var scanned models.Decimal
+		require.NoError(t, scanned.ScanNumeric(numeric))
+		require.True(t, value.Equal(scanned))
+	})
+}
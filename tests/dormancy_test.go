@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDormancy(t *testing.T) {
+	lastActivity := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	year := 365 * 24 * time.Hour
+	month := 30 * 24 * time.Hour
+
+	require.Equal(t, lastActivity.Add(year), models.DormantAt(lastActivity, lastActivity.Add(year-month), year, month))
+	require.Equal(t, lastActivity.Add(year+month), models.DormantAt(lastActivity, lastActivity.Add(year), year, month))
+
+	wallet := models.Wallet{Status: models.WalletStatusDormant}
+	require.ErrorIs(t, wallet.CheckActive(), models.ErrWalletDormant)
+	require.ErrorIs(t, wallet.CheckDebitable(), models.ErrWalletDormant)
+	require.True(t, wallet.CanChangeStatus(models.WalletStatusActive))
+
+	wallet.Status = models.WalletStatusFrozen
+	require.False(t, wallet.CanChangeStatus(models.WalletStatusDormant))
+
+	run := models.DormancyRun{Wallets: []models.DormancyRunWallet{
+		{Action: models.DormancyActionWarned},
+		{Action: models.DormancyActionDormant},
+		{Action: models.DormancyActionWarned},
+	}}
+	require.Equal(t, 2, run.Count(models.DormancyActionWarned))
+	require.Equal(t, 1, run.Count(models.DormancyActionDormant))
+}
+
+func (s *IntegrationTestSuite) TestDormancyPolicy() {
+	ctx := context.Background()
+
+	newUser := func(name, phone string, roles ...string) (models.User, string) {
+		user := models.User{
+			ID:       uuid.New(),
+			Username: name,
+			Email:    name + "@mail.com",
+			Phone:    phone,
+			Password: "password" + phone,
+			Roles:    roles,
+		}
+		s.Require().NoError(s.store.UpsertUser(ctx, user))
+		s.Require().NoError(s.store.SetUserRoles(ctx, user.ID, roles))
+
+		authToken, err := s.tokenGenerator.GetNewTokenString(user)
+		s.Require().NoError(err)
+
+		return user, authToken
+	}
+
+	owner, ownerToken := newUser("dormancyOwner", "30", models.RoleOwner)
+	_, adminToken := newUser("dormancyAdmin", "31", models.RoleAdmin)
+
+	s.authToken = ownerToken
+
+	emptyID := s.createWalletForConverter(owner.ID, "RUR", models.MustDecimal("0"))
+	fundedID := s.createWalletForConverter(owner.ID, "RUR", models.MustDecimal("10"))
+	savingsID := s.createWalletForConverter(owner.ID, "RUR", models.MustDecimal("0"))
+
+	errRollback := errors.New("rollback")
+
+	// inTx runs the checks within a transaction that is rolled back, so that wallets of other tests
+	// are left as they are.
+	inTx := func(fn func(ctx context.Context)) {
+		err := s.store.DoWithTx(ctx, func(ctx context.Context) error {
+			fn(ctx)
+
+			return errRollback
+		})
+		s.Require().ErrorIs(err, errRollback)
+	}
+
+	walletIDs := func(wallets []*models.Wallet, err error) map[uuid.UUID]bool {
+		s.Require().NoError(err)
+
+		ids := make(map[uuid.UUID]bool)
+		for _, wallet := range wallets {
+			ids[wallet.ID] = true
+		}
+
+		return ids
+	}
+
+	s.Run("mark wallet as savings", func() {
+		savings := true
+		wallet := new(models.Wallet)
+		resp := s.sendRequest(ctx, http.MethodPatch, "/"+savingsID.String(), models.WalletDTO{Savings: &savings}, &rest.HTTPResponse{Data: &wallet})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().True(wallet.Savings)
+	})
+
+	s.Run("only empty wallets that aren't savings are warned and made dormant", func() {
+		inTx(func(ctx context.Context) {
+			now := time.Now()
+
+			toWarn := walletIDs(s.store.GetWalletsToWarn(ctx, now.Add(time.Second), 10000))
+			s.Require().True(toWarn[emptyID])
+			s.Require().False(toWarn[fundedID])
+			s.Require().False(toWarn[savingsID])
+
+			s.Require().False(walletIDs(s.store.GetWalletsToMakeDormant(ctx, now.Add(time.Second), now.Add(time.Minute), 10000))[emptyID])
+
+			s.Require().NoError(s.store.MarkDormancyWarned(ctx, emptyID, now))
+
+			s.Require().False(walletIDs(s.store.GetWalletsToWarn(ctx, now.Add(time.Second), 10000))[emptyID])
+			s.Require().False(walletIDs(s.store.GetWalletsToMakeDormant(ctx, now.Add(time.Second), now, 10000))[emptyID])
+			s.Require().True(walletIDs(s.store.GetWalletsToMakeDormant(ctx, now.Add(time.Second), now.Add(time.Minute), 10000))[emptyID])
+		})
+	})
+
+	s.Run("owner reactivates a dormant wallet", func() {
+		_, err := s.service.SetWalletStatus(ctx, emptyID, uuid.Nil, models.WalletStatusDormant, "inactive")
+		s.Require().NoError(err)
+
+		deposit := func() int {
+			return s.sendRequest(ctx, http.MethodPut, "/deposit", models.Transaction{
+				WalletID:      emptyID,
+				Amount:        models.MustDecimal("5"),
+				Currency:      "RUR",
+				OperationType: models.OperationDeposit,
+			}, nil).StatusCode
+		}
+
+		s.Require().Equal(http.StatusConflict, deposit())
+
+		wallet := new(models.Wallet)
+		resp := s.sendAPIRequest(ctx, http.MethodPost, "/wallets/"+emptyID.String()+"/reactivate", nil, &rest.HTTPResponse{Data: &wallet})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.WalletStatusActive, wallet.Status)
+
+		s.Require().Equal(http.StatusOK, deposit())
+	})
+
+	s.Run("runs are reported to staff", func() {
+		run := models.DormancyRun{
+			ID:        uuid.New(),
+			StartedAt: time.Now(),
+			Wallets: []models.DormancyRunWallet{{
+				WalletID:       savingsID,
+				OwnerID:        owner.ID,
+				Action:         models.DormancyActionWarned,
+				LastActivityAt: time.Now().Add(-time.Hour),
+			}},
+		}
+		s.Require().NoError(s.store.SaveDormancyRun(ctx, run))
+
+		resp := s.sendAPIRequest(ctx, http.MethodGet, "/admin/wallets/dormancy-runs", nil, nil)
+		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+
+		s.authToken = adminToken
+
+		runs := new([]models.DormancyRun)
+		resp = s.sendAPIRequest(ctx, http.MethodGet, "/admin/wallets/dormancy-runs", nil, &rest.HTTPResponse{Data: &runs})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().NotEmpty(*runs)
+		s.Require().Equal(run.ID, (*runs)[0].ID)
+		s.Require().Len((*runs)[0].Wallets, 1)
+		s.Require().Equal(savingsID, (*runs)[0].Wallets[0].WalletID)
+	})
+}
@@ -0,0 +1,332 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+	"github.com/iurikman/cashFlowManager/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+// testFeeSchedule only charges business-tier users converting AED to RUR, so that the fees don't
+// affect the rest of the suite.
+func testFeeSchedule(revenueWalletID uuid.UUID) models.FeeSchedule {
+	return models.FeeSchedule{
+		RevenueWallets: map[string]uuid.UUID{"RUR": revenueWalletID},
+		Rules: []models.FeeRule{
+			{
+				CurrencyFrom: "AED",
+				CurrencyTo:   "RUR",
+				Tier:         models.TierBusiness,
+				Markup:       models.MustDecimal("0.01"),
+				FlatFees:     map[string]models.Decimal{"RUR": models.MustDecimal("5")},
+			},
+		},
+	}
+}
+
+// createRevenueWallet creates the RUR wallet collecting the fees of the suite.
+func (s *IntegrationTestSuite) createRevenueWallet(ctx context.Context) *models.Wallet {
+	revenueUser := models.User{
+		ID:       uuid.New(),
+		Username: "revenueUser",
+		Email:    "revenueUser@mail.com",
+		Phone:    "20",
+		Password: "password20",
+	}
+	s.Require().NoError(s.store.UpsertUser(ctx, revenueUser))
+
+	wallet, err := s.store.CreateWallet(ctx, models.Wallet{Owner: revenueUser.ID, Name: "FX revenue", Currency: "RUR"})
+	s.Require().NoError(err)
+
+	return wallet
+}
+
+func TestFeeSchedule(t *testing.T) {
+	schedule := models.FeeSchedule{
+		Rules: []models.FeeRule{
+			{Markup: models.MustDecimal("0.02")},
+			{Tier: models.TierPremium, Markup: models.MustDecimal("0.01")},
+			{
+				CurrencyFrom: "USD",
+				CurrencyTo:   "RUR",
+				Tier:         models.TierPremium,
+				Markup:       models.MustDecimal("0.005"),
+				FlatFees:     map[string]models.Decimal{"RUR": models.MustDecimal("10")},
+			},
+		},
+	}
+	require.NoError(t, schedule.Validate())
+
+	t.Run("most specific rule applies", func(t *testing.T) {
+		amount := models.MustDecimal("1000")
+
+		require.True(t, models.MustDecimal("20").Equal(schedule.Fee("USD", "RUR", models.TierStandard, "RUR", amount)))
+		require.True(t, models.MustDecimal("10").Equal(schedule.Fee("EUR", "RUR", models.TierPremium, "RUR", amount)))
+		require.True(t, models.MustDecimal("15").Equal(schedule.Fee("USD", "RUR", models.TierPremium, "RUR", amount)))
+	})
+
+	t.Run("fee is rounded to the fee currency", func(t *testing.T) {
+		require.True(t, models.MustDecimal("0.02").Equal(schedule.Fee("USD", "EUR", models.TierStandard, "EUR", models.MustDecimal("1.234"))))
+	})
+
+	t.Run("no matching rule", func(t *testing.T) {
+		require.True(t, models.FeeSchedule{}.Fee("USD", "RUR", models.TierStandard, "RUR", models.MustDecimal("1000")).IsZero())
+	})
+
+	t.Run("invalid schedules", func(t *testing.T) {
+		for _, invalid := range []models.FeeSchedule{
+			{Rules: []models.FeeRule{{Markup: models.MustDecimal("1")}}},
+			{Rules: []models.FeeRule{{Markup: models.MustDecimal("-0.01")}}},
+			{Rules: []models.FeeRule{{CurrencyFrom: "XXX"}}},
+			{Rules: []models.FeeRule{{Tier: "gold"}}},
+			{Rules: []models.FeeRule{{FlatFees: map[string]models.Decimal{"RUR": models.MustDecimal("-1")}}}},
+			{RevenueWallets: map[string]uuid.UUID{"XXX": uuid.New()}},
+		} {
+			require.ErrorIs(t, invalid.Validate(), models.ErrInvalidFeeSchedule)
+		}
+	})
+
+	t.Run("load from file", func(t *testing.T) {
+		revenueWalletID := uuid.New()
+		path := filepath.Join(t.TempDir(), "fees.json")
+		err := os.WriteFile(path, []byte(`{
+			"revenueWallets": {"RUR": "`+revenueWalletID.String()+`"},
+			"rules": [{"tier": "premium", "markup": "0.01", "flatFees": {"RUR": "5"}}]
+		}`), 0o600)
+		require.NoError(t, err)
+
+		loaded, err := service.LoadFeeSchedule(path)
+		require.NoError(t, err)
+		require.Equal(t, revenueWalletID, loaded.RevenueWallets["RUR"])
+		require.True(t, models.MustDecimal("15").Equal(loaded.Fee("USD", "RUR", models.TierPremium, "RUR", models.MustDecimal("1000"))))
+
+		empty, err := service.LoadFeeSchedule("")
+		require.NoError(t, err)
+		require.Empty(t, empty.Rules)
+	})
+}
+
+func (s *IntegrationTestSuite) TestFees() {
+	ctx := context.Background()
+
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "feesUser",
+		Email:    "feesUser@mail.com",
+		Phone:    "21",
+		Password: "password21",
+	}
+	authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+	s.Require().NoError(err)
+	s.Require().NoError(s.store.UpsertUser(ctx, testUser))
+	s.Require().NoError(s.store.SetUserTier(ctx, testUser.ID, models.TierBusiness))
+
+	s.authToken = authToken
+	walletID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("1000"))
+
+	requireBalance := func(id, ownerID uuid.UUID, balance string) {
+		wallet, err := s.store.GetWalletByID(ctx, id, ownerID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal(balance).Equal(wallet.Balance), wallet.Balance.String())
+	}
+
+	requireRevenue := func(balance string) {
+		s.Require().NoError(s.service.SettleFees(ctx))
+		requireBalance(s.revenueWallet.ID, s.revenueWallet.Owner, balance)
+	}
+
+	requireBalance(walletID, testUser.ID, "1000")
+
+	s.Run("cross-currency deposit is charged a fee", func() {
+		executedTransaction := new(models.Transaction)
+		resp := s.sendRequest(
+			ctx,
+			http.MethodPut,
+			"/deposit",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal("10"),
+				Currency:      "AED",
+				OperationType: models.OperationDeposit,
+			},
+			&rest.HTTPResponse{Data: &executedTransaction},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().True(models.MustDecimal("240").Equal(executedTransaction.ConvertedAmount))
+
+		requireBalance(walletID, testUser.ID, "1232.6")
+		requireRevenue("7.4")
+
+		transactions := new([]models.Transaction)
+		resp = s.sendRequest(
+			ctx,
+			http.MethodGet,
+			"/"+walletID.String()+"/transactions?sorting=executed_at&descending=true",
+			nil,
+			&rest.HTTPResponse{Data: &transactions},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		fee := (*transactions)[0]
+		s.Require().Equal(models.OperationFee, fee.OperationType)
+		s.Require().Equal(executedTransaction.TransactionID, fee.OriginalID)
+		s.Require().Equal(models.DirectionOutgoing, fee.Direction)
+		s.Require().True(models.MustDecimal("7.4").Equal(fee.Amount))
+	})
+
+	s.Run("pair without a rule is not charged", func() {
+		resp := s.sendRequest(
+			ctx,
+			http.MethodPut,
+			"/deposit",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal("10"),
+				Currency:      "CHY",
+				OperationType: models.OperationDeposit,
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		requireBalance(walletID, testUser.ID, "1352.6")
+		requireRevenue("7.4")
+	})
+
+	s.Run("captured cross-currency hold is charged a fee", func() {
+		hold := new(models.Hold)
+		resp := s.sendAPIRequest(
+			ctx,
+			http.MethodPost,
+			"/holds",
+			models.Hold{WalletID: walletID, Amount: models.MustDecimal("10"), Currency: "AED"},
+			&rest.HTTPResponse{Data: &hold},
+		)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+		resp = s.sendAPIRequest(ctx, http.MethodPost, "/holds/"+hold.ID.String()+"/capture", nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		requireBalance(walletID, testUser.ID, "1105.2")
+		requireRevenue("14.8")
+	})
+
+	s.Run("quote shows the fee", func() {
+		quote := new(models.Quote)
+		resp := s.sendAPIRequest(
+			ctx,
+			http.MethodPost,
+			"/fx/quotes",
+			models.QuoteRequest{
+				CurrencyFrom:  "AED",
+				CurrencyTo:    "RUR",
+				Amount:        models.MustDecimal("10"),
+				OperationType: models.OperationDeposit,
+			},
+			&rest.HTTPResponse{Data: &quote},
+		)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().Equal("RUR", quote.FeeCurrency)
+		s.Require().True(models.MustDecimal("7.4").Equal(quote.Fee), quote.Fee.String())
+	})
+
+	s.Run("reversal refunds the fee", func() {
+		supportUser := models.User{
+			ID:       uuid.New(),
+			Username: "feesSupport",
+			Email:    "feesSupport@mail.com",
+			Phone:    "33",
+			Password: "password33",
+			Roles:    []string{models.RoleSupport},
+		}
+		supportToken, err := s.tokenGenerator.GetNewTokenString(supportUser)
+		s.Require().NoError(err)
+		s.Require().NoError(s.store.UpsertUser(ctx, supportUser))
+		s.Require().NoError(s.store.SetUserRoles(ctx, supportUser.ID, supportUser.Roles))
+
+		executedTransaction := new(models.Transaction)
+		resp := s.sendRequest(
+			ctx,
+			http.MethodPut,
+			"/deposit",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal("10"),
+				Currency:      "AED",
+				OperationType: models.OperationDeposit,
+			},
+			&rest.HTTPResponse{Data: &executedTransaction},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		requireBalance(walletID, testUser.ID, "1337.8")
+
+		s.authToken = supportToken
+		defer func() { s.authToken = authToken }()
+
+		reverseEndpoint := "/admin/transactions/" + executedTransaction.TransactionID.String() + "/reverse"
+		half := models.MustDecimal("5")
+
+		resp = s.sendAPIRequest(ctx, http.MethodPost, reverseEndpoint, models.Reversal{Amount: &half}, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		requireBalance(walletID, testUser.ID, "1221.5")
+
+		resp = s.sendAPIRequest(ctx, http.MethodPost, reverseEndpoint, nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		requireBalance(walletID, testUser.ID, "1105.2")
+	})
+
+	s.Run("quoted fee is charged after the fees changed", func() {
+		// The quote was made when the fee was lower than the schedule charges now.
+		quote, err := s.store.SaveQuote(ctx, models.Quote{
+			ID:              uuid.New(),
+			OwnerID:         testUser.ID,
+			CurrencyFrom:    "AED",
+			CurrencyTo:      "RUR",
+			Amount:          models.MustDecimal("10"),
+			ConvertedAmount: models.MustDecimal("240"),
+			Rate:            models.MustDecimal("24"),
+			RateSource:      "manual",
+			RateDate:        time.Now(),
+			OperationType:   models.OperationDeposit,
+			Fee:             models.MustDecimal("1.23"),
+			FeeCurrency:     "RUR",
+			ExpiresAt:       time.Now().Add(time.Minute),
+			CreatedAt:       time.Now(),
+		})
+		s.Require().NoError(err)
+
+		resp := s.sendRequest(
+			ctx,
+			http.MethodPut,
+			"/deposit",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal("10"),
+				Currency:      "AED",
+				OperationType: models.OperationDeposit,
+				QuoteID:       &quote.ID,
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		requireBalance(walletID, testUser.ID, "1343.97")
+		requireRevenue("16.03")
+	})
+
+	s.Run("settled fees keep the ledger balanced", func() {
+		requireRevenue("16.03")
+
+		report, err := s.service.CheckLedger(ctx)
+		s.Require().NoError(err)
+		s.Require().True(report.Balanced())
+	})
+}
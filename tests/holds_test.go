@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+)
+
+func (s *IntegrationTestSuite) TestHolds() {
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "holdsUser",
+		Email:    "holdsUser@mail.com",
+		Phone:    "9",
+		Password: "password9",
+	}
+	authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+	s.Require().NoError(err)
+	err = s.store.UpsertUser(context.Background(), testUser)
+	s.Require().NoError(err)
+
+	s.authToken = authToken
+	walletID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("1000"))
+
+	requireBalances := func(balance, availableBalance string) {
+		wallet, err := s.store.GetWalletByID(context.Background(), walletID, testUser.ID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal(balance).Equal(wallet.Balance))
+		s.Require().True(models.MustDecimal(availableBalance).Equal(wallet.AvailableBalance))
+	}
+
+	createHold := func(amount string, expiresAt time.Time) *models.Hold {
+		hold := new(models.Hold)
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/holds",
+			models.Hold{
+				WalletID:  walletID,
+				Amount:    models.MustDecimal(amount),
+				Currency:  "RUR",
+				ExpiresAt: expiresAt,
+			},
+			&rest.HTTPResponse{Data: &hold},
+		)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+		return hold
+	}
+
+	s.Run("hold reduces available balance only", func() {
+		hold := createHold("300", time.Time{})
+		requireBalances("1000", "700")
+
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/withdraw",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal("800"),
+				Currency:      "RUR",
+				OperationType: "withdraw",
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+
+		s.Run("partial capture releases the rest", func() {
+			captured := models.MustDecimal("100")
+			executedTransaction := new(models.Transaction)
+
+			resp := s.sendAPIRequest(
+				context.Background(),
+				http.MethodPost,
+				"/holds/"+hold.ID.String()+"/capture",
+				models.Capture{Amount: &captured},
+				&rest.HTTPResponse{Data: &executedTransaction},
+			)
+			s.Require().Equal(http.StatusOK, resp.StatusCode)
+			s.Require().Equal(models.OperationWithdraw, executedTransaction.OperationType)
+			requireBalances("900", "900")
+		})
+
+		s.Run("captured hold can't be captured again", func() {
+			resp := s.sendAPIRequest(context.Background(), http.MethodPost, "/holds/"+hold.ID.String()+"/capture", nil, nil)
+			s.Require().Equal(http.StatusConflict, resp.StatusCode)
+		})
+	})
+
+	s.Run("void", func() {
+		hold := createHold("200", time.Time{})
+		requireBalances("900", "700")
+
+		voidedHold := new(models.Hold)
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/holds/"+hold.ID.String()+"/void",
+			nil,
+			&rest.HTTPResponse{Data: &voidedHold},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.HoldStatusVoided, voidedHold.Status)
+		requireBalances("900", "900")
+	})
+
+	s.Run("hold above available balance", func() {
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/holds",
+			models.Hold{WalletID: walletID, Amount: models.MustDecimal("1000"), Currency: "RUR"},
+			nil,
+		)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("expiry", func() {
+		hold := createHold("50", time.Now().Add(time.Second))
+		requireBalances("900", "850")
+
+		time.Sleep(1100 * time.Millisecond)
+
+		expired, err := s.store.ExpireHolds(context.Background(), time.Now())
+		s.Require().NoError(err)
+		s.Require().EqualValues(1, expired)
+		requireBalances("900", "900")
+
+		expiredHold := new(models.Hold)
+		resp := s.sendAPIRequest(context.Background(), http.MethodGet, "/holds/"+hold.ID.String(), nil, &rest.HTTPResponse{Data: &expiredHold})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.HoldStatusExpired, expiredHold.Status)
+	})
+
+	s.Run("ledger stays balanced", func() {
+		report, err := s.service.CheckLedger(context.Background())
+		s.Require().NoError(err)
+		s.Require().True(report.Balanced())
+	})
+	s.Run("expiration in the past is rejected", func() {
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/holds",
+			models.Hold{
+				WalletID:  walletID,
+				Amount:    models.MustDecimal("10"),
+				Currency:  "RUR",
+				ExpiresAt: time.Now().Add(-time.Minute),
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+}
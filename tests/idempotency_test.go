@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+)
+
+func (s *IntegrationTestSuite) TestIdempotency() {
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "idempotencyUser",
+		Email:    "idempotencyUser@mail.com",
+		Phone:    "3",
+		Password: "password3",
+	}
+	authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+	s.Require().NoError(err)
+	err = s.store.UpsertUser(context.Background(), testUser)
+	s.Require().NoError(err)
+
+	s.authToken = authToken
+	walletID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("100"))
+
+	headers := map[string]string{"Idempotency-Key": uuid.NewString()}
+
+	deposit := models.Transaction{
+		WalletID:      walletID,
+		Amount:        models.MustDecimal("50"),
+		Currency:      "RUR",
+		OperationType: "deposit",
+	}
+
+	s.Run("200/statusOK(repeated request is executed once)", func() {
+		firstTransaction := new(models.Transaction)
+		resp := s.sendRequestWithHeaders(
+			context.Background(),
+			http.MethodPut,
+			"/deposit",
+			headers,
+			deposit,
+			&rest.HTTPResponse{Data: &firstTransaction},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		repeatedTransaction := new(models.Transaction)
+		resp = s.sendRequestWithHeaders(
+			context.Background(),
+			http.MethodPut,
+			"/deposit",
+			headers,
+			deposit,
+			&rest.HTTPResponse{Data: &repeatedTransaction},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(firstTransaction.TransactionID, repeatedTransaction.TransactionID)
+
+		wallet, err := s.store.GetWalletByID(context.Background(), walletID, testUser.ID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal("150").Equal(wallet.Balance))
+	})
+
+	s.Run("409/statusConflict(same key, different payload)", func() {
+		changedDeposit := deposit
+		changedDeposit.Amount = models.MustDecimal("60")
+
+		resp := s.sendRequestWithHeaders(
+			context.Background(),
+			http.MethodPut,
+			"/deposit",
+			headers,
+			changedDeposit,
+			nil,
+		)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	s.Run("200/statusOK(expired key is released for a new request)", func() {
+		ctx := context.Background()
+		key := headers["Idempotency-Key"]
+
+		s.Require().NoError(s.store.DeleteExpiredIdempotencyKey(ctx, testUser.ID, key, time.Now().Add(-time.Hour)))
+
+		_, err := s.store.GetIdempotencyKey(ctx, testUser.ID, key, time.Now().Add(-time.Hour))
+		s.Require().NoError(err)
+
+		s.Require().NoError(s.store.DeleteExpiredIdempotencyKey(ctx, testUser.ID, key, time.Now()))
+
+		changedDeposit := deposit
+		changedDeposit.Amount = models.MustDecimal("60")
+
+		resp := s.sendRequestWithHeaders(ctx, http.MethodPut, "/deposit", headers, changedDeposit, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		wallet, err := s.store.GetWalletByID(ctx, walletID, testUser.ID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal("210").Equal(wallet.Balance))
+	})
+
+	s.Run("400/statusBadRequest(key is too long)", func() {
+		longKey := make([]byte, 256)
+		for i := range longKey {
+			longKey[i] = 'k'
+		}
+
+		resp := s.sendRequestWithHeaders(
+			context.Background(),
+			http.MethodPut,
+			"/deposit",
+			map[string]string{"Idempotency-Key": string(longKey)},
+			deposit,
+			nil,
+		)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -9,29 +9,41 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/iurikman/cashFlowManager/internal/broker"
+	"github.com/iurikman/cashFlowManager/internal/broker/mocks"
 	"github.com/iurikman/cashFlowManager/internal/config"
+	"github.com/iurikman/cashFlowManager/internal/jwks"
 	"github.com/iurikman/cashFlowManager/internal/jwtgenerator"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/password"
 	"github.com/iurikman/cashFlowManager/internal/rest"
 	"github.com/iurikman/cashFlowManager/internal/service"
-	"github.com/iurikman/cashFlowManager/internal/service/mocks"
 	"github.com/iurikman/cashFlowManager/internal/store"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	migrate "github.com/rubenv/sql-migrate"
-	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
-const bindAddress = "http://localhost:8080/api/v1/wallets"
+const (
+	apiAddress  = "http://localhost:8080/api/v1"
+	bindAddress = apiAddress + "/wallets"
+	jwtKeyID    = "tests"
+	jwtIssuer   = "cashflow-tests"
+	jwtAudience = "cashflow-api"
+)
 
 type IntegrationTestSuite struct {
 	suite.Suite
-	cancel               context.CancelFunc
-	store                *store.Postgres
-	service              *service.Service
-	server               *rest.Server
-	authToken            string
-	tokenGenerator       *jwtgenerator.JWTGenerator
-	transactionsProducer *mocks.TransactionsProducer
+	cancel         context.CancelFunc
+	store          *store.Postgres
+	service        *service.Service
+	server         *rest.Server
+	authToken      string
+	tokenGenerator *jwtgenerator.JWTGenerator
+	keySet         *jwks.KeySet
+	publisher      *mocks.Publisher
+	outboxRelay    *broker.OutboxRelay
+	revenueWallet  *models.Wallet
 }
 
 func TestIntegrationTestSuite(t *testing.T) {
@@ -58,19 +70,72 @@ func (s *IntegrationTestSuite) SetupSuite() {
 	err = s.store.Migrate(migrate.Up)
 	s.Require().NoError(err)
 
-	err = s.store.Truncate(ctx, "transactions_history", "wallets", "users")
+	err = s.store.Truncate(ctx, "ledger_postings", "outbox", "idempotency_keys", "transactions_history", "quotes", "holds",
+		"schedule_runs", "schedules", "refresh_tokens", "exchange_rates", "wallet_status_changes",
+		"wallet_pockets", "dormancy_run_wallets", "dormancy_runs", "wallets", "users")
 	s.Require().NoError(err)
 
+	s.resetCurrencies(ctx)
+	s.revenueWallet = s.createRevenueWallet(ctx)
+
 	xrConverter := MockConverter{}
 
-	s.tokenGenerator = jwtgenerator.NewJWTGenerator()
+	s.tokenGenerator, err = jwtgenerator.NewJWTGenerator(jwtgenerator.Config{
+		KeyID:    jwtKeyID,
+		Issuer:   jwtIssuer,
+		Audience: jwtAudience,
+		TTL:      cfg.AccessTokenTTL,
+	})
+	s.Require().NoError(err)
 
-	s.transactionsProducer = mocks.NewTransactionsProducer(s.T())
-	s.transactionsProducer.On("ProduceTransaction", mock.Anything, mock.Anything).Return(nil)
+	s.keySet, err = jwks.New(ctx, jwks.Config{})
+	s.Require().NoError(err)
+	s.keySet.Add(s.tokenGenerator.GetKeyID(), s.tokenGenerator.GetPublicKey())
+
+	s.publisher = mocks.NewPublisher(s.T())
 
-	s.service = service.NewService(db, xrConverter, s.transactionsProducer)
+	s.outboxRelay = broker.NewOutboxRelay(db, s.publisher, broker.OutboxRelayConfig{
+		PollInterval: cfg.OutboxPollInterval,
+		BatchSize:    cfg.OutboxBatchSize,
+		MaxAttempts:  cfg.OutboxMaxAttempts,
+		RetryBackoff: cfg.OutboxRetryBackoff,
+		ClaimTimeout: cfg.OutboxClaimTimeout,
+	})
 
-	s.server, err = rest.NewServer(rest.ServerConfig{BindAddress: cfg.BindAddress}, s.service, s.tokenGenerator.GetPublicKey())
+	passwordHasher, err := password.NewHasher(password.Config{
+		Algorithm:     cfg.PasswordHashAlgorithm,
+		Argon2Time:    cfg.PasswordArgon2Time,
+		Argon2Memory:  cfg.PasswordArgon2Memory,
+		Argon2Threads: cfg.PasswordArgon2Threads,
+		BcryptCost:    cfg.PasswordBcryptCost,
+	})
+	s.Require().NoError(err)
+
+	s.service = service.NewService(db, xrConverter, s.tokenGenerator, passwordHasher, service.Config{
+		IdempotencyKeyTTL:         cfg.IdempotencyKeyTTL,
+		LedgerCheckInterval:       cfg.LedgerCheckInterval,
+		HoldTTL:                   cfg.HoldTTL,
+		HoldsExpiryInterval:       cfg.HoldsExpiryInterval,
+		SchedulerInterval:         cfg.SchedulerInterval,
+		SchedulerBatchSize:        cfg.SchedulerBatchSize,
+		RefreshTokenTTL:           cfg.RefreshTokenTTL,
+		QuoteTTL:                  cfg.QuoteTTL,
+		Fees:                      testFeeSchedule(s.revenueWallet.ID),
+		FeeSettlementInterval:     cfg.FeeSettlementInterval,
+		CurrenciesRefreshInterval: cfg.CurrenciesRefreshInterval,
+		WalletRestorePeriod:       cfg.WalletRestorePeriod,
+		CleanerInterval:           cfg.CleanerInterval,
+		OutboxRetention:           cfg.OutboxRetention,
+		DormancyPeriod:            cfg.DormancyPeriod,
+		DormancyWarningPeriod:     cfg.DormancyWarningPeriod,
+		DormancyBatchSize:         cfg.DormancyBatchSize,
+	})
+
+	s.server, err = rest.NewServer(
+		rest.ServerConfig{BindAddress: cfg.BindAddress, Issuer: jwtIssuer, Audience: jwtAudience},
+		s.service,
+		s.keySet,
+	)
 	s.Require().NoError(err)
 
 	go func() {
@@ -86,16 +151,51 @@ func (s *IntegrationTestSuite) TearDownSuite() {
 func (s *IntegrationTestSuite) sendRequest(ctx context.Context, method, endpoint string, body interface{}, dest interface{}) *http.Response {
 	s.T().Helper()
 
+	return s.sendRequestWithHeaders(ctx, method, endpoint, nil, body, dest)
+}
+
+func (s *IntegrationTestSuite) sendRequestWithHeaders(
+	ctx context.Context,
+	method, endpoint string,
+	headers map[string]string,
+	body interface{},
+	dest interface{},
+) *http.Response {
+	s.T().Helper()
+
+	return s.doRequest(ctx, method, bindAddress+endpoint, headers, body, dest)
+}
+
+// sendAPIRequest sends a request to an endpoint outside of /wallets.
+func (s *IntegrationTestSuite) sendAPIRequest(ctx context.Context, method, endpoint string, body interface{}, dest interface{}) *http.Response {
+	s.T().Helper()
+
+	return s.doRequest(ctx, method, apiAddress+endpoint, nil, body, dest)
+}
+
+func (s *IntegrationTestSuite) doRequest(
+	ctx context.Context,
+	method, url string,
+	headers map[string]string,
+	body interface{},
+	dest interface{},
+) *http.Response {
+	s.T().Helper()
+
 	reqBody, err := json.Marshal(body)
 	s.Require().NoError(err)
 
-	req, err := http.NewRequestWithContext(ctx, method, bindAddress+endpoint, bytes.NewBuffer(reqBody))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(reqBody))
 	s.Require().NoError(err)
 
 	req.Header.Set("Content-Type", "application/json")
 
 	req.Header.Set("Authorization", "Bearer "+s.authToken)
 
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
 	resp, err := http.DefaultClient.Do(req)
 	s.Require().NoError(err)
 
@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/jwks"
+	"github.com/iurikman/cashFlowManager/internal/jwtgenerator"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKS(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("PEM files", func(t *testing.T) {
+		key := newRSAKey(t)
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		require.NoError(t, err)
+
+		path := filepath.Join(t.TempDir(), "main.pem")
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+		keySet, err := jwks.New(ctx, jwks.Config{PublicKeyFiles: []string{path}})
+		require.NoError(t, err)
+
+		found, err := keySet.Key(ctx, "main")
+		require.NoError(t, err)
+		require.True(t, key.PublicKey.Equal(found))
+
+		found, err = keySet.Key(ctx, "")
+		require.NoError(t, err)
+		require.True(t, key.PublicKey.Equal(found))
+
+		_, err = keySet.Key(ctx, "other")
+		require.ErrorIs(t, err, models.ErrUnknownKeyID)
+	})
+
+	t.Run("JWKS URL with rotation", func(t *testing.T) {
+		oldKey, newKey := newRSAKey(t), newRSAKey(t)
+
+		var (
+			mu      sync.Mutex
+			payload = jwksPayload(t, map[string]*rsa.PrivateKey{"old": oldKey})
+		)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			_, _ = w.Write(payload)
+		}))
+		defer server.Close()
+
+		keySet, err := jwks.New(ctx, jwks.Config{JWKSURL: server.URL})
+		require.NoError(t, err)
+
+		found, err := keySet.Key(ctx, "old")
+		require.NoError(t, err)
+		require.True(t, oldKey.PublicKey.Equal(found))
+
+		mu.Lock()
+		payload = jwksPayload(t, map[string]*rsa.PrivateKey{"new": newKey})
+		mu.Unlock()
+
+		require.NoError(t, keySet.Refresh(ctx))
+
+		found, err = keySet.Key(ctx, "new")
+		require.NoError(t, err)
+		require.True(t, newKey.PublicKey.Equal(found))
+
+		_, err = keySet.Key(ctx, "old")
+		require.ErrorIs(t, err, models.ErrUnknownKeyID)
+	})
+
+	t.Run("JWKS file", func(t *testing.T) {
+		first, second := newRSAKey(t), newRSAKey(t)
+
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		require.NoError(t, os.WriteFile(path, jwksPayload(t, map[string]*rsa.PrivateKey{"first": first, "second": second}), 0o600))
+
+		keySet, err := jwks.New(ctx, jwks.Config{JWKSFile: path})
+		require.NoError(t, err)
+
+		found, err := keySet.Key(ctx, "second")
+		require.NoError(t, err)
+		require.True(t, second.PublicKey.Equal(found))
+
+		// Without a key ID the key is ambiguous.
+		_, err = keySet.Key(ctx, "")
+		require.ErrorIs(t, err, models.ErrUnknownKeyID)
+	})
+
+	t.Run("invalid sources", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jwks.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"keys": [{"kty": "EC", "kid": "ec"}]}`), 0o600))
+
+		_, err := jwks.New(ctx, jwks.Config{JWKSFile: path})
+		require.Error(t, err)
+
+		_, err = jwks.New(ctx, jwks.Config{PublicKeyFiles: []string{"main=" + filepath.Join(t.TempDir(), "missing.pem")}})
+		require.Error(t, err)
+	})
+}
+
+func (s *IntegrationTestSuite) TestTokenValidation() {
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "tokensUser",
+		Email:    "tokensUser@mail.com",
+		Phone:    "11",
+		Password: "password11",
+	}
+	err := s.store.UpsertUser(context.Background(), testUser)
+	s.Require().NoError(err)
+
+	requireStatus := func(cfg jwtgenerator.Config, status int) {
+		tokenGenerator, err := jwtgenerator.NewJWTGenerator(cfg)
+		s.Require().NoError(err)
+
+		authToken, err := tokenGenerator.GetNewTokenString(testUser)
+		s.Require().NoError(err)
+
+		s.authToken = authToken
+		resp := s.sendRequest(context.Background(), http.MethodGet, "", nil, nil)
+		s.Require().Equal(status, resp.StatusCode)
+	}
+
+	s.Run("valid token", func() {
+		authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+		s.Require().NoError(err)
+
+		s.authToken = authToken
+		resp := s.sendRequest(context.Background(), http.MethodGet, "", nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+	})
+
+	s.Run("unknown key", func() {
+		requireStatus(jwtgenerator.Config{KeyID: "unknown", Issuer: jwtIssuer, Audience: jwtAudience}, http.StatusUnauthorized)
+	})
+
+	s.Run("known key id with a different key", func() {
+		requireStatus(jwtgenerator.Config{KeyID: jwtKeyID, Issuer: jwtIssuer, Audience: jwtAudience}, http.StatusUnauthorized)
+	})
+
+	s.Run("wrong issuer", func() {
+		requireStatus(jwtgenerator.Config{KeyID: jwtKeyID, Issuer: "someone-else", Audience: jwtAudience}, http.StatusUnauthorized)
+	})
+
+	s.Run("wrong audience", func() {
+		requireStatus(jwtgenerator.Config{KeyID: jwtKeyID, Issuer: jwtIssuer, Audience: "other-api"}, http.StatusUnauthorized)
+	})
+
+	s.Run("token without expiration", func() {
+		const kid = "no-expiration"
+
+		key := newRSAKey(s.T())
+		s.keySet.Add(kid, &key.PublicKey)
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS512, models.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:  testUser.ID.String(),
+				Issuer:   jwtIssuer,
+				Audience: jwt.ClaimStrings{jwtAudience},
+			},
+			UUID: testUser.ID,
+		})
+		token.Header["kid"] = kid
+
+		authToken, err := token.SignedString(key)
+		s.Require().NoError(err)
+
+		s.authToken = authToken
+		resp := s.sendRequest(context.Background(), http.MethodGet, "", nil, nil)
+		s.Require().Equal(http.StatusUnauthorized, resp.StatusCode)
+
+		s.authToken, err = s.tokenGenerator.GetNewTokenString(testUser)
+		s.Require().NoError(err)
+
+		introspection := new(models.TokenIntrospection)
+		resp = s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/auth/introspect",
+			models.IntrospectionRequest{Token: authToken},
+			&rest.HTTPResponse{Data: &introspection},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().False(introspection.Active)
+	})
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	return key
+}
+
+func jwksPayload(t *testing.T, keys map[string]*rsa.PrivateKey) []byte {
+	t.Helper()
+
+	jsonKeys := make([]map[string]string, 0, len(keys))
+
+	for kid, key := range keys {
+		jsonKeys = append(jsonKeys, map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": "RS512",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	payload, err := json.Marshal(map[string]any{"keys": jsonKeys})
+	require.NoError(t, err)
+
+	return payload
+}
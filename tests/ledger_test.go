@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+)
+
+func (s *IntegrationTestSuite) TestLedger() {
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "ledgerUser",
+		Email:    "ledgerUser@mail.com",
+		Phone:    "5",
+		Password: "password5",
+	}
+	authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+	s.Require().NoError(err)
+	err = s.store.UpsertUser(context.Background(), testUser)
+	s.Require().NoError(err)
+
+	s.authToken = authToken
+
+	idRUR := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("1000"))
+	idCHY := s.createWalletForConverter(testUser.ID, "CHY", models.MustDecimal("1000"))
+
+	s.Run("ledger is balanced after operations", func() {
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/transfer",
+			models.Transaction{
+				WalletID:       idRUR,
+				TargetWalletID: idCHY,
+				Amount:         models.MustDecimal("100"),
+				Currency:       "RUR",
+				OperationType:  "transfer",
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		resp = s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/withdraw",
+			models.Transaction{
+				WalletID:      idRUR,
+				Amount:        models.MustDecimal("10"),
+				Currency:      "CHY",
+				OperationType: "withdraw",
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		newCurrency := "AED"
+		resp = s.sendRequest(
+			context.Background(),
+			http.MethodPatch,
+			"/"+idCHY.String(),
+			models.WalletDTO{Currency: &newCurrency},
+			nil,
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		report, err := s.service.CheckLedger(context.Background())
+		s.Require().NoError(err)
+		s.Require().Empty(report.CurrencyImbalances)
+		s.Require().Empty(report.WalletDiscrepancies)
+		s.Require().True(report.Balanced())
+	})
+}
@@ -3,31 +3,48 @@ package tests
 
 import (
 	"context"
+	"time"
 
 	"github.com/iurikman/cashFlowManager/internal/converter"
+	"github.com/iurikman/cashFlowManager/internal/models"
 )
 
-var AllowedCurrencies = map[string]float64{
-	"RUR": 1,
-	"CHY": 12,
-	"AED": 24,
-	"INR": 2,
+var AllowedCurrencies = map[string]models.Decimal{
+	"RUR": models.NewDecimalFromInt(1),
+	"CHY": models.NewDecimalFromInt(12),
+	"AED": models.NewDecimalFromInt(24),
+	"INR": models.NewDecimalFromInt(2),
 }
 
+const MockRateSource = "mock"
+
 type MockConverter struct{}
 
-func (c MockConverter) Convert(ctx context.Context, currencyFrom converter.Currency, currencyTo converter.Currency) (float64, error) {
+func (c MockConverter) Convert(ctx context.Context, currencyFrom converter.Currency, currencyTo converter.Currency) (*models.Conversion, error) {
 	changeRateCurrFrom := AllowedCurrencies[currencyFrom.Name]
 	changeRateCurrTo := AllowedCurrencies[currencyTo.Name]
 
-	switch {
-	case currencyTo.Name == "RUR":
-		result := currencyFrom.Amount * changeRateCurrFrom
+	rate, err := changeRateCurrFrom.Div(changeRateCurrTo)
+	if err != nil {
+		return nil, err
+	}
 
-		return result, nil
-	default:
-		result := (currencyFrom.Amount * changeRateCurrFrom) / changeRateCurrTo
+	return &models.Conversion{
+		Amount:     currencyFrom.Amount.Mul(rate).RoundForCurrency(currencyTo.Name),
+		Rate:       rate,
+		RateSource: MockRateSource,
+		RateDate:   time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
 
-		return result, nil
+// ConvertAt converts at the mock rates, which are published for every date.
+func (c MockConverter) ConvertAt(ctx context.Context, currencyFrom converter.Currency, currencyTo converter.Currency, date time.Time) (*models.Conversion, error) {
+	conversion, err := c.Convert(ctx, currencyFrom, currencyTo)
+	if err != nil {
+		return nil, err
 	}
+
+	conversion.RateDate = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	return conversion, nil
 }
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+	"github.com/stretchr/testify/mock"
+)
+
+var errBrokerUnavailable = errors.New("broker unavailable")
+
+func (s *IntegrationTestSuite) TestOutbox() {
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "outboxUser",
+		Email:    "outboxUser@mail.com",
+		Phone:    "4",
+		Password: "password4",
+	}
+	authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+	s.Require().NoError(err)
+	err = s.store.UpsertUser(context.Background(), testUser)
+	s.Require().NoError(err)
+
+	s.authToken = authToken
+	walletID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("100"))
+
+	executedTransaction := new(models.Transaction)
+	resp := s.sendRequest(
+		context.Background(),
+		http.MethodPut,
+		"/withdraw",
+		models.Transaction{
+			WalletID:      walletID,
+			Amount:        models.MustDecimal("30"),
+			Currency:      "RUR",
+			OperationType: "withdraw",
+		},
+		&rest.HTTPResponse{Data: &executedTransaction},
+	)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	isWithdrawEvent := mock.MatchedBy(func(message models.OutboxMessage) bool {
+		return message.Key == executedTransaction.TransactionID.String()
+	})
+
+	withdrawEventCalls := func() int {
+		calls := 0
+
+		for _, call := range s.publisher.Calls {
+			message, ok := call.Arguments.Get(1).(models.OutboxMessage)
+			if ok && message.Key == executedTransaction.TransactionID.String() {
+				calls++
+			}
+		}
+
+		return calls
+	}
+
+	s.publisher.On("Publish", mock.Anything, isWithdrawEvent).Return(errBrokerUnavailable).Once()
+	s.publisher.On("Publish", mock.Anything, mock.Anything).Return(nil)
+
+	s.Run("failed message is retried after backoff", func() {
+		s.Require().NoError(s.outboxRelay.PublishPending(context.Background()))
+		s.Require().Equal(1, withdrawEventCalls())
+
+		s.Require().NoError(s.outboxRelay.PublishPending(context.Background()))
+		s.Require().Equal(1, withdrawEventCalls())
+
+		time.Sleep(1100 * time.Millisecond)
+
+		s.Require().NoError(s.outboxRelay.PublishPending(context.Background()))
+		s.Require().Equal(2, withdrawEventCalls())
+	})
+
+	s.Run("published message is not sent again", func() {
+		s.Require().NoError(s.outboxRelay.PublishPending(context.Background()))
+		s.Require().Equal(2, withdrawEventCalls())
+	})
+
+	s.Run("published messages are cleaned after retention", func() {
+		cleaned, err := s.store.CleanOutbox(context.Background(), time.Now().Add(-time.Hour))
+		s.Require().NoError(err)
+		s.Require().Zero(cleaned)
+
+		cleaned, err = s.store.CleanOutbox(context.Background(), time.Now())
+		s.Require().NoError(err)
+		s.Require().Positive(cleaned)
+
+		cleaned, err = s.store.CleanOutbox(context.Background(), time.Now())
+		s.Require().NoError(err)
+		s.Require().Zero(cleaned)
+	})
+
+	s.Run("claimed message is left to its relay until the claim times out", func() {
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/deposit",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal("10"),
+				Currency:      "RUR",
+				OperationType: "deposit",
+			},
+			&rest.HTTPResponse{Data: &executedTransaction},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		claimedKeys := func(claimedUntil time.Time) []string {
+			messages, err := s.store.ClaimOutboxMessages(context.Background(), 1000, 1000, claimedUntil)
+			s.Require().NoError(err)
+
+			keys := make([]string, 0, len(messages))
+			for _, message := range messages {
+				keys = append(keys, message.Key)
+			}
+
+			return keys
+		}
+
+		s.Require().Contains(claimedKeys(time.Now().Add(time.Minute)), executedTransaction.TransactionID.String())
+		s.Require().NotContains(claimedKeys(time.Now()), executedTransaction.TransactionID.String())
+	})
+	s.Run("message that ran out of attempts is dead-lettered", func() {
+		messages, err := s.store.ClaimOutboxMessages(context.Background(), 1000, 1000, time.Now())
+		s.Require().NoError(err)
+
+		var message *models.OutboxMessage
+
+		for _, claimed := range messages {
+			if claimed.Key == executedTransaction.TransactionID.String() {
+				message = claimed
+			}
+		}
+
+		s.Require().NotNil(message)
+
+		for range 3 {
+			err = s.store.MarkOutboxMessageFailed(context.Background(), message.ID, errBrokerUnavailable.Error(), time.Now())
+			s.Require().NoError(err)
+		}
+
+		deadLetters, err := s.store.DeadLetterOutboxMessages(context.Background(), 3)
+		s.Require().NoError(err)
+		s.Require().Len(deadLetters, 1)
+		s.Require().Equal(message.ID, deadLetters[0].ID)
+		s.Require().Equal(errBrokerUnavailable.Error(), deadLetters[0].LastError)
+
+		deadLetters, err = s.store.DeadLetterOutboxMessages(context.Background(), 3)
+		s.Require().NoError(err)
+		s.Require().Empty(deadLetters)
+
+		messages, err = s.store.ClaimOutboxMessages(context.Background(), 1000, 1000, time.Now())
+		s.Require().NoError(err)
+
+		for _, claimed := range messages {
+			s.Require().NotEqual(message.ID, claimed.ID)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iurikman/cashFlowManager/internal/password"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	argon2idConfig := password.Config{
+		Algorithm:     password.AlgorithmArgon2id,
+		Argon2Time:    1,
+		Argon2Memory:  1024,
+		Argon2Threads: 1,
+		BcryptCost:    4,
+	}
+
+	hasher, err := password.NewHasher(argon2idConfig)
+	require.NoError(t, err)
+
+	t.Run("argon2id", func(t *testing.T) {
+		hash, err := hasher.Hash("secret")
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+		require.True(t, password.IsHash(hash))
+
+		ok, needsRehash, err := hasher.Verify("secret", hash)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.False(t, needsRehash)
+
+		ok, _, err = hasher.Verify("wrong", hash)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		other, err := hasher.Hash("secret")
+		require.NoError(t, err)
+		require.NotEqual(t, hash, other)
+	})
+
+	t.Run("plaintext needs rehash", func(t *testing.T) {
+		require.False(t, password.IsHash("secret"))
+
+		ok, needsRehash, err := hasher.Verify("secret", "secret")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.True(t, needsRehash)
+
+		ok, _, err = hasher.Verify("wrong", "secret")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("plaintext that looks like a hash", func(t *testing.T) {
+		for _, plaintext := range []string{"$argon2id$secret", "$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5", "$2a$secret"} {
+			require.False(t, password.IsHash(plaintext))
+
+			ok, needsRehash, err := hasher.Verify(plaintext, plaintext)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.True(t, needsRehash)
+
+			ok, _, err = hasher.Verify("wrong", plaintext)
+			require.NoError(t, err)
+			require.False(t, ok)
+		}
+	})
+
+	t.Run("hash with parameters over the limits", func(t *testing.T) {
+		for _, hash := range []string{
+			"$argon2id$v=19$m=1073741824,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+			"$argon2id$v=19$m=1024,t=4294967295,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U",
+			"$2a$31$abcdefghijklmnopqrstuuabcdefghijklmnopqrstuvwxyz01234",
+		} {
+			require.False(t, password.IsHash(hash))
+
+			ok, needsRehash, err := hasher.Verify("secret", hash)
+			require.NoError(t, err)
+			require.False(t, ok)
+			require.True(t, needsRehash)
+		}
+	})
+
+	t.Run("changed parameters need rehash", func(t *testing.T) {
+		hash, err := hasher.Hash("secret")
+		require.NoError(t, err)
+
+		strongerConfig := argon2idConfig
+		strongerConfig.Argon2Time = 2
+
+		stronger, err := password.NewHasher(strongerConfig)
+		require.NoError(t, err)
+
+		ok, needsRehash, err := stronger.Verify("secret", hash)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.True(t, needsRehash)
+	})
+
+	t.Run("bcrypt", func(t *testing.T) {
+		bcryptConfig := argon2idConfig
+		bcryptConfig.Algorithm = password.AlgorithmBcrypt
+
+		bcryptHasher, err := password.NewHasher(bcryptConfig)
+		require.NoError(t, err)
+
+		hash, err := bcryptHasher.Hash("secret")
+		require.NoError(t, err)
+		require.True(t, password.IsHash(hash))
+
+		ok, needsRehash, err := bcryptHasher.Verify("secret", hash)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.False(t, needsRehash)
+
+		// Switching the algorithm keeps old hashes valid until they are upgraded.
+		ok, needsRehash, err = hasher.Verify("secret", hash)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.True(t, needsRehash)
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		_, err := password.NewHasher(password.Config{Algorithm: "md5"})
+		require.Error(t, err)
+
+		_, err = password.NewHasher(password.Config{Algorithm: password.AlgorithmBcrypt, BcryptCost: 100})
+		require.Error(t, err)
+
+		overLimitConfig := argon2idConfig
+		overLimitConfig.Argon2Memory = 1 << 30
+
+		_, err = password.NewHasher(overLimitConfig)
+		require.Error(t, err)
+	})
+}
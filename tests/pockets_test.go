@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletPockets(t *testing.T) {
+	wallet := models.Wallet{Currency: "RUR"}
+	require.Equal(t, "", wallet.PocketOf("USD"))
+
+	wallet.MultiCurrency = true
+	require.Equal(t, "", wallet.PocketOf("RUR"))
+	require.Equal(t, "USD", wallet.PocketOf("USD"))
+
+	require.Equal(t, "RUR", wallet.BalanceCurrency(""))
+	require.Equal(t, "USD", wallet.BalanceCurrency("USD"))
+
+	exchange := models.Transaction{
+		WalletID:       uuid.New(),
+		Amount:         models.MustDecimal("10"),
+		Currency:       "AED",
+		TargetCurrency: "AED",
+		OperationType:  models.OperationExchange,
+	}
+	require.ErrorIs(t, exchange.Validate(), models.ErrSameCurrencies)
+
+	exchange.TargetCurrency = "XXX"
+	require.ErrorIs(t, exchange.Validate(), models.ErrCurrencyNotAllowed)
+
+	exchange.TargetCurrency = "CHY"
+	require.NoError(t, exchange.Validate())
+}
+
+func (s *IntegrationTestSuite) TestPockets() {
+	ctx := context.Background()
+
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "pocketsUser",
+		Email:    "pocketsUser@mail.com",
+		Phone:    "24",
+		Password: "password24",
+	}
+	authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+	s.Require().NoError(err)
+	s.Require().NoError(s.store.UpsertUser(ctx, testUser))
+
+	s.authToken = authToken
+	walletID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("1000"))
+	targetID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("0"))
+
+	getWallet := func() *models.Wallet {
+		wallet := new(models.Wallet)
+		resp := s.sendRequest(ctx, http.MethodGet, "/"+walletID.String(), nil, &rest.HTTPResponse{Data: &wallet})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		return wallet
+	}
+
+	requirePockets := func(wallet *models.Wallet, balance string, pockets map[string]string) {
+		s.Require().True(models.MustDecimal(balance).Equal(wallet.Balance), wallet.Balance.String())
+		s.Require().Len(wallet.Pockets, len(pockets))
+
+		for _, pocket := range wallet.Pockets {
+			s.Require().True(models.MustDecimal(pockets[pocket.Currency]).Equal(pocket.Balance), pocket.Currency)
+		}
+	}
+
+	exchange := func(amount, currency, targetCurrency string) *http.Response {
+		return s.sendRequest(
+			ctx,
+			http.MethodPut,
+			"/exchange",
+			models.Transaction{
+				WalletID:       walletID,
+				Amount:         models.MustDecimal(amount),
+				Currency:       currency,
+				TargetCurrency: targetCurrency,
+			},
+			nil,
+		)
+	}
+
+	s.Run("single-currency wallet can't exchange", func() {
+		s.Require().Equal(http.StatusConflict, exchange("100", "RUR", "CHY").StatusCode)
+	})
+
+	s.Run("switch to multi-currency", func() {
+		multiCurrency := true
+		wallet := new(models.Wallet)
+		resp := s.sendRequest(
+			ctx,
+			http.MethodPatch,
+			"/"+walletID.String(),
+			models.WalletDTO{MultiCurrency: &multiCurrency},
+			&rest.HTTPResponse{Data: &wallet},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().True(wallet.MultiCurrency)
+		requirePockets(wallet, "1000", nil)
+	})
+
+	s.Run("deposit lands in the pocket of its currency", func() {
+		executedTransaction := new(models.Transaction)
+		resp := s.sendRequest(
+			ctx,
+			http.MethodPut,
+			"/deposit",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal("10"),
+				Currency:      "AED",
+				OperationType: models.OperationDeposit,
+			},
+			&rest.HTTPResponse{Data: &executedTransaction},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal("AED", executedTransaction.Pocket)
+		s.Require().True(models.MustDecimal("10").Equal(executedTransaction.ConvertedAmount))
+
+		requirePockets(getWallet(), "1000", map[string]string{"AED": "10"})
+	})
+
+	s.Run("exchange between pockets and the main balance", func() {
+		s.Require().Equal(http.StatusOK, exchange("5", "AED", "CHY").StatusCode)
+		requirePockets(getWallet(), "1000", map[string]string{"AED": "5", "CHY": "10"})
+
+		s.Require().Equal(http.StatusOK, exchange("10", "CHY", "RUR").StatusCode)
+		requirePockets(getWallet(), "1120", map[string]string{"AED": "5", "CHY": "0"})
+
+		s.Require().Equal(http.StatusBadRequest, exchange("6", "AED", "RUR").StatusCode)
+		s.Require().Equal(http.StatusBadRequest, exchange("6", "AED", "AED").StatusCode)
+	})
+
+	s.Run("withdraw beyond the pocket balance", func() {
+		resp := s.sendRequest(
+			ctx,
+			http.MethodPut,
+			"/withdraw",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal("6"),
+				Currency:      "AED",
+				OperationType: models.OperationWithdraw,
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("currency and pockets are kept", func() {
+		newCurrency := "CHY"
+		resp := s.sendRequest(ctx, http.MethodPatch, "/"+walletID.String(), models.WalletDTO{Currency: &newCurrency}, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+
+		multiCurrency := false
+		resp = s.sendRequest(ctx, http.MethodPatch, "/"+walletID.String(), models.WalletDTO{MultiCurrency: &multiCurrency}, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	s.Run("transfer from a pocket", func() {
+		resp := s.sendRequest(
+			ctx,
+			http.MethodPut,
+			"/transfer",
+			models.Transaction{
+				WalletID:       walletID,
+				TargetWalletID: targetID,
+				Amount:         models.MustDecimal("5"),
+				Currency:       "AED",
+				OperationType:  models.OperationTransfer,
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		requirePockets(getWallet(), "1120", map[string]string{"AED": "0", "CHY": "0"})
+
+		target, err := s.store.GetWalletByID(ctx, targetID, testUser.ID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal("120").Equal(target.Balance), target.Balance.String())
+
+		multiCurrency := false
+		resp = s.sendRequest(ctx, http.MethodPatch, "/"+walletID.String(), models.WalletDTO{MultiCurrency: &multiCurrency}, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+	})
+
+	s.Run("ledger reconciles the pockets", func() {
+		report, err := s.service.CheckLedger(ctx)
+		s.Require().NoError(err)
+		s.Require().Empty(report.WalletDiscrepancies)
+		s.Require().True(report.Balanced())
+	})
+}
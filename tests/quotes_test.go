@@ -0,0 +1,230 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+)
+
+func (s *IntegrationTestSuite) TestQuotes() {
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "quotesUser",
+		Email:    "quotesUser@mail.com",
+		Phone:    "19",
+		Password: "password19",
+	}
+	authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+	s.Require().NoError(err)
+	err = s.store.UpsertUser(context.Background(), testUser)
+	s.Require().NoError(err)
+
+	s.authToken = authToken
+	walletID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("1000"))
+
+	saveQuote := func(rate string, expiresAt time.Time) *models.Quote {
+		quote, err := s.store.SaveQuote(context.Background(), models.Quote{
+			ID:              uuid.New(),
+			OwnerID:         testUser.ID,
+			CurrencyFrom:    "CHY",
+			CurrencyTo:      "RUR",
+			Amount:          models.MustDecimal("10"),
+			ConvertedAmount: models.MustDecimal(rate).Mul(models.MustDecimal("10")),
+			Rate:            models.MustDecimal(rate),
+			RateSource:      "manual",
+			RateDate:        time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+			OperationType:   models.OperationDeposit,
+			FeeCurrency:     "RUR",
+			ExpiresAt:       expiresAt,
+			CreatedAt:       time.Now(),
+		})
+		s.Require().NoError(err)
+
+		return quote
+	}
+
+	deposit := func(amount string, quoteID *uuid.UUID, dest interface{}) *http.Response {
+		return s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/deposit",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal(amount),
+				Currency:      "CHY",
+				OperationType: models.OperationDeposit,
+				QuoteID:       quoteID,
+			},
+			dest,
+		)
+	}
+
+	s.Run("create quote", func() {
+		quote := new(models.Quote)
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/fx/quotes",
+			models.QuoteRequest{
+				CurrencyFrom:  "CHY",
+				CurrencyTo:    "RUR",
+				Amount:        models.MustDecimal("10"),
+				OperationType: models.OperationDeposit,
+			},
+			&rest.HTTPResponse{Data: &quote},
+		)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().True(models.MustDecimal("120").Equal(quote.ConvertedAmount))
+		s.Require().True(models.MustDecimal("12").Equal(quote.Rate))
+		s.Require().Equal(MockRateSource, quote.RateSource)
+		s.Require().True(quote.ExpiresAt.After(time.Now()))
+
+		s.Run("quote of the same currencies", func() {
+			resp := s.sendAPIRequest(
+				context.Background(),
+				http.MethodPost,
+				"/fx/quotes",
+				models.QuoteRequest{CurrencyFrom: "RUR", CurrencyTo: "RUR", Amount: models.MustDecimal("10")},
+				nil,
+			)
+			s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+		})
+
+		s.Run("quote without operation type", func() {
+			resp := s.sendAPIRequest(
+				context.Background(),
+				http.MethodPost,
+				"/fx/quotes",
+				models.QuoteRequest{CurrencyFrom: "CHY", CurrencyTo: "RUR", Amount: models.MustDecimal("10")},
+				nil,
+			)
+			s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+		})
+	})
+
+	s.Run("deposit uses the locked rate", func() {
+		quote := saveQuote("13", time.Now().Add(time.Minute))
+
+		executedTransaction := new(models.Transaction)
+		resp := deposit("10", &quote.ID, &rest.HTTPResponse{Data: &executedTransaction})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().True(models.MustDecimal("130").Equal(executedTransaction.ConvertedAmount))
+		s.Require().True(models.MustDecimal("13").Equal(executedTransaction.ExRate))
+		s.Require().Equal(quote.ID, *executedTransaction.QuoteID)
+		s.Require().Equal("manual", executedTransaction.RateSource)
+		s.Require().Equal(quote.RateDate, executedTransaction.RateDate.UTC())
+
+		wallet, err := s.store.GetWalletByID(context.Background(), walletID, testUser.ID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal("1130").Equal(wallet.Balance))
+
+		s.Run("quote can't be used twice", func() {
+			resp := deposit("10", &quote.ID, nil)
+			s.Require().Equal(http.StatusConflict, resp.StatusCode)
+		})
+	})
+
+	s.Run("deposit at the current rate records its provenance", func() {
+		executedTransaction := new(models.Transaction)
+		resp := deposit("10", nil, &rest.HTTPResponse{Data: &executedTransaction})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Nil(executedTransaction.QuoteID)
+
+		stored, err := s.store.GetTransactionByID(context.Background(), executedTransaction.TransactionID, testUser.ID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal("12").Equal(stored.ExRate))
+		s.Require().Equal(MockRateSource, stored.RateSource)
+		s.Require().Equal(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), stored.RateDate.UTC())
+	})
+
+	s.Run("expired quote", func() {
+		quote := saveQuote("13", time.Now().Add(-time.Second))
+
+		resp := deposit("10", &quote.ID, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	s.Run("quote of another amount", func() {
+		quote := saveQuote("13", time.Now().Add(time.Minute))
+
+		resp := deposit("20", &quote.ID, nil)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("quote of another operation type", func() {
+		quote := saveQuote("13", time.Now().Add(time.Minute))
+
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/withdraw",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal("10"),
+				Currency:      "CHY",
+				OperationType: models.OperationWithdraw,
+				QuoteID:       &quote.ID,
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("unknown quote", func() {
+		unknownID := uuid.New()
+
+		resp := deposit("10", &unknownID, nil)
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func (s *IntegrationTestSuite) TestExchangeRates() {
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "ratesUser",
+		Email:    "ratesUser@mail.com",
+		Phone:    "23",
+		Password: "password23",
+	}
+	authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+	s.Require().NoError(err)
+	err = s.store.UpsertUser(context.Background(), testUser)
+	s.Require().NoError(err)
+
+	s.authToken = authToken
+
+	s.Run("rate of a past date", func() {
+		rate := new(models.PairRate)
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodGet,
+			"/fx/rates?pair=CHY/RUR&date=2026-10-10",
+			nil,
+			&rest.HTTPResponse{Data: &rate},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal("CHY", rate.CurrencyFrom)
+		s.Require().Equal("RUR", rate.CurrencyTo)
+		s.Require().True(models.MustDecimal("12").Equal(rate.Rate))
+		s.Require().Equal(MockRateSource, rate.RateSource)
+		s.Require().Equal(time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC), rate.RateDate.UTC())
+	})
+
+	s.Run("current rate", func() {
+		rate := new(models.PairRate)
+		resp := s.sendAPIRequest(context.Background(), http.MethodGet, "/fx/rates?pair=AED/CHY", nil, &rest.HTTPResponse{Data: &rate})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().True(models.MustDecimal("2").Equal(rate.Rate))
+	})
+
+	s.Run("invalid requests", func() {
+		for _, query := range []string{"pair=CHYRUR", "pair=CHY/XXX", "pair=CHY/RUR&date=tomorrow", "pair=CHY/RUR&date=2999-01-01"} {
+			resp := s.sendAPIRequest(context.Background(), http.MethodGet, "/fx/rates?"+query, nil, nil)
+			s.Require().Equal(http.StatusBadRequest, resp.StatusCode, query)
+		}
+	})
+}
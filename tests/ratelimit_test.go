@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iurikman/cashFlowManager/internal/ratelimit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("burst then rejection with retry delay", func(t *testing.T) {
+		limiter := ratelimit.New(ratelimit.Limit{Rate: 1, Burst: 2})
+
+		for range 2 {
+			allowed, _ := limiter.Allow("user:1")
+			require.True(t, allowed)
+		}
+
+		allowed, retryAfter := limiter.Allow("user:1")
+		require.False(t, allowed)
+		require.Greater(t, retryAfter, time.Duration(0))
+		require.LessOrEqual(t, retryAfter, time.Second)
+	})
+
+	t.Run("keys have separate buckets", func(t *testing.T) {
+		limiter := ratelimit.New(ratelimit.Limit{Rate: 1, Burst: 1})
+
+		allowed, _ := limiter.Allow("user:1")
+		require.True(t, allowed)
+
+		allowed, _ = limiter.Allow("user:1")
+		require.False(t, allowed)
+
+		allowed, _ = limiter.Allow("ip:127.0.0.1")
+		require.True(t, allowed)
+	})
+
+	t.Run("tokens are refilled", func(t *testing.T) {
+		limiter := ratelimit.New(ratelimit.Limit{Rate: 50, Burst: 1})
+
+		allowed, _ := limiter.Allow("user:1")
+		require.True(t, allowed)
+
+		allowed, retryAfter := limiter.Allow("user:1")
+		require.False(t, allowed)
+
+		time.Sleep(retryAfter)
+
+		allowed, _ = limiter.Allow("user:1")
+		require.True(t, allowed)
+	})
+
+	t.Run("zero rate disables limiting", func(t *testing.T) {
+		limiter := ratelimit.New(ratelimit.Limit{})
+
+		for range 100 {
+			allowed, _ := limiter.Allow("user:1")
+			require.True(t, allowed)
+		}
+	})
+}
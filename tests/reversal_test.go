@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+)
+
+func (s *IntegrationTestSuite) TestReversal() {
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "reversalUser",
+		Email:    "reversalUser@mail.com",
+		Phone:    "8",
+		Password: "password8",
+	}
+	authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+	s.Require().NoError(err)
+	err = s.store.UpsertUser(context.Background(), testUser)
+	s.Require().NoError(err)
+
+	supportUser := models.User{
+		ID:       uuid.New(),
+		Username: "reversalSupport",
+		Email:    "reversalSupport@mail.com",
+		Phone:    "32",
+		Password: "password32",
+		Roles:    []string{models.RoleSupport},
+	}
+	supportToken, err := s.tokenGenerator.GetNewTokenString(supportUser)
+	s.Require().NoError(err)
+	s.Require().NoError(s.store.UpsertUser(context.Background(), supportUser))
+	s.Require().NoError(s.store.SetUserRoles(context.Background(), supportUser.ID, supportUser.Roles))
+
+	s.authToken = authToken
+	walletID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("1000"))
+
+	executedTransaction := new(models.Transaction)
+	resp := s.sendRequest(
+		context.Background(),
+		http.MethodPut,
+		"/withdraw",
+		models.Transaction{
+			WalletID:      walletID,
+			Amount:        models.MustDecimal("10"),
+			Currency:      "CHY",
+			OperationType: "withdraw",
+		},
+		&rest.HTTPResponse{Data: &executedTransaction},
+	)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	reverseEndpoint := "/admin/transactions/" + executedTransaction.TransactionID.String() + "/reverse"
+
+	// reverse sends the reversal request as support, reversals are not available to owners.
+	reverse := func(endpoint string, body, data any) *http.Response {
+		s.authToken = supportToken
+		defer func() { s.authToken = authToken }()
+
+		return s.sendAPIRequest(context.Background(), http.MethodPost, endpoint, body, data)
+	}
+
+	balance := func() models.Decimal {
+		wallet, err := s.store.GetWalletByID(context.Background(), walletID, testUser.ID)
+		s.Require().NoError(err)
+
+		return wallet.Balance
+	}
+
+	s.Require().True(models.MustDecimal("880").Equal(balance()))
+
+	s.Run("owner is refused", func() {
+		resp := s.sendAPIRequest(context.Background(), http.MethodPost, reverseEndpoint, nil, nil)
+		s.Require().Equal(http.StatusForbidden, resp.StatusCode)
+		s.Require().True(models.MustDecimal("880").Equal(balance()))
+	})
+
+	s.Run("partial refund", func() {
+		partial := models.MustDecimal("2.5")
+		reversal := new(models.Transaction)
+
+		resp := reverse(reverseEndpoint, models.Reversal{Amount: &partial}, &rest.HTTPResponse{Data: &reversal})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.OperationReversal, reversal.OperationType)
+		s.Require().Equal(executedTransaction.TransactionID, reversal.OriginalID)
+		s.Require().True(models.MustDecimal("30").Equal(reversal.ConvertedAmount))
+		s.Require().True(models.MustDecimal("910").Equal(balance()))
+	})
+
+	s.Run("refund exceeding the rest", func() {
+		tooMuch := models.MustDecimal("8")
+
+		resp := reverse(reverseEndpoint, models.Reversal{Amount: &tooMuch}, nil)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("full refund of the rest", func() {
+		resp := reverse(reverseEndpoint, nil, nil)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().True(models.MustDecimal("1000").Equal(balance()))
+	})
+
+	s.Run("double reversal", func() {
+		resp := reverse(reverseEndpoint, nil, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+	})
+
+	s.Run("unknown transaction", func() {
+		resp := reverse("/admin/transactions/"+uuid.New().String()+"/reverse", nil, nil)
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+
+	s.Run("reversed transfer goes back from the target wallet", func() {
+		targetWalletID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("1"))
+
+		transfer := new(models.Transaction)
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/transfer",
+			models.Transaction{
+				WalletID:       walletID,
+				TargetWalletID: targetWalletID,
+				Amount:         models.MustDecimal("100"),
+				Currency:       "RUR",
+				OperationType:  "transfer",
+			},
+			&rest.HTTPResponse{Data: &transfer},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		reversal := new(models.Transaction)
+		resp = reverse("/admin/transactions/"+transfer.TransactionID.String()+"/reverse", nil, &rest.HTTPResponse{Data: &reversal})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		reversalDirection := func(id uuid.UUID) string {
+			transactions := new([]models.Transaction)
+			resp := s.sendRequest(
+				context.Background(),
+				http.MethodGet,
+				"/"+id.String()+"/transactions?limit=100",
+				nil,
+				&rest.HTTPResponse{Data: &transactions},
+			)
+			s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+			for _, transaction := range *transactions {
+				if transaction.TransactionID == reversal.TransactionID {
+					return transaction.Direction
+				}
+			}
+
+			return ""
+		}
+
+		s.Require().Equal(models.DirectionIncoming, reversalDirection(walletID))
+		s.Require().Equal(models.DirectionOutgoing, reversalDirection(targetWalletID))
+	})
+
+	s.Run("wallet currency changed since the transaction", func() {
+		withdrawal := new(models.Transaction)
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/withdraw",
+			models.Transaction{
+				WalletID:      walletID,
+				Amount:        models.MustDecimal("10"),
+				Currency:      "RUR",
+				OperationType: "withdraw",
+			},
+			&rest.HTTPResponse{Data: &withdrawal},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal("RUR", withdrawal.BalanceCurrency)
+
+		resp = s.sendRequest(
+			context.Background(),
+			http.MethodPatch,
+			"/"+walletID.String(),
+			models.WalletDTO{Currency: toString("CHY")},
+			nil,
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		resp = reverse("/admin/transactions/"+withdrawal.TransactionID.String()+"/reverse", nil, nil)
+		s.Require().Equal(http.StatusConflict, resp.StatusCode)
+		s.Require().True(models.MustDecimal("82.5").Equal(balance()))
+	})
+
+	s.Run("ledger stays balanced", func() {
+		report, err := s.service.CheckLedger(context.Background())
+		s.Require().NoError(err)
+		s.Require().True(report.Balanced())
+	})
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+)
+
+func (s *IntegrationTestSuite) TestSchedules() {
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "schedulesUser",
+		Email:    "schedulesUser@mail.com",
+		Phone:    "10",
+		Password: "password10",
+	}
+	authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+	s.Require().NoError(err)
+	err = s.store.UpsertUser(context.Background(), testUser)
+	s.Require().NoError(err)
+
+	s.authToken = authToken
+	walletID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("1000"))
+	targetWalletID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("0"))
+
+	requireBalance := func(id uuid.UUID, balance string) {
+		wallet, err := s.store.GetWalletByID(context.Background(), id, testUser.ID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal(balance).Equal(wallet.Balance))
+	}
+
+	s.Run("invalid schedule", func() {
+		amount := models.MustDecimal("100")
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/schedules",
+			models.Schedule{
+				WalletID:      walletID,
+				OperationType: models.OperationWithdraw,
+				Amount:        &amount,
+				Currency:      "RUR",
+				Recurrence:    models.RecurrenceCron,
+				Cron:          "0 25 * * *",
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("transfer without target", func() {
+		amount := models.MustDecimal("100")
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/schedules",
+			models.Schedule{
+				WalletID:      walletID,
+				OperationType: models.OperationTransfer,
+				Amount:        &amount,
+				Currency:      "RUR",
+				Recurrence:    models.RecurrenceOnce,
+				RunAt:         time.Now().Add(time.Hour),
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("one-time transfer", func() {
+		amount := models.MustDecimal("100")
+		schedule := new(models.Schedule)
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/schedules",
+			models.Schedule{
+				Name:           "one-time",
+				WalletID:       walletID,
+				TargetWalletID: targetWalletID,
+				OperationType:  models.OperationTransfer,
+				Amount:         &amount,
+				Currency:       "RUR",
+				Recurrence:     models.RecurrenceOnce,
+				RunAt:          time.Now().Add(time.Second),
+			},
+			&rest.HTTPResponse{Data: &schedule},
+		)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+		s.Require().True(schedule.Active)
+		s.Require().NotNil(schedule.NextRunAt)
+
+		s.Require().NoError(s.service.RunDueSchedules(context.Background()))
+		requireBalance(walletID, "1000")
+
+		time.Sleep(1100 * time.Millisecond)
+
+		s.Require().NoError(s.service.RunDueSchedules(context.Background()))
+		s.Require().NoError(s.service.RunDueSchedules(context.Background()))
+		requireBalance(walletID, "900")
+		requireBalance(targetWalletID, "100")
+
+		runs := make([]*models.ScheduleRun, 0)
+		resp = s.sendAPIRequest(
+			context.Background(),
+			http.MethodGet,
+			"/schedules/"+schedule.ID.String()+"/runs",
+			nil,
+			&rest.HTTPResponse{Data: &runs},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(runs, 1)
+		s.Require().Equal(models.ScheduleRunSucceeded, runs[0].Status)
+		s.Require().NotEqual(uuid.Nil, runs[0].TransactionID)
+
+		resp = s.sendAPIRequest(
+			context.Background(),
+			http.MethodGet,
+			"/schedules/"+schedule.ID.String(),
+			nil,
+			&rest.HTTPResponse{Data: &schedule},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().False(schedule.Active)
+		s.Require().Nil(schedule.NextRunAt)
+	})
+
+	s.Run("failed run is recorded", func() {
+		amount := models.MustDecimal("5000")
+		schedule := new(models.Schedule)
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/schedules",
+			models.Schedule{
+				WalletID:      walletID,
+				OperationType: models.OperationWithdraw,
+				Amount:        &amount,
+				Currency:      "RUR",
+				Recurrence:    models.RecurrenceOnce,
+				RunAt:         time.Now().Add(time.Second),
+			},
+			&rest.HTTPResponse{Data: &schedule},
+		)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+		time.Sleep(1100 * time.Millisecond)
+
+		s.Require().NoError(s.service.RunDueSchedules(context.Background()))
+		requireBalance(walletID, "900")
+
+		runs, err := s.service.GetScheduleRuns(context.Background(), schedule.ID, testUser.ID, models.Params{Limit: 10})
+		s.Require().NoError(err)
+		s.Require().Len(runs, 1)
+		s.Require().Equal(models.ScheduleRunFailed, runs[0].Status)
+		s.Require().NotEmpty(runs[0].Error)
+	})
+
+	s.Run("delete", func() {
+		percent := models.MustDecimal("10")
+		day := 1
+		schedule := new(models.Schedule)
+		resp := s.sendAPIRequest(
+			context.Background(),
+			http.MethodPost,
+			"/schedules",
+			models.Schedule{
+				WalletID:      walletID,
+				OperationType: models.OperationWithdraw,
+				Percent:       &percent,
+				Recurrence:    models.RecurrenceMonthly,
+				DayOfMonth:    &day,
+			},
+			&rest.HTTPResponse{Data: &schedule},
+		)
+		s.Require().Equal(http.StatusCreated, resp.StatusCode)
+
+		resp = s.sendAPIRequest(context.Background(), http.MethodDelete, "/schedules/"+schedule.ID.String(), nil, nil)
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+
+		resp = s.sendAPIRequest(context.Background(), http.MethodGet, "/schedules/"+schedule.ID.String(), nil, nil)
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+)
+
+func (s *IntegrationTestSuite) TestTransfersBetweenUsers() {
+	sender := models.User{
+		ID:       uuid.New(),
+		Username: "sender",
+		Email:    "sender@mail.com",
+		Phone:    "6",
+		Password: "password6",
+	}
+	recipient := models.User{
+		ID:       uuid.New(),
+		Username: "recipient",
+		Email:    "recipient@mail.com",
+		Phone:    "7",
+		Password: "password7",
+	}
+
+	err := s.store.UpsertUser(context.Background(), sender)
+	s.Require().NoError(err)
+	err = s.store.UpsertUser(context.Background(), recipient)
+	s.Require().NoError(err)
+
+	recipientToken, err := s.tokenGenerator.GetNewTokenString(recipient)
+	s.Require().NoError(err)
+
+	s.authToken = recipientToken
+	recipientWalletID := s.createWalletForConverter(recipient.ID, "RUR", models.Decimal{})
+
+	senderToken, err := s.tokenGenerator.GetNewTokenString(sender)
+	s.Require().NoError(err)
+
+	s.authToken = senderToken
+	senderWalletID := s.createWalletForConverter(sender.ID, "RUR", models.MustDecimal("1000"))
+
+	s.Run("by wallet ID", func() {
+		executedTransaction := new(models.Transaction)
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/transfer",
+			models.Transaction{
+				WalletID:       senderWalletID,
+				TargetWalletID: recipientWalletID,
+				Amount:         models.MustDecimal("100"),
+				Currency:       "RUR",
+				OperationType:  "transfer",
+			},
+			&rest.HTTPResponse{Data: &executedTransaction},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(recipient.ID, executedTransaction.TargetOwnerID)
+
+		recipientWallet, err := s.store.GetWalletByID(context.Background(), recipientWalletID, recipient.ID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal("100").Equal(recipientWallet.Balance))
+
+		messages, err := s.store.ClaimOutboxMessages(context.Background(), 1000, 1000, time.Now())
+		s.Require().NoError(err)
+
+		directions := make([]string, 0)
+
+		for _, message := range messages {
+			if message.Key != executedTransaction.TransactionID.String() {
+				continue
+			}
+
+			var event models.Transaction
+
+			s.Require().NoError(json.Unmarshal(message.Payload, &event))
+
+			directions = append(directions, event.Direction)
+		}
+
+		s.Require().ElementsMatch([]string{models.DirectionOutgoing, models.DirectionIncoming}, directions)
+	})
+
+	s.Run("by recipient email", func() {
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/transfer",
+			models.Transaction{
+				WalletID:      senderWalletID,
+				Recipient:     recipient.Email,
+				Amount:        models.MustDecimal("50"),
+				Currency:      "RUR",
+				OperationType: "transfer",
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		recipientWallet, err := s.store.GetWalletByID(context.Background(), recipientWalletID, recipient.ID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal("150").Equal(recipientWallet.Balance))
+	})
+
+	s.Run("unknown recipient", func() {
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/transfer",
+			models.Transaction{
+				WalletID:      senderWalletID,
+				Recipient:     "nobody@mail.com",
+				Amount:        models.MustDecimal("50"),
+				Currency:      "RUR",
+				OperationType: "transfer",
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+
+	s.Run("deposit ignores client-supplied type and target", func() {
+		executedTransaction := new(models.Transaction)
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/deposit",
+			models.Transaction{
+				WalletID:       senderWalletID,
+				TargetWalletID: recipientWalletID,
+				TargetOwnerID:  recipient.ID,
+				OriginalID:     uuid.New(),
+				Amount:         models.MustDecimal("10"),
+				Currency:       "RUR",
+				OperationType:  "withdraw",
+			},
+			&rest.HTTPResponse{Data: &executedTransaction},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.OperationDeposit, executedTransaction.OperationType)
+		s.Require().Equal(uuid.Nil, executedTransaction.TargetWalletID)
+		s.Require().Equal(uuid.Nil, executedTransaction.TargetOwnerID)
+		s.Require().Equal(uuid.Nil, executedTransaction.OriginalID)
+
+		senderWallet, err := s.store.GetWalletByID(context.Background(), senderWalletID, sender.ID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal("860").Equal(senderWallet.Balance))
+	})
+
+	s.Run("recipient history shows incoming entries", func() {
+		s.authToken = recipientToken
+
+		transactions := new([]models.Transaction)
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodGet,
+			"/"+recipientWalletID.String()+"/transactions?limit=10&sorting=executed_at",
+			nil,
+			&rest.HTTPResponse{Data: &transactions},
+		)
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		incoming := 0
+
+		for _, transaction := range *transactions {
+			if transaction.Direction == models.DirectionIncoming {
+				s.Require().Equal(sender.ID, transaction.OwnerID)
+				s.Require().Equal(recipientWalletID, transaction.TargetWalletID)
+
+				incoming++
+			}
+		}
+
+		s.Require().Equal(2, incoming)
+	})
+	s.Run("recipient without active wallets is reported as unknown", func() {
+		s.authToken = senderToken
+
+		_, err := s.store.SetWalletStatus(context.Background(), recipientWalletID, models.WalletStatusFrozen)
+		s.Require().NoError(err)
+
+		resp := s.sendRequest(
+			context.Background(),
+			http.MethodPut,
+			"/transfer",
+			models.Transaction{
+				WalletID:      senderWalletID,
+				Recipient:     recipient.Email,
+				Amount:        models.MustDecimal("50"),
+				Currency:      "RUR",
+				OperationType: "transfer",
+			},
+			nil,
+		)
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+
+		_, err = s.store.SetWalletStatus(context.Background(), recipientWalletID, models.WalletStatusActive)
+		s.Require().NoError(err)
+	})
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+)
+
+func (s *IntegrationTestSuite) TestWalletRestore() {
+	ctx := context.Background()
+
+	testUser := models.User{
+		ID:       uuid.New(),
+		Username: "restoreUser",
+		Email:    "restoreUser@mail.com",
+		Phone:    "28",
+		Password: "password28",
+	}
+	authToken, err := s.tokenGenerator.GetNewTokenString(testUser)
+	s.Require().NoError(err)
+	s.Require().NoError(s.store.UpsertUser(ctx, testUser))
+
+	s.authToken = authToken
+	walletID := s.createWalletForConverter(testUser.ID, "RUR", models.MustDecimal("250"))
+	walletPath := "/wallets/" + walletID.String()
+
+	getDeletedWallets := func() []models.Wallet {
+		wallets := new([]models.Wallet)
+		resp := s.sendAPIRequest(ctx, http.MethodGet, "/wallets/deleted", nil, &rest.HTTPResponse{Data: &wallets})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		return *wallets
+	}
+
+	restore := func(status int) *models.Wallet {
+		wallet := new(models.Wallet)
+		resp := s.sendAPIRequest(ctx, http.MethodPost, walletPath+"/restore", nil, &rest.HTTPResponse{Data: &wallet})
+		s.Require().Equal(status, resp.StatusCode)
+
+		return wallet
+	}
+
+	deleteWallet := func() {
+		resp := s.sendAPIRequest(ctx, http.MethodDelete, walletPath, nil, nil)
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+	}
+
+	s.Run("active wallet can't be restored", func() {
+		restore(http.StatusNotFound)
+		s.Require().Empty(getDeletedWallets())
+	})
+
+	s.Run("deleted wallet is listed until its grace period ends", func() {
+		deleteWallet()
+
+		wallets := getDeletedWallets()
+		s.Require().Len(wallets, 1)
+		s.Require().Equal(walletID, wallets[0].ID)
+		s.Require().True(wallets[0].Deleted)
+		s.Require().NotNil(wallets[0].DeletedAt)
+		s.Require().NotNil(wallets[0].RestorableUntil)
+		s.Require().True(wallets[0].RestorableUntil.After(time.Now()))
+	})
+
+	s.Run("restored wallet keeps its balance", func() {
+		wallet := restore(http.StatusOK)
+		s.Require().False(wallet.Deleted)
+		s.Require().Nil(wallet.DeletedAt)
+		s.Require().True(models.MustDecimal("250").Equal(wallet.Balance), wallet.Balance.String())
+
+		s.Require().Empty(getDeletedWallets())
+		restore(http.StatusNotFound)
+	})
+
+	s.Run("purged wallet can't be restored", func() {
+		deleteWallet()
+
+		purged, err := s.store.PurgeWallets(ctx, time.Now().Add(time.Second))
+		s.Require().NoError(err)
+		s.Require().Positive(purged)
+
+		s.Require().Empty(getDeletedWallets())
+		restore(http.StatusConflict)
+
+		wallet, err := s.store.GetAnyWalletByID(ctx, walletID)
+		s.Require().NoError(err)
+		s.Require().NotNil(wallet.PurgedAt)
+	})
+
+	s.Run("wallet of another owner can't be restored", func() {
+		otherUser := models.User{
+			ID:       uuid.New(),
+			Username: "restoreOtherUser",
+			Email:    "restoreOtherUser@mail.com",
+			Phone:    "29",
+			Password: "password29",
+		}
+		s.Require().NoError(s.store.UpsertUser(ctx, otherUser))
+
+		s.authToken, err = s.tokenGenerator.GetNewTokenString(otherUser)
+		s.Require().NoError(err)
+
+		otherID := s.createWalletForConverter(otherUser.ID, "RUR", models.MustDecimal("0"))
+		resp := s.sendAPIRequest(ctx, http.MethodDelete, "/wallets/"+otherID.String(), nil, nil)
+		s.Require().Equal(http.StatusNoContent, resp.StatusCode)
+
+		s.authToken = authToken
+
+		resp = s.sendAPIRequest(ctx, http.MethodPost, "/wallets/"+otherID.String()+"/restore", nil, nil)
+		s.Require().Equal(http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/iurikman/cashFlowManager/internal/models"
+	"github.com/iurikman/cashFlowManager/internal/rest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletStatusTransitions(t *testing.T) {
+	wallet := models.Wallet{Status: models.WalletStatusActive}
+	require.NoError(t, wallet.CheckActive())
+	require.True(t, wallet.CanChangeStatus(models.WalletStatusClosing))
+
+	wallet.Status = models.WalletStatusFrozen
+	require.ErrorIs(t, wallet.CheckDebitable(), models.ErrWalletFrozen)
+	require.False(t, wallet.CanChangeStatus(models.WalletStatusClosing))
+
+	wallet.Status = models.WalletStatusClosing
+	require.ErrorIs(t, wallet.CheckActive(), models.ErrWalletClosing)
+	require.NoError(t, wallet.CheckDebitable())
+	require.False(t, wallet.CanChangeStatus(models.WalletStatusActive))
+
+	wallet.Status = models.WalletStatusClosed
+	require.ErrorIs(t, wallet.CheckDebitable(), models.ErrWalletClosed)
+
+	for _, status := range []string{models.WalletStatusActive, models.WalletStatusFrozen, models.WalletStatusClosing} {
+		require.False(t, wallet.CanChangeStatus(status))
+	}
+}
+
+func (s *IntegrationTestSuite) TestWalletLifecycle() {
+	ctx := context.Background()
+
+	newUser := func(name, phone string, roles ...string) (models.User, string) {
+		user := models.User{
+			ID:       uuid.New(),
+			Username: name,
+			Email:    name + "@mail.com",
+			Phone:    phone,
+			Password: "password" + phone,
+			Roles:    roles,
+		}
+		s.Require().NoError(s.store.UpsertUser(ctx, user))
+		s.Require().NoError(s.store.SetUserRoles(ctx, user.ID, roles))
+
+		authToken, err := s.tokenGenerator.GetNewTokenString(user)
+		s.Require().NoError(err)
+
+		return user, authToken
+	}
+
+	owner, ownerToken := newUser("lifecycleOwner", "25", models.RoleOwner)
+	_, supportToken := newUser("lifecycleSupport", "26", models.RoleSupport)
+	_, adminToken := newUser("lifecycleAdmin", "27", models.RoleAdmin)
+
+	s.authToken = ownerToken
+
+	walletID := s.createWalletForConverter(owner.ID, "RUR", models.MustDecimal("1000"))
+	targetID := s.createWalletForConverter(owner.ID, "CHY", models.MustDecimal("0"))
+
+	changeStatus := func(path string, request models.WalletStatusRequest, status int) *models.Wallet {
+		wallet := new(models.Wallet)
+		resp := s.sendAPIRequest(ctx, http.MethodPost, path, request, &rest.HTTPResponse{Data: &wallet})
+		s.Require().Equal(status, resp.StatusCode)
+
+		return wallet
+	}
+
+	operate := func(operation string, id uuid.UUID, amount string) int {
+		return s.sendRequest(
+			ctx,
+			http.MethodPut,
+			"/"+operation,
+			models.Transaction{
+				WalletID:      id,
+				Amount:        models.MustDecimal(amount),
+				Currency:      "RUR",
+				OperationType: operation,
+			},
+			nil,
+		).StatusCode
+	}
+
+	requireBalance := func(id uuid.UUID, balance string) {
+		wallet, err := s.store.GetWalletByID(ctx, id, owner.ID)
+		s.Require().NoError(err)
+		s.Require().True(models.MustDecimal(balance).Equal(wallet.Balance), wallet.Balance.String())
+	}
+
+	walletPath := "/wallets/" + walletID.String()
+
+	s.Run("owner freezes and unfreezes the wallet", func() {
+		wallet := changeStatus(walletPath+"/freeze", models.WalletStatusRequest{Reason: "lost card"}, http.StatusOK)
+		s.Require().Equal(models.WalletStatusFrozen, wallet.Status)
+
+		s.Require().Equal(http.StatusConflict, operate(models.OperationDeposit, walletID, "100"))
+
+		wallet = changeStatus(walletPath+"/unfreeze", models.WalletStatusRequest{}, http.StatusOK)
+		s.Require().Equal(models.WalletStatusActive, wallet.Status)
+	})
+
+	s.Run("owner can't unfreeze a wallet frozen by staff", func() {
+		s.authToken = supportToken
+		changeStatus("/admin"+walletPath+"/freeze", models.WalletStatusRequest{Reason: "fraud check"}, http.StatusOK)
+
+		s.authToken = ownerToken
+		changeStatus(walletPath+"/unfreeze", models.WalletStatusRequest{}, http.StatusConflict)
+		changeStatus(walletPath+"/close", models.WalletStatusRequest{}, http.StatusConflict)
+
+		s.authToken = supportToken
+		changeStatus("/admin"+walletPath+"/unfreeze", models.WalletStatusRequest{}, http.StatusOK)
+		changeStatus("/admin"+walletPath+"/close", models.WalletStatusRequest{}, http.StatusForbidden)
+	})
+
+	s.Run("wallet with a balance is closing until it is drained", func() {
+		s.authToken = ownerToken
+
+		wallet := changeStatus(walletPath+"/close", models.WalletStatusRequest{}, http.StatusOK)
+		s.Require().Equal(models.WalletStatusClosing, wallet.Status)
+
+		s.Require().Equal(http.StatusConflict, operate(models.OperationDeposit, walletID, "100"))
+		s.Require().Equal(http.StatusOK, operate(models.OperationWithdraw, walletID, "400"))
+
+		changeStatus(walletPath+"/close", models.WalletStatusRequest{}, http.StatusConflict)
+
+		s.Require().Equal(http.StatusOK, operate(models.OperationWithdraw, walletID, "600"))
+
+		wallet = changeStatus(walletPath+"/close", models.WalletStatusRequest{}, http.StatusOK)
+		s.Require().Equal(models.WalletStatusClosed, wallet.Status)
+
+		s.Require().Equal(http.StatusConflict, operate(models.OperationWithdraw, walletID, "1"))
+		changeStatus(walletPath+"/freeze", models.WalletStatusRequest{}, http.StatusConflict)
+	})
+
+	s.Run("status changes are recorded", func() {
+		changes := new([]models.WalletStatusChange)
+		resp := s.sendAPIRequest(ctx, http.MethodGet, walletPath+"/status-history", nil, &rest.HTTPResponse{Data: &changes})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Len(*changes, 6)
+		s.Require().Equal(models.WalletStatusClosed, (*changes)[0].StatusTo)
+		s.Require().Equal(models.WalletStatusClosing, (*changes)[0].StatusFrom)
+		s.Require().Equal(owner.ID, (*changes)[0].ChangedBy)
+		s.Require().Equal("lost card", (*changes)[5].Reason)
+	})
+
+	s.Run("close sweeps the balance to another wallet", func() {
+		sweptID := s.createWalletForConverter(owner.ID, "RUR", models.MustDecimal("120"))
+
+		changeStatus("/wallets/"+sweptID.String()+"/close", models.WalletStatusRequest{SweepTo: &sweptID}, http.StatusBadRequest)
+
+		wallet := changeStatus("/wallets/"+sweptID.String()+"/close", models.WalletStatusRequest{SweepTo: &targetID}, http.StatusOK)
+		s.Require().Equal(models.WalletStatusClosed, wallet.Status)
+
+		requireBalance(sweptID, "0")
+		requireBalance(targetID, "10")
+
+		transactions := new([]models.Transaction)
+		resp := s.sendRequest(ctx, http.MethodGet, "/"+targetID.String()+"/transactions", nil, &rest.HTTPResponse{Data: &transactions})
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+		s.Require().Equal(models.OperationSweep, (*transactions)[0].OperationType)
+	})
+
+	s.Run("admin closes a wallet of any owner", func() {
+		closedID := s.createWalletForConverter(owner.ID, "CHY", models.MustDecimal("5"))
+
+		s.authToken = adminToken
+
+		wallet := changeStatus("/admin/wallets/"+closedID.String()+"/close", models.WalletStatusRequest{SweepTo: &targetID}, http.StatusOK)
+		s.Require().Equal(models.WalletStatusClosed, wallet.Status)
+
+		requireBalance(targetID, "15")
+
+		report, err := s.service.CheckLedger(ctx)
+		s.Require().NoError(err)
+		s.Require().True(report.Balanced())
+	})
+}
@@ -3,6 +3,7 @@ package tests
 import (
 	"context"
 	"net/http"
+	"net/url"
 
 	"github.com/google/uuid"
 	"github.com/iurikman/cashFlowManager/internal/models"
@@ -44,13 +45,13 @@ func (s *IntegrationTestSuite) TestWallets() {
 	s.authToken = authToken1
 	listOfWallets = append(s.createWallets(amountOfWallets, testUserID1), listOfWallets...)
 
-	idRUR := s.createWalletForConverter(testUserID1, "RUR", 10000.0)
-	idAED := s.createWalletForConverter(testUserID1, "AED", 10000.0)
+	idRUR := s.createWalletForConverter(testUserID1, "RUR", models.MustDecimal("10000"))
+	idAED := s.createWalletForConverter(testUserID1, "AED", models.MustDecimal("10000"))
 
 	testAmountBelowZero := models.Transaction{
 		TransactionID: uuid.New(),
 		WalletID:      listOfWallets[2].ID,
-		Amount:        -1,
+		Amount:        models.MustDecimal("-1"),
 		Currency:      "AED",
 		OperationType: "deposit",
 	}
@@ -58,7 +59,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 	testWalletIDIsNil := models.Transaction{
 		TransactionID: uuid.New(),
 		WalletID:      uuid.Nil,
-		Amount:        10,
+		Amount:        models.MustDecimal("10"),
 		Currency:      "AED",
 		OperationType: "deposit",
 	}
@@ -66,7 +67,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 	testCurrencyNotAllowed := models.Transaction{
 		TransactionID: uuid.New(),
 		WalletID:      listOfWallets[0].ID,
-		Amount:        10,
+		Amount:        models.MustDecimal("10"),
 		Currency:      "NONECURRENCY",
 		OperationType: "deposit",
 	}
@@ -77,7 +78,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 			s.Require().Equal(http.StatusCreated, resp.StatusCode)
 			s.Require().Equal(testUserID1, createdWallet.Owner)
 			s.Require().Equal(listOfWallets[0].Currency, "RUR")
-			s.Require().Equal(createdWallet.Balance, 10.0)
+			s.Require().True(models.MustDecimal("10").Equal(createdWallet.Balance))
 		})
 
 		s.Run("400/statusBadRequest", func() {
@@ -195,7 +196,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 			s.Require().Equal(listOfWallets[0].ID, rWallet.ID)
 			s.Require().Equal(listOfWallets[0].Owner, rWallet.Owner)
 			s.Require().Equal(listOfWallets[0].Currency, rWallet.Currency)
-			s.Require().Equal(listOfWallets[0].Balance, rWallet.Balance)
+			s.Require().True(listOfWallets[0].Balance.Equal(rWallet.Balance))
 			s.Require().Equal(listOfWallets[0].Deleted, rWallet.Deleted)
 		})
 
@@ -224,6 +225,80 @@ func (s *IntegrationTestSuite) TestWallets() {
 		})
 	})
 
+	s.Run("GET list", func() {
+		s.Run("200/statusOK(filter by currency)", func() {
+			wallets := new([]models.Wallet)
+
+			resp := s.sendRequest(
+				context.Background(),
+				http.MethodGet,
+				"?filterCurrency=AED",
+				nil,
+				&rest.HTTPResponse{Data: &wallets},
+			)
+			s.Require().Equal(http.StatusOK, resp.StatusCode)
+			s.Require().Len(*wallets, 1)
+			s.Require().Equal(idAED, (*wallets)[0].ID)
+		})
+
+		s.Run("200/statusOK(filter by balance)", func() {
+			wallets := new([]models.Wallet)
+
+			resp := s.sendRequest(
+				context.Background(),
+				http.MethodGet,
+				"?balanceFrom=10000&sorting=currency",
+				nil,
+				&rest.HTTPResponse{Data: &wallets},
+			)
+			s.Require().Equal(http.StatusOK, resp.StatusCode)
+			s.Require().Len(*wallets, 2)
+			s.Require().Equal(idAED, (*wallets)[0].ID)
+			s.Require().Equal(idRUR, (*wallets)[1].ID)
+		})
+
+		s.Run("200/statusOK(pagination)", func() {
+			wallets := new([]models.Wallet)
+
+			resp := s.sendRequest(
+				context.Background(),
+				http.MethodGet,
+				"?limit=2&offset=1&sorting=created_at",
+				nil,
+				&rest.HTTPResponse{Data: &wallets},
+			)
+			s.Require().Equal(http.StatusOK, resp.StatusCode)
+			s.Require().Len(*wallets, 2)
+
+			for _, wallet := range *wallets {
+				s.Require().Equal(testUserID1, wallet.Owner)
+			}
+		})
+
+		s.Run("400/statusBadRequest(sorting not allowed)", func() {
+			resp := s.sendRequest(
+				context.Background(),
+				http.MethodGet,
+				"?sorting=owner",
+				nil,
+				nil,
+			)
+			s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+		})
+
+		s.Run("400/statusBadRequest(negative pagination)", func() {
+			for _, params := range []string{"?limit=-1", "?offset=-1"} {
+				resp := s.sendRequest(context.Background(), http.MethodGet, params, nil, nil)
+				s.Require().Equal(http.StatusBadRequest, resp.StatusCode, params)
+			}
+		})
+
+		s.Run("200/statusOK(limit over the maximum page size)", func() {
+			resp := s.sendRequest(context.Background(), http.MethodGet, "?limit=1000000000", nil, nil)
+			s.Require().Equal(http.StatusOK, resp.StatusCode)
+		})
+	})
+
 	s.Run("PATCH", func() {
 		s.Run("200/statusOK", func() {
 			rWallet := new(models.Wallet)
@@ -364,7 +439,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 			testDepositOperation := models.Transaction{
 				TransactionID: uuid.New(),
 				WalletID:      listOfWallets[0].ID,
-				Amount:        1000,
+				Amount:        models.MustDecimal("1000"),
 				Currency:      "CHY",
 				OperationType: "deposit",
 			}
@@ -387,7 +462,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 				TransactionID:  uuid.New(),
 				WalletID:       listOfWallets[0].ID,
 				TargetWalletID: listOfWallets[1].ID,
-				Amount:         1,
+				Amount:         models.MustDecimal("1"),
 				Currency:       "CHY",
 				OperationType:  "transfer",
 			}
@@ -407,7 +482,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 			testWithdrawOperation := models.Transaction{
 				TransactionID: uuid.New(),
 				WalletID:      listOfWallets[0].ID,
-				Amount:        1,
+				Amount:        models.MustDecimal("1"),
 				Currency:      "CHY",
 				OperationType: "withdraw",
 			}
@@ -425,7 +500,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 			testConverterWithdrawCHYfromRUR := models.Transaction{
 				TransactionID: uuid.New(),
 				WalletID:      idRUR,
-				Amount:        10.0,
+				Amount:        models.MustDecimal("10"),
 				Currency:      "CHY",
 				OperationType: "withdraw",
 			}
@@ -439,14 +514,14 @@ func (s *IntegrationTestSuite) TestWallets() {
 			)
 			updatedWallet, _ := s.store.GetWalletByID(context.Background(), idRUR, testUserID1)
 			s.Require().Equal(http.StatusOK, resp.StatusCode)
-			s.Require().Equal(9990.0, updatedWallet.Balance)
+			s.Require().True(models.MustDecimal("9880").Equal(updatedWallet.Balance))
 		})
 
 		s.Run("200/statusOK(deposit/test converter deposit CHY to RUR)", func() {
 			testTransaction := models.Transaction{
 				TransactionID: uuid.New(),
 				WalletID:      idRUR,
-				Amount:        10.0,
+				Amount:        models.MustDecimal("10"),
 				Currency:      "CHY",
 				OperationType: "deposit",
 			}
@@ -461,7 +536,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 			updatedWallet, _ := s.store.GetWalletByID(context.Background(), idRUR, testUserID1)
 
 			s.Require().Equal(http.StatusOK, resp.StatusCode)
-			s.Require().Equal(10000.0, updatedWallet.Balance)
+			s.Require().True(models.MustDecimal("10000").Equal(updatedWallet.Balance))
 		})
 
 		s.Run("200/statusOK(transfer/test converter transfer AMD to RUR)", func() {
@@ -469,7 +544,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 				TransactionID:  uuid.New(),
 				WalletID:       idAED,
 				TargetWalletID: idRUR,
-				Amount:         10.0,
+				Amount:         models.MustDecimal("10"),
 				Currency:       "AED",
 				OperationType:  "transfer",
 			}
@@ -485,8 +560,8 @@ func (s *IntegrationTestSuite) TestWallets() {
 			updatedWalletFrom, _ := s.store.GetWalletByID(context.Background(), idAED, testUserID1)
 
 			s.Require().Equal(http.StatusOK, resp.StatusCode)
-			s.Require().Equal(10240.0, updatedWalletTo.Balance)
-			s.Require().Equal(9990.0, updatedWalletFrom.Balance)
+			s.Require().True(models.MustDecimal("10240").Equal(updatedWalletTo.Balance))
+			s.Require().True(models.MustDecimal("9990").Equal(updatedWalletFrom.Balance))
 		})
 
 		s.Run("400/StatusBadRequest(deposit/amount below zero)", func() {
@@ -611,7 +686,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 				TransactionID:  uuid.New(),
 				WalletID:       listOfWallets[0].ID,
 				TargetWalletID: listOfWallets[1].ID,
-				Amount:         100000000,
+				Amount:         models.MustDecimal("100000000"),
 				Currency:       "CHY",
 				OperationType:  "transfer",
 			}
@@ -632,7 +707,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 			testWithdrawBalanceBelowZero := models.Transaction{
 				TransactionID: uuid.New(),
 				WalletID:      listOfWallets[0].ID,
-				Amount:        100000000,
+				Amount:        models.MustDecimal("100000000"),
 				Currency:      "CHY",
 				OperationType: "withdraw",
 			}
@@ -661,7 +736,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 			testOwnerUUIDNotFound := models.Transaction{
 				TransactionID: uuid.New(),
 				WalletID:      uuid.New(),
-				Amount:        400,
+				Amount:        models.MustDecimal("400"),
 				Currency:      "AED",
 				OperationType: "deposit",
 			}
@@ -681,7 +756,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 				TransactionID:  uuid.New(),
 				WalletID:       listOfWallets[4].ID,
 				TargetWalletID: uuid.New(),
-				Amount:         50,
+				Amount:         models.MustDecimal("50"),
 				Currency:       "CHY",
 				OperationType:  "transfer",
 			}
@@ -700,7 +775,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 			testDeletedTrue := models.Transaction{
 				TransactionID: uuid.New(),
 				WalletID:      listOfWallets[0].ID,
-				Amount:        400,
+				Amount:        models.MustDecimal("400"),
 				Currency:      "AED",
 				OperationType: "transfer",
 			}
@@ -734,7 +809,7 @@ func (s *IntegrationTestSuite) TestWallets() {
 					s.Require().Equal(listOfWallets[0].ID, transaction.WalletID)
 				}
 
-				s.Require().Equal(testTransaction.Amount, (*transactions)[2].Amount)
+				s.Require().True(testTransaction.Amount.Equal((*transactions)[2].Amount))
 				s.Require().Equal(testTransaction.Currency, (*transactions)[2].Currency)
 				s.Require().Equal(testTransaction.OperationType, (*transactions)[2].OperationType)
 			})
@@ -751,6 +826,18 @@ func (s *IntegrationTestSuite) TestWallets() {
 				s.Require().Equal(http.StatusUnprocessableEntity, resp.StatusCode)
 			})
 
+			s.Run("400/sortingNotAllowed", func() {
+				params := "?limit=10&sorting=" + url.QueryEscape("executed_at; DROP TABLE wallets")
+				resp := s.sendRequest(
+					context.Background(),
+					http.MethodGet,
+					"/"+listOfWallets[0].ID.String()+"/transactions"+params,
+					nil,
+					nil,
+				)
+				s.Require().Equal(http.StatusBadRequest, resp.StatusCode)
+			})
+
 			s.Run("404/StatusNotFound", func() {
 				params := "?limit=10&sorting=executed_at&descending=true"
 				id := uuid.New()
@@ -810,7 +897,7 @@ func (s *IntegrationTestSuite) testCreateWallet(testUserID uuid.UUID) (models.Wa
 		TransactionID: uuid.New(),
 		WalletID:      createdWallet.ID,
 		OwnerID:       createdWallet.Owner,
-		Amount:        10.0,
+		Amount:        models.MustDecimal("10"),
 		Currency:      createdWallet.Currency,
 		OperationType: "deposit",
 	}
@@ -835,7 +922,7 @@ func (s *IntegrationTestSuite) testCreateWallet(testUserID uuid.UUID) (models.Wa
 	return *wallet, *resp
 }
 
-func (s *IntegrationTestSuite) createWalletForConverter(testOwnerID uuid.UUID, currency string, balance float64) uuid.UUID {
+func (s *IntegrationTestSuite) createWalletForConverter(testOwnerID uuid.UUID, currency string, balance models.Decimal) uuid.UUID {
 	var createdWallet models.Wallet
 	testWallet := models.Wallet{
 		Owner:    testOwnerID,